@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+// runContext lists the files glob adds to the context and how many secrets are redacted from each of them,
+// showRedactions lists every redaction with its line and a preview of the secret
+func runContext(cfg *config.BrainsConfig, glob string, showRedactions bool) error {
+	fs, err := file_system.NewFileSystemConfig()
+	if err != nil {
+		return err
+	}
+	fs.SetDenyPatterns(cfg.DenyFiles())
+	files, err := fs.Glob(glob)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files matched pattern %s", glob)
+	}
+	redactor := cfg.Redactor()
+	if redactor == nil {
+		pterm.Warning.Println("redaction is disabled, files are sent as they are")
+	}
+
+	fileData := pterm.TableData{{"File", "Bytes", "Redactions"}}
+	redactionData := pterm.TableData{{"File", "Line", "Kind", "Preview", "Placeholder"}}
+	for _, path := range files {
+		if fs.IsDenied(path) {
+			fileData = append(fileData, []string{path, "-", "denied, not sent"})
+			continue
+		}
+		contents, err := fs.GetFileContents(path)
+		if err != nil || contents == "" {
+			continue
+		}
+		_, findings := redactor.Find(contents)
+		fileData = append(fileData, []string{path, strconv.Itoa(len(contents)), strconv.Itoa(len(findings))})
+		for _, f := range findings {
+			redactionData = append(redactionData, []string{path, strconv.Itoa(f.Line), f.Kind, f.Preview, f.Placeholder})
+		}
+	}
+
+	if err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(fileData).Render(); err != nil {
+		return err
+	}
+	if !showRedactions {
+		return nil
+	}
+	if len(redactionData) == 1 {
+		pterm.Info.Println("nothing was redacted")
+		return nil
+	}
+	return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(redactionData).Render()
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v2"
+
+	"github.com/madhuravius/brains/internal/aws"
+	"github.com/madhuravius/brains/internal/config"
+)
+
+// noPersona is offered next to the selected personas when picking the default one.
+const noPersona = "(none)"
+
+// defaultOpenAIBaseURL is where Ollama serves its OpenAI-compatible API.
+const defaultOpenAIBaseURL = "http://localhost:11434/v1"
+
+// contextOptions are the context_config settings offered by init, in the order they are listed.
+var contextOptions = []string{"send_file_list", "summarize_logs", "send_logs", "send_all_tags"}
+
+// prompter asks the init questions, with yes every question takes its default answer.
+type prompter struct {
+	yes bool
+}
+
+func (p prompter) text(label, defaultValue string) (string, error) {
+	if p.yes {
+		return defaultValue, nil
+	}
+	return pterm.DefaultInteractiveTextInput.WithDefaultText(label).WithDefaultValue(defaultValue).Show()
+}
+
+func (p prompter) choose(label string, options []string, defaultOption string) (string, error) {
+	if p.yes || len(options) == 0 {
+		return defaultOption, nil
+	}
+	return pterm.DefaultInteractiveSelect.WithDefaultText(label).WithOptions(options).WithDefaultOption(defaultOption).WithFilter(len(options) > 10).Show()
+}
+
+func (p prompter) chooseMany(label string, options, defaultOptions []string) ([]string, error) {
+	if p.yes {
+		return defaultOptions, nil
+	}
+	return pterm.DefaultInteractiveMultiselect.WithDefaultText(label).WithOptions(options).WithDefaultOptions(defaultOptions).WithFilter(false).Show()
+}
+
+func (p prompter) confirm(label string, defaultValue bool) (bool, error) {
+	if p.yes {
+		return defaultValue, nil
+	}
+	return pterm.DefaultInteractiveConfirm.WithDefaultText(label).WithDefaultValue(defaultValue).Show()
+}
+
+// runInit walks through the region, model, personas and context and writes a commented ".brains.yml" at the
+// repository root, the values already configured by other layers are offered as defaults.
+func runInit(c *cli.Context, current *config.BrainsConfig) error {
+	p := prompter{yes: c.Bool("yes")}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root, inRepo := config.FindRepoRoot(cwd)
+	if !inRepo {
+		pterm.Warning.Printfln("%s is not in a git repository", cwd)
+		ok, err := p.confirm("Write the config to this directory anyway?", false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("init cancelled, run it from the repository brains should work on")
+		}
+		root = cwd
+	}
+	path := filepath.Join(root, config.ConfigFile)
+	if _, err := os.Stat(path); err == nil {
+		ok, err := p.confirm(fmt.Sprintf("%s already exists, overwrite it?", path), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("init cancelled, %s was left unchanged", path)
+		}
+	}
+
+	cfg := &config.BrainsConfig{
+		LoggingEnabled: true,
+		Research:       current.Research,
+	}
+	defaultRegion := current.AWSRegion
+	if current.Origin("aws_region") == config.OriginDefault {
+		if detected := aws.DetectRegion(c.Context, current.AWSProfile); detected != "" {
+			defaultRegion = detected
+		}
+	}
+	if cfg.AWSRegion, err = p.text("AWS region", defaultRegion); err != nil {
+		return err
+	}
+	if cfg.Provider, err = p.choose("Provider", []string{config.ProviderBedrock, config.ProviderOpenAI}, current.Provider); err != nil {
+		return err
+	}
+	if cfg.Provider == config.ProviderOpenAI {
+		defaultBaseURL := current.OpenAI.BaseURL
+		if defaultBaseURL == "" {
+			defaultBaseURL = defaultOpenAIBaseURL
+		}
+		if cfg.OpenAI.BaseURL, err = p.text("OpenAI-compatible base URL", defaultBaseURL); err != nil {
+			return err
+		}
+		cfg.OpenAI.APIKeyEnv = current.OpenAI.APIKeyEnv
+		if cfg.Model, err = p.text("Model name served by it", current.Model); err != nil {
+			return err
+		}
+	} else if cfg.Model, err = chooseBedrockModel(c, p, current, cfg.AWSRegion); err != nil {
+		return err
+	}
+
+	presets := make([]string, 0, len(config.PersonaPresets))
+	for name := range config.PersonaPresets {
+		presets = append(presets, name)
+	}
+	sort.Strings(presets)
+	personas, err := p.chooseMany("Personas", presets, []string{"dev"})
+	if err != nil {
+		return err
+	}
+	cfg.Personas = map[string]string{}
+	for _, name := range personas {
+		cfg.Personas[name] = config.PersonaPresets[name]
+	}
+	defaultPersona := noPersona
+	if slices.Contains(personas, "dev") {
+		defaultPersona = "dev"
+	}
+	if cfg.DefaultPersona, err = p.choose("Default persona", append(slices.Clone(personas), noPersona), defaultPersona); err != nil {
+		return err
+	}
+	if cfg.DefaultPersona == noPersona {
+		cfg.DefaultPersona = ""
+	}
+
+	if cfg.DefaultContext, err = p.text("Files sent as context by default (glob)", current.DefaultContext); err != nil {
+		return err
+	}
+	contextConfig, err := p.chooseMany("Extra context", contextOptions, []string{"send_file_list", "summarize_logs"})
+	if err != nil {
+		return err
+	}
+	cfg.ContextConfig = config.ContextConfig{
+		SendFileList:  slices.Contains(contextConfig, "send_file_list"),
+		SummarizeLogs: slices.Contains(contextConfig, "summarize_logs"),
+		SendLogs:      slices.Contains(contextConfig, "send_logs"),
+		SendAllTags:   slices.Contains(contextConfig, "send_all_tags"),
+	}
+
+	if err := config.WriteConfigFile(path, cfg); err != nil {
+		return err
+	}
+	pterm.Success.Printfln("wrote %s", path)
+	added, err := config.EnsureGitignore(root, config.GitignoreEntries...)
+	if err != nil {
+		return err
+	}
+	if len(added) > 0 {
+		pterm.Info.Printfln("added %v to %s", added, filepath.Join(root, ".gitignore"))
+	}
+	pterm.Info.Println("run \"brains health\" to check credentials and model access")
+	return nil
+}
+
+// chooseBedrockModel lists the text models with tool use granted in region, falling back to typing a model ID
+// when they can't be listed (ex: no credentials yet).
+func chooseBedrockModel(c *cli.Context, p prompter, current *config.BrainsConfig, region string) (string, error) {
+	awsImpl := aws.NewAWSConfig(region)
+	if awsImpl == nil {
+		return p.text("Model ID", current.Model)
+	}
+	awsImpl.SetEndpoint(current.EndpointURL)
+	awsImpl.SetCredentials(current.AWSProfile, current.AssumeRole)
+	if !awsImpl.SetAndValidateCredentials() {
+		pterm.Warning.Println("unable to validate credentials, models can't be listed")
+		return p.text("Model ID", current.Model)
+	}
+	models, err := awsImpl.ListModels(c.Context, aws.ModelFilter{Modality: "TEXT", ToolUse: true})
+	if err != nil {
+		pterm.Warning.Printfln("listing models failed: %v", err)
+		return p.text("Model ID", current.Model)
+	}
+	var modelIDs []string
+	for _, model := range models {
+		if model.Access == aws.ModelAccessGranted {
+			modelIDs = append(modelIDs, model.ModelID)
+		}
+	}
+	if len(modelIDs) == 0 {
+		pterm.Warning.Printfln("no model with tool use is accessible in %s, request access in the Bedrock console", region)
+		return p.text("Model ID", current.Model)
+	}
+	defaultModel := modelIDs[0]
+	if slices.Contains(modelIDs, current.Model) {
+		defaultModel = current.Model
+	}
+	return p.choose("Model", modelIDs, defaultModel)
+}
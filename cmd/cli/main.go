@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v2"
@@ -10,11 +13,14 @@ import (
 	"github.com/madhuravius/brains/internal/aws"
 	"github.com/madhuravius/brains/internal/config"
 	"github.com/madhuravius/brains/internal/core"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/openai"
+	"github.com/madhuravius/brains/internal/prompts"
 )
 
 // CLIConfig holds the top‑level command‑line options.
 type CLIConfig struct {
-	awsConfig    aws.AWSImpl
+	llmConfig    llm.LLMImpl
 	brainsConfig config.BrainsConfigImpl
 	coreConfig   core.CoreImpl
 	persona      string
@@ -28,7 +34,7 @@ func generateCommonFlags(cliConfig *CLIConfig, cfg *config.BrainsConfig) []cli.F
 			Aliases:     []string{"p"},
 			Name:        "persona",
 			Value:       cfg.DefaultPersona,
-			Usage:       "Supply a persona with a corresponding value in \".brains.yml\" to use as part of the prompt.",
+			Usage:       "Supply a persona from \".brains.yml\" or \".brains/personas\" to use as part of the prompt.",
 			Destination: &cliConfig.persona,
 		},
 		&cli.StringFlag{
@@ -41,14 +47,211 @@ func generateCommonFlags(cliConfig *CLIConfig, cfg *config.BrainsConfig) []cli.F
 	}
 }
 
-// validateAWSCredentials checks that the supplied AWS credentials are valid.
-func (c *CLIConfig) validateAWSCredentials() {
-	if !c.awsConfig.SetAndValidateCredentials() {
+// configFlags maps the global flags to the config key they override, flags are the highest config layer.
+var configFlags = map[string]string{
+	"model":             "model",
+	"region":            "aws_region",
+	"provider":          "provider",
+	"aws-profile":       "aws_profile",
+	"role-arn":          "assume_role.role_arn",
+	"role-session-name": "assume_role.session_name",
+	"external-id":       "assume_role.external_id",
+	"role-duration":     "assume_role.duration",
+	"mfa-serial":        "assume_role.mfa_serial",
+	"no-hooks":          "hooks.disabled",
+	"commit":            "git.auto_commit",
+}
+
+// generateConfigFlags registers global flags that override the settings in ".brains.yml".
+func generateConfigFlags(cfg *config.BrainsConfig) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "model",
+			Value: cfg.Model,
+			Usage: "Model to use for this run",
+		},
+		&cli.StringFlag{
+			Name:  "region",
+			Value: cfg.AWSRegion,
+			Usage: "AWS region to use for this run",
+		},
+		&cli.StringFlag{
+			Name:  "provider",
+			Value: cfg.Provider,
+			Usage: "Provider to use for this run (bedrock or openai)",
+		},
+		&cli.StringFlag{
+			Name:  "aws-profile",
+			Value: cfg.AWSProfile,
+			Usage: "Shared config profile to load credentials from",
+		},
+		&cli.StringFlag{
+			Name:  "role-arn",
+			Value: cfg.AssumeRole.RoleARN,
+			Usage: "IAM role to assume on top of the base credentials",
+		},
+		&cli.StringFlag{
+			Name:  "role-session-name",
+			Value: cfg.AssumeRole.SessionName,
+			Usage: "Session name used when assuming --role-arn",
+		},
+		&cli.StringFlag{
+			Name:  "external-id",
+			Value: cfg.AssumeRole.ExternalID,
+			Usage: "External ID required by the trust policy of --role-arn",
+		},
+		&cli.DurationFlag{
+			Name:  "role-duration",
+			Value: cfg.AssumeRole.Duration,
+			Usage: "Lifetime of the assumed role session (ex: 1h)",
+		},
+		&cli.StringFlag{
+			Name:  "mfa-serial",
+			Value: cfg.AssumeRole.MFASerial,
+			Usage: "Serial or ARN of the MFA device required to assume --role-arn",
+		},
+		&cli.BoolFlag{
+			Name:  "no-hooks",
+			Value: cfg.Hooks.Disabled,
+			Usage: "Skip pre_commands and post_edit_commands for this run",
+		},
+		&cli.BoolFlag{
+			Name:  "commit",
+			Value: cfg.Git.AutoCommit,
+			Usage: "Commit the edits of brains code for this run, see git.auto_commit",
+		},
+		&cli.StringFlag{
+			Name:  "session",
+			Usage: "Session to record and read history from for this run, see \"brains session\"",
+		},
+	}
+}
+
+// applyConfigFlags layers the global flags that were set on top of the loaded configuration.
+func applyConfigFlags(c *cli.Context, cfg *config.BrainsConfig) error {
+	for flagName, key := range configFlags {
+		if !c.IsSet(flagName) {
+			continue
+		}
+		if err := cfg.SetFlag(flagName, key, c.String(flagName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// personaRequest resolves --persona into the request for the ask and code commands, the persona model and
+// context apply unless --model or --add were given
+func (c *CLIConfig) personaRequest(ctx *cli.Context, prompt string) (*core.LLMRequest, error) {
+	cfg := c.brainsConfig.GetConfig()
+	persona, err := c.brainsConfig.ResolvePersona(c.persona)
+	if err != nil {
+		return nil, err
+	}
+	command := ctx.Command.Name
+	if !persona.AllowsCommand(command) {
+		return nil, fmt.Errorf("persona %s can't run %s, it is limited to %s", persona.Name, command, strings.Join(persona.Commands, ", "))
+	}
+	req := &core.LLMRequest{
+		Prompt:              prompt,
+		PersonaInstructions: persona.PromptInstructions(),
+		ModelID:             cfg.Model,
+		Glob:                c.glob,
+		Tools:               persona.Tools,
+	}
+	if persona.Model != "" && !strings.HasPrefix(cfg.Origin("model"), "flag ") {
+		req.ModelID = persona.Model
+	}
+	if persona.Context != "" && !ctx.IsSet("add") {
+		req.Glob = persona.Context
+	}
+	c.llmConfig.SetTemperature(persona.Temperature)
+	return req, nil
+}
+
+// validateCredentials checks that the configured provider is reachable with valid credentials.
+func (c *CLIConfig) validateCredentials() {
+	if !c.llmConfig.SetAndValidateCredentials() {
 		pterm.Error.Println("unable to validate credentials")
 		os.Exit(1)
 	}
 }
 
+// logFilter reads the filter flags of brains log
+func logFilter(c *cli.Context, cfg *config.BrainsConfig) (config.LogFilter, error) {
+	filter := config.LogFilter{RunID: c.String("run")}
+	if !c.Bool("all-sessions") {
+		filter.Session = cfg.Session()
+	}
+	types, err := config.ParseEventTypes(c.StringSlice("type"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Types = types
+	if since := c.String("since"); since != "" {
+		if filter.Since, err = config.ParseSince(since); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// approveHooks lists the hook commands a repository wants to run and asks before trusting them, declining is
+// the default so a freshly cloned repository never runs anything unattended.
+func approveHooks(dir string, commands, previous []string) (bool, error) {
+	if len(previous) > 0 {
+		pterm.Warning.Printfln("the hook commands of %s changed since they were approved", dir)
+		pterm.Println("previously approved:")
+		for _, command := range previous {
+			pterm.Println("  " + command)
+		}
+	} else {
+		pterm.Warning.Printfln("%s wants to run hook commands", dir)
+	}
+	pterm.Println("commands:")
+	for _, command := range commands {
+		pterm.Println("  " + command)
+	}
+	return pterm.DefaultInteractiveConfirm.WithDefaultText("Trust and run these commands?").WithDefaultValue(false).Show()
+}
+
+// requireBedrockProvider guards commands that only make sense against Bedrock.
+func requireBedrockProvider(cfg *config.BrainsConfig) cli.BeforeFunc {
+	return func(c *cli.Context) error {
+		if cfg.Provider != config.ProviderBedrock {
+			return fmt.Errorf("%s is only supported with the %q provider", c.Command.FullName(), config.ProviderBedrock)
+		}
+		return nil
+	}
+}
+
+// validateConfig prints configuration problems and fails when any of them is not a warning.
+func validateConfig(cfg *config.BrainsConfig, awsImpl aws.AWSImpl) error {
+	var isKnownModel func(string) bool
+	if cfg.Provider == config.ProviderBedrock {
+		isKnownModel = awsImpl.HasPricing
+	}
+	failed := 0
+	for _, problem := range cfg.Validate(isKnownModel) {
+		if problem.Warning {
+			pterm.Warning.Println(problem.String())
+			continue
+		}
+		pterm.Error.Println(problem.String())
+		failed++
+	}
+	if _, err := prompts.Load(cfg.PromptsPath()); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			pterm.Error.Println(line)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("configuration has %d problem(s), fix them or run \"brains config show --origin\" to see where values come from", failed)
+	}
+	return nil
+}
+
 // main parses flags, validates configuration and dispatches sub‑commands.
 func main() {
 	brainsConfig, err := config.LoadConfig()
@@ -57,44 +260,132 @@ func main() {
 		os.Exit(1)
 	}
 
-	awsImpl := aws.NewAWSConfig(brainsConfig.GetConfig().AWSRegion)
-	awsImpl.SetLogger(brainsConfig.GetConfig())
-
-	coreConfig := core.NewCoreConfig(awsImpl, brainsConfig)
-	coreConfig.SetLogger(brainsConfig.GetConfig())
+	cliConfig := &CLIConfig{brainsConfig: brainsConfig}
+	var awsImpl aws.AWSImpl
+	var llmImpl llm.LLMImpl
+	// providers are built once flags are layered on, so --model, --region and --provider apply to them
+	setupProviders := func(cfg *config.BrainsConfig) error {
+		awsImpl = aws.NewAWSConfig(cfg.AWSRegion)
+		awsImpl.SetLogger(cfg)
+		awsImpl.SetGuardrail(cfg.Guardrail)
+		awsImpl.SetEndpoint(cfg.EndpointURL)
+		awsImpl.SetCredentials(cfg.AWSProfile, cfg.AssumeRole)
 
-	cliConfig := &CLIConfig{
-		awsConfig:    awsImpl,
-		brainsConfig: brainsConfig,
-		coreConfig:   coreConfig,
-	}
+		switch cfg.Provider {
+		case config.ProviderBedrock:
+			llmImpl = awsImpl
+		case config.ProviderOpenAI:
+			llmImpl = openai.NewOpenAIConfig(cfg.OpenAI)
+			llmImpl.SetLogger(cfg)
+		default:
+			return fmt.Errorf("unknown provider %q, expected %q or %q", cfg.Provider, config.ProviderBedrock, config.ProviderOpenAI)
+		}
+		llmImpl.SetReasoningBudget(cfg.ReasoningBudget)
 
-	if err := brainsConfig.GetConfig().PreCommandsHook(); err != nil {
-		pterm.Error.Println("error on precommands hook execution")
-		os.Exit(1)
+		coreConfig := core.NewCoreConfig(llmImpl, brainsConfig)
+		coreConfig.SetLogger(cfg)
+		cliConfig.llmConfig = llmImpl
+		cliConfig.coreConfig = coreConfig
+		return nil
 	}
 
 	app := &cli.App{
 		Name:  "brains",
 		Usage: "a simple LLM wrapper using AWS Bedrock",
+		Flags: generateConfigFlags(brainsConfig.GetConfig()),
+		Before: func(c *cli.Context) error {
+			if err := applyConfigFlags(c, brainsConfig.GetConfig()); err != nil {
+				return err
+			}
+			if c.IsSet("session") {
+				if err := brainsConfig.GetConfig().SetSession(c.String("session")); err != nil {
+					return err
+				}
+			}
+			command := c.Args().First()
+			if slices.Contains([]string{"", "help", "h", "init"}, command) {
+				return nil
+			}
+			if command != "config" {
+				if err := brainsConfig.GetConfig().CreateDefaultConfig(); err != nil {
+					return err
+				}
+			}
+			if slices.Contains([]string{"persona", "prompts", "context", "log", "reset", "session", "undo"}, command) {
+				// personas, prompts, the context, the event log, sessions and undo are files and config, no provider is needed for them
+				return nil
+			}
+			if err := setupProviders(brainsConfig.GetConfig()); err != nil {
+				return err
+			}
+			if command == "config" {
+				// config commands have to work with a broken configuration to help fixing it
+				return nil
+			}
+			if err := validateConfig(brainsConfig.GetConfig(), awsImpl); err != nil {
+				return err
+			}
+			if err := brainsConfig.GetConfig().TrustHooks(approveHooks); err != nil {
+				return err
+			}
+			if err := brainsConfig.GetConfig().PreCommandsHook(); err != nil {
+				return fmt.Errorf("error on precommands hook execution: %w", err)
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
+			{
+				Name:  "init",
+				Usage: "walk through the region, model, personas and context and write \".brains.yml\" at the repository root",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "yes",
+						Aliases: []string{"y"},
+						Usage:   "Accept the default answers without prompting",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return runInit(c, brainsConfig.GetConfig())
+				},
+			},
 			{
 				Name:  "health",
 				Usage: "verify functionality and connections",
 				Action: func(c *cli.Context) error {
 					pterm.Info.Println("health checks starting")
-					cliConfig.validateAWSCredentials()
+					cliConfig.validateCredentials()
+					if brainsConfig.GetConfig().Provider == config.ProviderBedrock {
+						awsImpl.PrintIdentity()
+					}
 					if !cliConfig.coreConfig.ValidateBedrockConfiguration(cliConfig.brainsConfig.GetConfig().Model) {
-						pterm.Error.Println("unable to access bedrock")
+						pterm.Error.Println("unable to access model")
 						os.Exit(1)
 					}
 					pterm.Success.Println("health check complete")
 					return nil
 				},
 			},
+			{
+				Name:      "context",
+				Usage:     "list the files sent as context for a glob (default_context when unset) and the secrets redacted from them",
+				ArgsUsage: "[glob]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "show-redactions",
+						Usage: "List every redacted secret with its file, line and kind",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					glob := c.Args().Get(0)
+					if glob == "" {
+						glob = brainsConfig.GetConfig().DefaultContext
+					}
+					return runContext(brainsConfig.GetConfig(), glob, c.Bool("show-redactions"))
+				},
+			},
 			{
 				Name:  "ask",
-				Usage: "send a prompt to the Bedrock model and display the response",
+				Usage: "send a prompt to the configured model and display the response",
 				Flags: generateCommonFlags(cliConfig, brainsConfig.GetConfig()),
 				Action: func(c *cli.Context) error {
 					prompt := c.Args().Get(0)
@@ -102,14 +393,12 @@ func main() {
 						textInput := pterm.DefaultInteractiveTextInput.WithMultiLine()
 						prompt, _ = textInput.Show()
 					}
-					cliConfig.validateAWSCredentials()
-					personaInstructions := cliConfig.brainsConfig.GetPersonaInstructions(cliConfig.persona)
-					if err = cliConfig.coreConfig.AskFlow(context.Background(), &core.LLMRequest{
-						Prompt:              prompt,
-						PersonaInstructions: personaInstructions,
-						ModelID:             cliConfig.brainsConfig.GetConfig().Model,
-						Glob:                cliConfig.glob,
-					}); err != nil {
+					req, err := cliConfig.personaRequest(c, prompt)
+					if err != nil {
+						return err
+					}
+					cliConfig.validateCredentials()
+					if err = cliConfig.coreConfig.AskFlow(context.Background(), req); err != nil {
 						pterm.Error.Println("error on ask flow execution")
 						os.Exit(1)
 					}
@@ -119,7 +408,7 @@ func main() {
 			},
 			{
 				Name:  "code",
-				Usage: "send a prompt to the Bedrock model and execute coding actions",
+				Usage: "send a prompt to the configured model and execute coding actions",
 				Flags: generateCommonFlags(cliConfig, brainsConfig.GetConfig()),
 				Action: func(c *cli.Context) error {
 					prompt := c.Args().Get(0)
@@ -127,14 +416,12 @@ func main() {
 						textInput := pterm.DefaultInteractiveTextInput.WithMultiLine()
 						prompt, _ = textInput.Show()
 					}
-					cliConfig.validateAWSCredentials()
-					personaInstructions := cliConfig.brainsConfig.GetPersonaInstructions(cliConfig.persona)
-					if err = cliConfig.coreConfig.CodeFlow(context.Background(), &core.LLMRequest{
-						Prompt:              prompt,
-						PersonaInstructions: personaInstructions,
-						ModelID:             cliConfig.brainsConfig.GetConfig().Model,
-						Glob:                cliConfig.glob,
-					}); err != nil {
+					req, err := cliConfig.personaRequest(c, prompt)
+					if err != nil {
+						return err
+					}
+					cliConfig.validateCredentials()
+					if err = cliConfig.coreConfig.CodeFlow(context.Background(), req); err != nil {
 						pterm.Error.Println("error on code flow execution")
 						os.Exit(1)
 					}
@@ -143,38 +430,436 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:      "tokens",
+				Usage:     "estimate offline how many tokens files add to the context, defaults to default_context",
+				ArgsUsage: "[glob]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "model",
+						Usage: "Count with the tokenizer of another model than the configured one",
+						Value: brainsConfig.GetConfig().Model,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					glob := c.Args().Get(0)
+					if glob == "" {
+						glob = brainsConfig.GetConfig().DefaultContext
+					}
+					if err := cliConfig.coreConfig.CountTokens(glob, c.String("model")); err != nil {
+						pterm.Error.Printfln("counting tokens failed: %v", err)
+						return err
+					}
+					return nil
+				},
+			},
 			{
 				Name:  "pricing",
 				Usage: "print information on bedrock prices and selected model",
 				Action: func(c *cli.Context) error {
-					if err := awsImpl.PrintPricing(brainsConfig.GetConfig().Model); err != nil {
+					if err := llmImpl.PrintPricing(brainsConfig.GetConfig().Model); err != nil {
 						pterm.Error.Printfln("pricing failed: %v", err)
 						return err
 					}
 					return nil
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "refresh",
+						Usage: "rebuild pricing for the configured region from the AWS Price List API into \"~/.brains/pricing.json\"",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "from-file",
+								Usage: "Read a saved Price List API export (ex: aws pricing get-products --service-code AmazonBedrock)",
+							},
+						},
+						Before: requireBedrockProvider(brainsConfig.GetConfig()),
+						Action: func(c *cli.Context) error {
+							cliConfig.validateCredentials()
+							modelsPricing, err := awsImpl.RefreshPricing(c.Context, c.String("from-file"))
+							if err != nil {
+								pterm.Error.Printfln("pricing refresh failed: %v", err)
+								return err
+							}
+							path, _ := aws.PricingCachePath()
+							pterm.Success.Printfln("pricing for %d models written to %s", len(modelsPricing), path)
+							return awsImpl.PrintPricing(brainsConfig.GetConfig().Model)
+						},
+					},
+				},
+			},
+			{
+				Name:  "models",
+				Usage: "list foundation models and inference profiles available in the configured region",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "Only list models from a provider (ex: Anthropic, Meta)",
+					},
+					&cli.StringFlag{
+						Name:  "modality",
+						Usage: "Only list models with an output modality (TEXT, IMAGE, EMBEDDING)",
+					},
+					&cli.BoolFlag{
+						Name:  "streaming",
+						Usage: "Only list models that support response streaming",
+					},
+					&cli.BoolFlag{
+						Name:  "tool-use",
+						Usage: "Only list models that support tool use",
+					},
+				},
+				Before: requireBedrockProvider(brainsConfig.GetConfig()),
+				Action: func(c *cli.Context) error {
+					cliConfig.validateCredentials()
+					models, err := awsImpl.ListModels(c.Context, aws.ModelFilter{
+						Provider:  c.String("provider"),
+						Modality:  c.String("modality"),
+						Streaming: c.Bool("streaming"),
+						ToolUse:   c.Bool("tool-use"),
+					})
+					if err != nil {
+						pterm.Error.Printfln("listing models failed: %v", err)
+						return err
+					}
+					return awsImpl.PrintModels(models, brainsConfig.GetConfig().Model)
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "use",
+						Usage:     "set the model used by brains in \".brains.yml\"",
+						ArgsUsage: "<model-id>",
+						Action: func(c *cli.Context) error {
+							modelID := c.Args().Get(0)
+							if modelID == "" {
+								return fmt.Errorf("a model id is required, see \"brains models\" for options")
+							}
+							cliConfig.validateCredentials()
+							models, err := awsImpl.ListModels(c.Context, aws.ModelFilter{})
+							if err != nil {
+								pterm.Error.Printfln("listing models failed: %v", err)
+								return err
+							}
+							var selected *aws.ModelListing
+							for idx := range models {
+								if models[idx].ModelID == modelID {
+									selected = &models[idx]
+									break
+								}
+							}
+							if selected == nil {
+								return fmt.Errorf("model %s is not available in %s", modelID, brainsConfig.GetConfig().AWSRegion)
+							}
+							if selected.Access != aws.ModelAccessGranted {
+								pterm.Warning.Printfln("access to %s is %s for this account", modelID, selected.Access)
+							}
+							if err := brainsConfig.GetConfig().SetModel(modelID); err != nil {
+								pterm.Error.Printfln("unable to update configuration: %v", err)
+								return err
+							}
+							pterm.Success.Printfln("now using model %s", modelID)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "inspect the configuration merged from defaults, config files, env vars and flags",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "show",
+						Usage: "print the effective configuration",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "origin",
+								Usage: "Show the layer that set each key",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return brainsConfig.GetConfig().PrintConfig(c.Bool("origin"))
+						},
+					},
+					{
+						Name:  "validate",
+						Usage: "check config files, env vars and flags for unknown keys, invalid values and missing personas or models",
+						Action: func(c *cli.Context) error {
+							if err := validateConfig(brainsConfig.GetConfig(), awsImpl); err != nil {
+								return err
+							}
+							pterm.Success.Println("configuration is valid")
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "persona",
+				Usage: "manage the personas defined in \".brains.yml\" and \".brains/personas\"",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "list every persona and where it is defined",
+						Action: func(c *cli.Context) error {
+							return brainsConfig.GetConfig().PrintPersonas()
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "print a persona with the settings it extends resolved",
+						ArgsUsage: "<name>",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return fmt.Errorf("expected a persona name")
+							}
+							return brainsConfig.GetConfig().PrintPersona(c.Args().First())
+						},
+					},
+					{
+						Name:      "new",
+						Usage:     "create a persona file in \".brains/personas\"",
+						ArgsUsage: "<name>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "extends",
+								Usage: "Persona to inherit instructions and settings from",
+							},
+							&cli.StringFlag{
+								Name:  "description",
+								Usage: "Short description shown by \"brains persona list\"",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return fmt.Errorf("expected a persona name")
+							}
+							persona := &config.Persona{
+								Name:         c.Args().First(),
+								Extends:      c.String("extends"),
+								Description:  c.String("description"),
+								Instructions: "Describe how the assistant should behave.",
+							}
+							if persona.Extends != "" {
+								if _, err := brainsConfig.ResolvePersona(persona.Extends); err != nil {
+									return err
+								}
+							}
+							path, err := brainsConfig.GetConfig().NewPersonaFile(persona)
+							if err != nil {
+								return err
+							}
+							pterm.Success.Printfln("wrote %s, edit its instructions and settings", path)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "prompts",
+				Usage: "inspect the prompt templates and override them from \".brains/prompts\"",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "list every template, where it comes from and the variables it must reference",
+						Action: func(c *cli.Context) error {
+							templates, err := prompts.Load(brainsConfig.GetConfig().PromptsPath())
+							if err != nil {
+								return err
+							}
+							return templates.Print()
+						},
+					},
+					{
+						Name:      "show",
+						Usage:     "print a template as it is used, with its override applied",
+						ArgsUsage: "<name>",
+						Action: func(c *cli.Context) error {
+							templates, err := prompts.Load(brainsConfig.GetConfig().PromptsPath())
+							if err != nil {
+								return err
+							}
+							tmpl, err := templates.Get(c.Args().First())
+							if err != nil {
+								return err
+							}
+							tmpl.Print()
+							return nil
+						},
+					},
+					{
+						Name:      "eject",
+						Usage:     "copy the default of a template into \".brains/prompts\" to edit it",
+						ArgsUsage: "<name>",
+						Action: func(c *cli.Context) error {
+							path, err := prompts.Eject(brainsConfig.GetConfig().PromptsPath(), c.Args().First())
+							if err != nil {
+								return err
+							}
+							pterm.Success.Printfln("wrote %s, it replaces the default until it is removed", path)
+							return nil
+						},
+					},
+				},
 			},
 			{
 				Name:  "log",
-				Usage: "print all logs",
+				Usage: "print the event log of the current session, filtered by type, time and run",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "type",
+						Usage: "Only print events of these types (ex: request,response,code_response,tool)",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only print events after a duration ago (ex: 2h) or a date (ex: 2006-01-02)",
+					},
+					&cli.StringFlag{
+						Name:  "run",
+						Usage: "Only print the events of a run ID, \"last\" for the latest run",
+					},
+					&cli.BoolFlag{
+						Name:  "all-sessions",
+						Usage: "Print the events of every session",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the events as JSON lines",
+					},
+				},
 				Action: func(c *cli.Context) error {
-					cliConfig.brainsConfig.GetConfig().PrintLogs()
-					pterm.Success.Println("logs printed")
-					return nil
+					filter, err := logFilter(c, cliConfig.brainsConfig.GetConfig())
+					if err != nil {
+						return err
+					}
+					return cliConfig.brainsConfig.GetConfig().PrintLogs(filter, c.Bool("json"))
 				},
 			},
 			{
 				Name:  "reset",
-				Usage: "clear all logs",
+				Usage: "clear the logs of the current session",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Clear the logs of every session",
+					},
+				},
 				Action: func(c *cli.Context) error {
-					if err := cliConfig.brainsConfig.GetConfig().Reset(); err != nil {
+					cfg := cliConfig.brainsConfig.GetConfig()
+					if c.Bool("all") {
+						if err := cfg.ResetAll(); err != nil {
+							pterm.Error.Printfln("reset failed: %v", err)
+							return err
+						}
+						pterm.Success.Println("logs of every session cleared")
+						return nil
+					}
+					if err := cfg.Reset(); err != nil {
 						pterm.Error.Printfln("reset failed: %v", err)
 						return err
 					}
-					pterm.Success.Println("logs cleared")
+					pterm.Success.Printfln("logs of session %s cleared", cfg.Session())
 					return nil
 				},
 			},
+			{
+				Name:      "undo",
+				Usage:     "restore the files edited by brains code in a run, the latest run by default",
+				ArgsUsage: "[run-id]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "list",
+						Usage: "List the runs that can be undone",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Restore files even if they changed after the run edited them",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return runUndo(c.Args().First(), c.Bool("list"), c.Bool("force"))
+				},
+			},
+			{
+				Name:  "session",
+				Usage: "keep separate histories for unrelated tasks, the active session is kept between runs",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "list the sessions of the event log, the active one is starred",
+						Action: func(c *cli.Context) error {
+							return cliConfig.brainsConfig.GetConfig().PrintSessions()
+						},
+					},
+					{
+						Name:      "switch",
+						Usage:     "make a session the active one, it is created with its first event",
+						ArgsUsage: "<name>",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return fmt.Errorf("expected a session name")
+							}
+							name := c.Args().First()
+							if err := cliConfig.brainsConfig.GetConfig().SwitchSession(name); err != nil {
+								return err
+							}
+							pterm.Success.Printfln("switched to session %s", name)
+							return nil
+						},
+					},
+					{
+						Name:      "delete",
+						Usage:     "remove the history of a session",
+						ArgsUsage: "<name>",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return fmt.Errorf("expected a session name")
+							}
+							name := c.Args().First()
+							if err := cliConfig.brainsConfig.GetConfig().DeleteSession(name); err != nil {
+								return err
+							}
+							pterm.Success.Printfln("deleted session %s", name)
+							return nil
+						},
+					},
+					{
+						Name:      "export",
+						Usage:     "write the events of a session as JSON lines, the current session by default",
+						ArgsUsage: "[name]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "File to write the events to instead of stdout",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							cfg := cliConfig.brainsConfig.GetConfig()
+							name := cfg.Session()
+							if c.Args().Present() {
+								name = c.Args().First()
+							}
+							output := c.String("output")
+							if output == "" {
+								return cfg.ExportSession(name, os.Stdout)
+							}
+							f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 -- path given by the user
+							if err != nil {
+								return err
+							}
+							if err := cfg.ExportSession(name, f); err != nil {
+								_ = f.Close()
+								return err
+							}
+							if err := f.Close(); err != nil {
+								return err
+							}
+							pterm.Success.Printfln("exported session %s to %s", name, output)
+							return nil
+						},
+					},
+				},
+			},
 		},
 	}
 
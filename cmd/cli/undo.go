@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+// runUndo restores the files edited by a run, the latest one when runID is empty, or lists the runs with list
+func runUndo(runID string, list, force bool) error {
+	fs, err := file_system.NewFileSystemConfig()
+	if err != nil {
+		return err
+	}
+	if list {
+		snapshots, err := fs.Snapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			pterm.Info.Println("no edits to undo")
+			return nil
+		}
+		tableData := pterm.TableData{{"Run", "Time", "Files"}}
+		for _, s := range snapshots {
+			tableData = append(tableData, []string{s.RunID, s.Time.Local().Format(time.DateTime), fmt.Sprint(len(s.Files))})
+		}
+		return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+	}
+
+	s, err := fs.Undo(runID, force)
+	if err != nil {
+		return err
+	}
+	for _, file := range s.Files {
+		if file.Existed {
+			pterm.Success.Printfln("restored %s", file.Path)
+		} else {
+			pterm.Success.Printfln("removed %s", file.Path)
+		}
+	}
+	pterm.Success.Printfln("undid the edits of run %s", s.RunID)
+	return nil
+}
@@ -36,12 +36,16 @@ func main() {
 	coreConfig := core.NewCoreConfig(awsConfig, brainsConfig)
 	coreConfig.SetLogger(brainsConfig.GetConfig())
 
-	personaInstructions := brainsConfig.GetPersonaInstructions("dev")
+	persona, err := brainsConfig.ResolvePersona("dev")
+	if err != nil {
+		pterm.Error.Printf("failed to resolve persona: %v\n", err)
+		os.Exit(1)
+	}
 
 	req := &core.LLMRequest{
 		Glob:                glob,
 		ModelID:             modelID,
-		PersonaInstructions: personaInstructions,
+		PersonaInstructions: persona.PromptInstructions(),
 		Prompt:              prompt,
 	}
 
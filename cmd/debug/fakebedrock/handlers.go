@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+const (
+	fakeAccount = "123456789012"
+	fakeArn     = "arn:aws:iam::123456789012:user/fakebedrock"
+)
+
+type server struct {
+	rules  []rule
+	models []string
+	region string
+}
+
+type invokeRequest struct {
+	System   string `json:"system"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+type converseBlock struct {
+	Text    *string `json:"text,omitempty"`
+	ToolUse *struct {
+		ToolUseID string `json:"toolUseId"`
+		Name      string `json:"name"`
+		Input     any    `json:"input"`
+	} `json:"toolUse,omitempty"`
+	ToolResult *struct {
+		Content []struct {
+			Text *string `json:"text,omitempty"`
+			JSON any     `json:"json,omitempty"`
+		} `json:"content"`
+	} `json:"toolResult,omitempty"`
+}
+
+type converseRequest struct {
+	System []struct {
+		Text string `json:"text"`
+	} `json:"system"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content []converseBlock `json:"content"`
+	} `json:"messages"`
+	ToolConfig *struct {
+		Tools []struct {
+			ToolSpec struct {
+				Name string `json:"name"`
+			} `json:"toolSpec"`
+		} `json:"tools"`
+	} `json:"toolConfig"`
+}
+
+type callerIdentityResponse struct {
+	XMLName xml.Name `xml:"GetCallerIdentityResponse"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Result  struct {
+		Arn     string `xml:"Arn"`
+		UserID  string `xml:"UserId"`
+		Account string `xml:"Account"`
+	} `xml:"GetCallerIdentityResult"`
+	RequestID string `xml:"ResponseMetadata>RequestId"`
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /model/{modelId}/invoke", s.handleInvoke)
+	mux.HandleFunc("POST /model/{modelId}/converse", s.handleConverse)
+	mux.HandleFunc("GET /foundation-models", s.handleListFoundationModels)
+	mux.HandleFunc("GET /inference-profiles", s.handleListInferenceProfiles)
+	mux.HandleFunc("GET /foundation-model-availability/{modelId}", s.handleModelAvailability)
+	mux.HandleFunc("POST /{$}", s.handleSTS)
+	return mux
+}
+
+func (s *server) handleInvoke(w http.ResponseWriter, r *http.Request) {
+	var req invokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
+	prompt := []string{req.System}
+	for _, m := range req.Messages {
+		prompt = append(prompt, invokeContentText(m.Content))
+	}
+	text := strings.Join(prompt, "\n")
+
+	matched := matchRule(s.rules, text, nil)
+	if matched == nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", "no rule matches the prompt")
+		return
+	}
+	answer := matched.Text
+	if matched.ToolUse != nil {
+		data, _ := json.Marshal(matched.ToolUse)
+		answer = string(data)
+	}
+	if matched.Reasoning != "" {
+		answer = "<reasoning>" + matched.Reasoning + "</reasoning>" + answer
+	}
+	pterm.Info.Printfln("invoke %s matched %q", r.PathValue("modelId"), matched.Match)
+
+	writeJSON(w, map[string]any{
+		"choices": []any{
+			map[string]any{"message": map[string]any{"role": "assistant", "content": answer}},
+		},
+		"usage": map[string]any{
+			"prompt_tokens":     estimateTokens(text),
+			"completion_tokens": estimateTokens(answer),
+		},
+	})
+}
+
+func (s *server) handleConverse(w http.ResponseWriter, r *http.Request) {
+	var req converseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
+	var prompt []string
+	for _, system := range req.System {
+		prompt = append(prompt, system.Text)
+	}
+	for _, m := range req.Messages {
+		for _, block := range m.Content {
+			prompt = append(prompt, converseBlockText(block))
+		}
+	}
+	text := strings.Join(prompt, "\n")
+
+	var tools []string
+	if req.ToolConfig != nil {
+		for _, t := range req.ToolConfig.Tools {
+			tools = append(tools, t.ToolSpec.Name)
+		}
+	}
+
+	matched := matchRule(s.rules, text, tools)
+	if matched == nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", "no rule matches the prompt")
+		return
+	}
+	pterm.Info.Printfln("converse %s matched %q", r.PathValue("modelId"), matched.Match)
+
+	content := []any{}
+	if matched.Reasoning != "" {
+		content = append(content, map[string]any{"reasoningContent": map[string]any{
+			"reasoningText": map[string]any{"text": matched.Reasoning, "signature": "fakebedrock"},
+		}})
+	}
+	stopReason := "end_turn"
+	answer := matched.Text
+	if matched.ToolUse != nil && len(tools) > 0 {
+		toolName := tools[0]
+		if matched.Tool != "" {
+			toolName = matched.Tool
+		}
+		content = append(content, map[string]any{"toolUse": map[string]any{
+			"toolUseId": "tooluse_fakebedrock",
+			"name":      toolName,
+			"input":     matched.ToolUse,
+		}})
+		stopReason = "tool_use"
+		data, _ := json.Marshal(matched.ToolUse)
+		answer = string(data)
+	} else {
+		if matched.ToolUse != nil {
+			data, _ := json.Marshal(matched.ToolUse)
+			answer = string(data)
+		}
+		content = append(content, map[string]any{"text": answer})
+	}
+
+	inputTokens, outputTokens := estimateTokens(text), estimateTokens(matched.Reasoning+answer)
+	writeJSON(w, map[string]any{
+		"output":     map[string]any{"message": map[string]any{"role": "assistant", "content": content}},
+		"stopReason": stopReason,
+		"usage": map[string]any{
+			"inputTokens":  inputTokens,
+			"outputTokens": outputTokens,
+			"totalTokens":  inputTokens + outputTokens,
+		},
+		"metrics": map[string]any{"latencyMs": 1},
+	})
+}
+
+func (s *server) handleListFoundationModels(w http.ResponseWriter, r *http.Request) {
+	summaries := []any{}
+	for _, modelID := range s.models {
+		provider, _, _ := strings.Cut(modelID, ".")
+		summaries = append(summaries, map[string]any{
+			"modelArn":                   fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/%s", s.region, modelID),
+			"modelId":                    modelID,
+			"modelName":                  modelID,
+			"providerName":               provider,
+			"inputModalities":            []string{"TEXT"},
+			"outputModalities":           []string{"TEXT"},
+			"responseStreamingSupported": true,
+			"inferenceTypesSupported":    []string{"ON_DEMAND"},
+			"modelLifecycle":             map[string]any{"status": "ACTIVE"},
+		})
+	}
+	writeJSON(w, map[string]any{"modelSummaries": summaries})
+}
+
+func (s *server) handleListInferenceProfiles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"inferenceProfileSummaries": []any{}})
+}
+
+func (s *server) handleModelAvailability(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"modelId":                 r.PathValue("modelId"),
+		"agreementAvailability":   map[string]any{"status": "AVAILABLE"},
+		"authorizationStatus":     "AUTHORIZED",
+		"entitlementAvailability": "AVAILABLE",
+		"regionAvailability":      "AVAILABLE",
+	})
+}
+
+// handleSTS answers the STS query protocol, only GetCallerIdentity is supported
+func (s *server) handleSTS(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("Action") != "GetCallerIdentity" {
+		writeError(w, http.StatusBadRequest, "InvalidAction", "only GetCallerIdentity is supported")
+		return
+	}
+	resp := callerIdentityResponse{
+		Xmlns:     "https://sts.amazonaws.com/doc/2011-06-15/",
+		RequestID: "fakebedrock",
+	}
+	resp.Result.Arn = fakeArn
+	resp.Result.UserID = "AIDAFAKEBEDROCK"
+	resp.Result.Account = fakeAccount
+
+	w.Header().Set("Content-Type", "text/xml")
+	_ = xml.NewEncoder(w).Encode(resp)
+}
+
+// invokeContentText accepts both string content and a list of typed content blocks
+func invokeContentText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []struct {
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(raw, &blocks)
+	var parts []string
+	for _, b := range blocks {
+		parts = append(parts, b.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func converseBlockText(block converseBlock) string {
+	switch {
+	case block.Text != nil:
+		return *block.Text
+	case block.ToolUse != nil:
+		return block.ToolUse.Name
+	case block.ToolResult != nil:
+		var parts []string
+		for _, c := range block.ToolResult.Content {
+			if c.Text != nil {
+				parts = append(parts, *c.Text)
+			} else {
+				data, _ := json.Marshal(c.JSON)
+				parts = append(parts, string(data))
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// estimateTokens uses the common four characters per token approximation
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Amzn-ErrorType", code)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+	pterm.Warning.Printfln("%s: %s", code, message)
+}
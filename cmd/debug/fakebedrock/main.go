@@ -0,0 +1,37 @@
+// fakebedrock serves the Bedrock runtime, Bedrock and STS wire protocols from scripted rules so the
+// brains binary can be run end to end without AWS, ex:
+//
+//	go run ./cmd/debug/fakebedrock -addr 127.0.0.1:4010 -rules cmd/debug/fakebedrock/rules.example.yml
+//	BRAINS_BEDROCK_ENDPOINT=http://127.0.0.1:4010 AWS_ACCESS_KEY_ID=fake AWS_SECRET_ACCESS_KEY=fake brains health
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:4010", "address to listen on")
+	rulesPath := flag.String("rules", "", "YAML file of scripted rules, built-in defaults are used as a fallback")
+	models := flag.String("models", "openai.gpt-oss-120b-1:0,anthropic.claude-3-5-haiku-20241022-v1:0", "comma separated model IDs returned by ListFoundationModels")
+	region := flag.String("region", "us-east-1", "region used in model ARNs")
+	flag.Parse()
+
+	rules, err := loadRules(*rulesPath)
+	if err != nil {
+		pterm.Fatal.Printfln("loadRules: %v", err)
+	}
+
+	s := &server{
+		rules:  rules,
+		models: strings.Split(*models, ","),
+		region: *region,
+	}
+	pterm.Info.Printfln("fake bedrock listening on http://%s with %d rules", *addr, len(rules))
+	if err := http.ListenAndServe(*addr, s.routes()); err != nil {
+		pterm.Fatal.Printfln("ListenAndServe: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rule scripts a single response, the first rule whose pattern matches the prompt wins
+type rule struct {
+	// Match is a regular expression run against the text of every message in the request
+	Match string `yaml:"match"`
+	// Tool restricts the rule to Converse requests offering a tool with this name
+	Tool string `yaml:"tool,omitempty"`
+	// Text is returned as a plain text answer
+	Text string `yaml:"text,omitempty"`
+	// ToolUse is returned as the input of a tool call to the first tool offered
+	ToolUse map[string]any `yaml:"tool_use,omitempty"`
+	// Reasoning is returned as a reasoning block (Converse) or inline <reasoning> tags (InvokeModel)
+	Reasoning string `yaml:"reasoning,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+type rulesFile struct {
+	Rules []rule `yaml:"rules"`
+}
+
+// defaultRules keep health, ask and code flows working when no rules file is supplied, research ends
+// straight away with the catch-all text answer
+var defaultRules = []rule{
+	{
+		Match: "(?i)health check",
+		Text:  "Health check successful, connection established.",
+	},
+	{
+		Match: ".*",
+		Tool:  "coder",
+		ToolUse: map[string]any{
+			"markdown_summary":  "fake bedrock made no changes",
+			"code_updates":      []any{},
+			"add_code_files":    []any{},
+			"remove_code_files": []any{},
+		},
+	},
+	{
+		Match: ".*",
+		Text:  "This is a scripted response from fake bedrock.",
+	},
+}
+
+func loadRules(path string) ([]rule, error) {
+	rules := append([]rule{}, defaultRules...)
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f rulesFile
+		if err := yaml.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+		// scripted rules take precedence, the defaults remain as a fallback
+		rules = append(f.Rules, rules...)
+	}
+
+	for idx := range rules {
+		pattern, err := regexp.Compile(rules[idx].Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", idx, err)
+		}
+		rules[idx].pattern = pattern
+	}
+	return rules, nil
+}
+
+func matchRule(rules []rule, prompt string, tools []string) *rule {
+	for idx := range rules {
+		r := &rules[idx]
+		if r.Tool != "" && !contains(tools, r.Tool) {
+			continue
+		}
+		if r.pattern.MatchString(prompt) {
+			return r
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
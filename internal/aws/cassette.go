@@ -0,0 +1,259 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+)
+
+// cassetteRecorder holds the cassette shared by every invoker handed out while recording
+type cassetteRecorder struct {
+	mu       sync.Mutex
+	path     string
+	cassette Cassette
+}
+
+type recordingInvoker struct {
+	BedrockInvoker
+	recorder *cassetteRecorder
+}
+
+type replayInvoker struct {
+	mu           sync.Mutex
+	path         string
+	interactions map[string][]CassetteInteraction
+	served       map[string]int
+}
+
+// CassettePath resolves a BRAINS_RECORD/BRAINS_REPLAY value, bare file names are kept under testdata/
+func CassettePath(path string) string {
+	if filepath.Dir(path) == "." && !filepath.IsAbs(path) {
+		return filepath.Join(cassetteDir, path)
+	}
+	return path
+}
+
+func newCassetteRecorder(path string) *cassetteRecorder {
+	return &cassetteRecorder{path: CassettePath(path)}
+}
+
+// NewRecordingInvoker wraps inner so every InvokeModel and Converse call is saved to the cassette at path
+func NewRecordingInvoker(inner BedrockInvoker, path string) BedrockInvoker {
+	return newCassetteRecorder(path).wrap(inner)
+}
+
+func (r *cassetteRecorder) wrap(inner BedrockInvoker) BedrockInvoker {
+	return &recordingInvoker{BedrockInvoker: inner, recorder: r}
+}
+
+// record appends an interaction and rewrites the cassette, the CLI may exit at any point after a call
+func (r *cassetteRecorder) record(operation string, request, response any, callErr error) error {
+	interaction := CassetteInteraction{Operation: operation}
+	var err error
+	if interaction.Request, err = json.Marshal(request); err != nil {
+		return err
+	}
+	if callErr != nil {
+		interaction.Error = callErr.Error()
+	} else if interaction.Response, err = json.Marshal(response); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o600)
+}
+
+func (r *recordingInvoker) InvokeModel(ctx context.Context, input *bedrockruntime.InvokeModelInput) (*bedrockruntime.InvokeModelOutput, error) {
+	request, err := encodeInvokeInput(input)
+	if err != nil {
+		return nil, err
+	}
+	out, callErr := r.BedrockInvoker.InvokeModel(ctx, input)
+	var response invokeWireResponse
+	if callErr == nil {
+		response = encodeInvokeOutput(out)
+	}
+	if err := r.recorder.record(operationInvokeModel, request, response, callErr); err != nil {
+		return nil, fmt.Errorf("record cassette %s: %w", r.recorder.path, err)
+	}
+	return out, callErr
+}
+
+func (r *recordingInvoker) ConverseModel(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	request, err := encodeConverseInput(input)
+	if err != nil {
+		return nil, err
+	}
+	out, callErr := r.BedrockInvoker.ConverseModel(ctx, input)
+	var response converseWireResponse
+	if callErr == nil {
+		if response, err = encodeConverseOutput(out); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.recorder.record(operationConverse, request, response, callErr); err != nil {
+		return nil, fmt.Errorf("record cassette %s: %w", r.recorder.path, err)
+	}
+	return out, callErr
+}
+
+// NewReplayInvoker serves InvokeModel and Converse calls from the cassette at path. Requests are matched on
+// their normalised body, identical requests are served in recorded order and the last one is repeated.
+func NewReplayInvoker(path string) (BedrockInvoker, error) {
+	path = CassettePath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+
+	r := &replayInvoker{
+		path:         path,
+		interactions: make(map[string][]CassetteInteraction),
+		served:       make(map[string]int),
+	}
+	for idx, interaction := range cassette.Interactions {
+		key, err := cassetteKey(interaction.Operation, interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("cassette %s interaction %d: %w", path, idx, err)
+		}
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+	return r, nil
+}
+
+func (r *replayInvoker) next(operation string, request any) (CassetteInteraction, error) {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return CassetteInteraction{}, err
+	}
+	key, err := cassetteKey(operation, raw)
+	if err != nil {
+		return CassetteInteraction{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded := r.interactions[key]
+	if len(recorded) == 0 {
+		return CassetteInteraction{}, fmt.Errorf("no recorded %s interaction in %s matches request %s", operation, r.path, raw)
+	}
+	idx := min(r.served[key], len(recorded)-1)
+	r.served[key]++
+	return recorded[idx], nil
+}
+
+func (r *replayInvoker) InvokeModel(ctx context.Context, input *bedrockruntime.InvokeModelInput) (*bedrockruntime.InvokeModelOutput, error) {
+	request, err := encodeInvokeInput(input)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := r.next(operationInvokeModel, request)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	var response invokeWireResponse
+	if err := json.Unmarshal(interaction.Response, &response); err != nil {
+		return nil, fmt.Errorf("decode recorded invoke response: %w", err)
+	}
+	out := &bedrockruntime.InvokeModelOutput{Body: response.BodyBytes}
+	if response.Body != nil {
+		out.Body = response.Body
+	}
+	if response.ContentType != "" {
+		out.ContentType = aws.String(response.ContentType)
+	}
+	return out, nil
+}
+
+func (r *replayInvoker) ConverseModel(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	request, err := encodeConverseInput(input)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := r.next(operationConverse, request)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	return decodeConverseOutput(interaction.Response)
+}
+
+func (r *replayInvoker) ListFoundationModels(ctx context.Context, input *bedrock.ListFoundationModelsInput) (*bedrock.ListFoundationModelsOutput, error) {
+	return nil, errNotRecorded("ListFoundationModels")
+}
+
+func (r *replayInvoker) ListInferenceProfiles(ctx context.Context, input *bedrock.ListInferenceProfilesInput) (*bedrock.ListInferenceProfilesOutput, error) {
+	return nil, errNotRecorded("ListInferenceProfiles")
+}
+
+func (r *replayInvoker) GetFoundationModelAvailability(ctx context.Context, input *bedrock.GetFoundationModelAvailabilityInput) (*bedrock.GetFoundationModelAvailabilityOutput, error) {
+	return nil, errNotRecorded("GetFoundationModelAvailability")
+}
+
+func errNotRecorded(operation string) error {
+	return fmt.Errorf("%s is not available while replaying a cassette", operation)
+}
+
+func encodeInvokeInput(input *bedrockruntime.InvokeModelInput) (invokeWireRequest, error) {
+	req := invokeWireRequest{
+		ModelID:             aws.ToString(input.ModelId),
+		GuardrailIdentifier: aws.ToString(input.GuardrailIdentifier),
+		GuardrailVersion:    aws.ToString(input.GuardrailVersion),
+		Trace:               string(input.Trace),
+	}
+	if len(input.Body) > 0 {
+		if err := json.Unmarshal(input.Body, &req.Body); err != nil {
+			return req, fmt.Errorf("invoke body is not JSON: %w", err)
+		}
+	}
+	return req, nil
+}
+
+func encodeInvokeOutput(out *bedrockruntime.InvokeModelOutput) invokeWireResponse {
+	response := invokeWireResponse{ContentType: aws.ToString(out.ContentType)}
+	if json.Valid(out.Body) {
+		response.Body = out.Body
+	} else {
+		response.BodyBytes = out.Body
+	}
+	return response
+}
+
+// cassetteKey normalises a request so that formatting and key order in a cassette do not affect matching
+func cassetteKey(operation string, request json.RawMessage) (string, error) {
+	var v any
+	if err := json.Unmarshal(request, &v); err != nil {
+		return "", err
+	}
+	normalised, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return operation + " " + string(normalised), nil
+}
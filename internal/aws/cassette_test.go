@@ -0,0 +1,110 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	awsBrains "github.com/madhuravius/brains/internal/aws"
+	"github.com/madhuravius/brains/internal/llm"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+var cassetteToolSpec = llm.ToolSpec{
+	Name:        "data_extractor",
+	InputSchema: map[string]any{"type": "object"},
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	invokerMock := &mockBrains.MockInvoker{}
+	invokerMock.On("InvokeModel", mock.Anything, mock.Anything).Return(&bedrockruntime.InvokeModelOutput{
+		Body: []byte(`{"choices":[{"message":{"role":"assistant","content":"recorded"}}]}`),
+	}, nil).Once()
+	invokerMock.On("InvokeModel", mock.Anything, mock.Anything).Return(nil, errors.New("throttled")).Once()
+	invokerMock.On("ConverseModel", mock.Anything, mock.Anything).Return(&bedrockruntime.ConverseOutput{
+		StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Role: bedrockruntimeTypes.ConversationRoleAssistant,
+			Content: []bedrockruntimeTypes.ContentBlock{
+				&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+					Name:  aws.String("data_extractor"),
+					Input: document.NewLazyDocument(map[string]any{"markdown_summary": "recorded"}),
+				}},
+			},
+		}},
+	}, nil)
+
+	recording := &awsBrains.AWSConfig{}
+	recording.SetInvoker(awsBrains.NewRecordingInvoker(invokerMock, path))
+
+	chat, err := recording.Chat(context.Background(), "model-id", llm.NewUserRequest("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, "recorded", chat.Choices[0].Message.Content)
+	_, err = recording.Chat(context.Background(), "model-id", llm.NewUserRequest("second"))
+	assert.ErrorContains(t, err, "throttled")
+	structured, err := recording.StructuredOutput(context.Background(), "model-id", llm.NewUserRequest("research"), cassetteToolSpec)
+	assert.NoError(t, err)
+	invokerMock.AssertExpectations(t)
+
+	replayInvoker, err := awsBrains.NewReplayInvoker(path)
+	assert.NoError(t, err)
+	replaying := &awsBrains.AWSConfig{}
+	replaying.SetInvoker(replayInvoker)
+
+	replayedChat, err := replaying.Chat(context.Background(), "model-id", llm.NewUserRequest("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, chat.Choices, replayedChat.Choices)
+	_, err = replaying.Chat(context.Background(), "model-id", llm.NewUserRequest("second"))
+	assert.ErrorContains(t, err, "throttled")
+	replayedStructured, err := replaying.StructuredOutput(context.Background(), "model-id", llm.NewUserRequest("research"), cassetteToolSpec)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(structured), string(replayedStructured))
+
+	_, err = replaying.Chat(context.Background(), "model-id", llm.NewUserRequest("never recorded"))
+	assert.ErrorContains(t, err, "no recorded InvokeModel interaction")
+	_, err = replaying.Chat(context.Background(), "other-model", llm.NewUserRequest("first"))
+	assert.Error(t, err)
+}
+
+func TestReplayFromEnvironment(t *testing.T) {
+	t.Setenv(awsBrains.ReplayEnv, "testdata/ask_session.json")
+
+	cfg := awsBrains.NewAWSConfig("us-east-1")
+	assert.True(t, cfg.SetAndValidateCredentials())
+
+	resp, err := cfg.Chat(context.Background(), "openai.gpt-oss-120b-1:0", llm.NewUserRequest("what does brains do?"))
+	assert.NoError(t, err)
+	assert.Equal(t, "brains is a small LLM wrapper around AWS Bedrock.", resp.Choices[0].Message.Content)
+	assert.Equal(t, float64(12), resp.Usage["prompt_tokens"])
+
+	out, err := cfg.StructuredOutput(context.Background(), "openai.gpt-oss-120b-1:0", llm.NewUserRequest("research brains"), cassetteToolSpec)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"markdown_summary": "reading the README",
+		"research_actions": {"urls_recommended": [], "files_requested": ["README.md"]}
+	}`, string(out))
+
+	_, err = cfg.ListModels(context.Background(), awsBrains.ModelFilter{})
+	assert.ErrorContains(t, err, "not available while replaying")
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := awsBrains.NewReplayInvoker(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestCassettePath(t *testing.T) {
+	assert.Equal(t, filepath.Join("testdata", "session.json"), awsBrains.CassettePath("session.json"))
+	assert.Equal(t, filepath.Join("recordings", "session.json"), awsBrains.CassettePath(filepath.Join("recordings", "session.json")))
+	assert.Equal(t, "/tmp/session.json", awsBrains.CassettePath("/tmp/session.json"))
+}
@@ -0,0 +1,244 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/pterm/pterm"
+)
+
+func (a *AWSConfig) ListModels(ctx context.Context, filter ModelFilter) ([]ModelListing, error) {
+	client := a.GetInvoker()
+	input := &bedrock.ListFoundationModelsInput{}
+	if filter.Provider != "" {
+		input.ByProvider = aws.String(filter.Provider)
+	}
+	if filter.Modality != "" {
+		input.ByOutputModality = types.ModelModality(strings.ToUpper(filter.Modality))
+	}
+	out, err := client.ListFoundationModels(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("list foundation models: %w", err)
+	}
+
+	baseModels := make(map[string]ModelListing)
+	for _, m := range out.ModelSummaries {
+		listing := a.listingFromSummary(m)
+		baseModels[listing.ModelID] = listing
+	}
+
+	profiles, err := listInferenceProfiles(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	a.setModelAccess(ctx, baseModels)
+
+	var listings []ModelListing
+	for _, listing := range baseModels {
+		listings = append(listings, listing)
+	}
+	for _, profile := range profiles {
+		baseModelID := baseModelIDFromProfile(profile)
+		base, ok := baseModels[baseModelID]
+		if !ok {
+			// the backing model was filtered out or is not offered in this region
+			continue
+		}
+		listing := base
+		listing.ModelID = aws.ToString(profile.InferenceProfileId)
+		listing.ModelName = aws.ToString(profile.InferenceProfileName)
+		listing.BaseModelID = baseModelID
+		if p, ok := a.pricingFor(listing.ModelID); ok {
+			listing.Pricing = &p
+		}
+		listings = append(listings, listing)
+	}
+
+	filtered := listings[:0]
+	for _, listing := range listings {
+		if filter.Streaming && !listing.Streaming {
+			continue
+		}
+		if filter.ToolUse && !listing.ToolUse {
+			continue
+		}
+		filtered = append(filtered, listing)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].ProviderName != filtered[j].ProviderName {
+			return filtered[i].ProviderName < filtered[j].ProviderName
+		}
+		return filtered[i].ModelID < filtered[j].ModelID
+	})
+	return filtered, nil
+}
+
+func (a *AWSConfig) listingFromSummary(m types.FoundationModelSummary) ModelListing {
+	modelID := aws.ToString(m.ModelId)
+	listing := ModelListing{
+		ModelID:      modelID,
+		ModelName:    aws.ToString(m.ModelName),
+		ProviderName: aws.ToString(m.ProviderName),
+		Streaming:    aws.ToBool(m.ResponseStreamingSupported),
+		ToolUse:      supportsToolUse(modelID),
+		Access:       ModelAccessUnknown,
+	}
+	for _, modality := range m.InputModalities {
+		listing.InputModalities = append(listing.InputModalities, string(modality))
+	}
+	for _, modality := range m.OutputModalities {
+		listing.OutputModalities = append(listing.OutputModalities, string(modality))
+	}
+	if p, ok := a.pricingFor(modelID); ok {
+		listing.Pricing = &p
+	}
+	return listing
+}
+
+func listInferenceProfiles(ctx context.Context, client BedrockInvoker) ([]types.InferenceProfileSummary, error) {
+	var profiles []types.InferenceProfileSummary
+	input := &bedrock.ListInferenceProfilesInput{TypeEquals: types.InferenceProfileTypeSystemDefined}
+	for {
+		out, err := client.ListInferenceProfiles(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("list inference profiles: %w", err)
+		}
+		profiles = append(profiles, out.InferenceProfileSummaries...)
+		if out.NextToken == nil || *out.NextToken == "" {
+			return profiles, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// setModelAccess resolves the account's access to every model, a failed lookup leaves the access unknown
+func (a *AWSConfig) setModelAccess(ctx context.Context, models map[string]ModelListing) {
+	client := a.GetInvoker()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		sem    = make(chan struct{}, modelAccessConcurrency)
+		access = make(map[string]ModelAccess, len(models))
+	)
+	for modelID := range models {
+		wg.Add(1)
+		go func(modelID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			out, err := client.GetFoundationModelAvailability(ctx, &bedrock.GetFoundationModelAvailabilityInput{
+				ModelId: aws.String(modelID),
+			})
+			modelAccess := ModelAccessUnknown
+			if err != nil {
+				pterm.Debug.Printfln("unable to check access for %s: %v", modelID, err)
+			} else {
+				modelAccess = accessFromAvailability(out)
+			}
+
+			mu.Lock()
+			access[modelID] = modelAccess
+			mu.Unlock()
+		}(modelID)
+	}
+	wg.Wait()
+
+	for modelID, modelAccess := range access {
+		listing := models[modelID]
+		listing.Access = modelAccess
+		models[modelID] = listing
+	}
+}
+
+func accessFromAvailability(out *bedrock.GetFoundationModelAvailabilityOutput) ModelAccess {
+	if out == nil {
+		return ModelAccessUnknown
+	}
+	if out.AuthorizationStatus != types.AuthorizationStatusAuthorized ||
+		out.EntitlementAvailability != types.EntitlementAvailabilityAvailable ||
+		out.RegionAvailability != types.RegionAvailabilityAvailable {
+		return ModelAccessNotGranted
+	}
+	if out.AgreementAvailability != nil && out.AgreementAvailability.Status != types.AgreementStatusAvailable {
+		return ModelAccessNotGranted
+	}
+	return ModelAccessGranted
+}
+
+// baseModelIDFromProfile returns the foundation model ID behind a cross-region inference profile
+func baseModelIDFromProfile(profile types.InferenceProfileSummary) string {
+	for _, m := range profile.Models {
+		arn := aws.ToString(m.ModelArn)
+		if idx := strings.Index(arn, "foundation-model/"); idx != -1 {
+			return arn[idx+len("foundation-model/"):]
+		}
+	}
+	return ""
+}
+
+func supportsToolUse(modelID string) bool {
+	for _, prefix := range toolUseModelPrefixes {
+		if strings.HasPrefix(modelID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AWSConfig) PrintModels(models []ModelListing, activeModelID string) error {
+	yesNo := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+
+	tableData := pterm.TableData{{
+		"Model ID",
+		"Model Name",
+		"Provider",
+		"Output",
+		"Streaming",
+		"Tool Use",
+		"Access",
+		"Input Cost / 1k Tokens",
+		"Output Cost / 1k Tokens",
+	}}
+	for _, m := range models {
+		modelID := m.ModelID
+		if modelID == activeModelID {
+			modelID = "* " + modelID
+		}
+		inputCost, outputCost := "-", "-"
+		if m.Pricing != nil {
+			inputCost = fmt.Sprintf("%f", m.Pricing.InputCostPer1kTokens)
+			outputCost = fmt.Sprintf("%f", m.Pricing.OutputCostPer1kTokens)
+		}
+		tableData = append(tableData, []string{
+			modelID,
+			m.ModelName,
+			m.ProviderName,
+			strings.Join(m.OutputModalities, ","),
+			yesNo(m.Streaming),
+			yesNo(m.ToolUse),
+			string(m.Access),
+			inputCost,
+			outputCost,
+		})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render(); err != nil {
+		return err
+	}
+	pterm.Info.Printfln("%d models listed, the active model is marked with *", len(models))
+	return nil
+}
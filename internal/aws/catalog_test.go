@@ -0,0 +1,148 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	awsBrains "github.com/madhuravius/brains/internal/aws"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+func setupCatalog(t *testing.T) (*awsBrains.AWSConfig, *mockBrains.MockInvoker) {
+	t.Helper()
+
+	cfg := &awsBrains.AWSConfig{}
+	inv := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(inv)
+	cfg.SetPricing([]awsBrains.ModelPricing{{
+		ModelID:               "anthropic.claude-3-haiku-20240307-v1:0",
+		ModelName:             "Claude 3 Haiku",
+		InputCostPer1kTokens:  0.00025,
+		OutputCostPer1kTokens: 0.00125,
+	}})
+
+	inv.On("ListFoundationModels", mock.Anything, mock.Anything).Return(&bedrock.ListFoundationModelsOutput{
+		ModelSummaries: []types.FoundationModelSummary{
+			{
+				ModelId:                    aws.String("anthropic.claude-3-haiku-20240307-v1:0"),
+				ModelName:                  aws.String("Claude 3 Haiku"),
+				ProviderName:               aws.String("Anthropic"),
+				OutputModalities:           []types.ModelModality{types.ModelModalityText},
+				ResponseStreamingSupported: aws.Bool(true),
+			},
+			{
+				ModelId:                    aws.String("amazon.titan-embed-text-v2:0"),
+				ModelName:                  aws.String("Titan Text Embeddings V2"),
+				ProviderName:               aws.String("Amazon"),
+				OutputModalities:           []types.ModelModality{types.ModelModalityEmbedding},
+				ResponseStreamingSupported: aws.Bool(false),
+			},
+		},
+	}, nil)
+	inv.On("ListInferenceProfiles", mock.Anything, mock.MatchedBy(func(in *bedrock.ListInferenceProfilesInput) bool {
+		return in.NextToken == nil
+	})).Return(&bedrock.ListInferenceProfilesOutput{
+		NextToken: aws.String("page-2"),
+	}, nil).Once()
+	inv.On("ListInferenceProfiles", mock.Anything, mock.MatchedBy(func(in *bedrock.ListInferenceProfilesInput) bool {
+		return aws.ToString(in.NextToken) == "page-2"
+	})).Return(&bedrock.ListInferenceProfilesOutput{
+		InferenceProfileSummaries: []types.InferenceProfileSummary{{
+			InferenceProfileId:   aws.String("us.anthropic.claude-3-haiku-20240307-v1:0"),
+			InferenceProfileName: aws.String("US Anthropic Claude 3 Haiku"),
+			Models: []types.InferenceProfileModel{{
+				ModelArn: aws.String("arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-3-haiku-20240307-v1:0"),
+			}},
+		}},
+	}, nil).Once()
+	inv.On("GetFoundationModelAvailability", mock.Anything, mock.MatchedBy(func(in *bedrock.GetFoundationModelAvailabilityInput) bool {
+		return aws.ToString(in.ModelId) == "anthropic.claude-3-haiku-20240307-v1:0"
+	})).Return(&bedrock.GetFoundationModelAvailabilityOutput{
+		AuthorizationStatus:     types.AuthorizationStatusAuthorized,
+		EntitlementAvailability: types.EntitlementAvailabilityAvailable,
+		RegionAvailability:      types.RegionAvailabilityAvailable,
+	}, nil)
+	inv.On("GetFoundationModelAvailability", mock.Anything, mock.MatchedBy(func(in *bedrock.GetFoundationModelAvailabilityInput) bool {
+		return aws.ToString(in.ModelId) == "amazon.titan-embed-text-v2:0"
+	})).Return(nil, errors.New("access denied"))
+
+	return cfg, inv
+}
+
+func TestListModelsJoinsProfilesPricingAndAccess(t *testing.T) {
+	cfg, inv := setupCatalog(t)
+
+	models, err := cfg.ListModels(context.Background(), awsBrains.ModelFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, models, 3)
+
+	byID := map[string]awsBrains.ModelListing{}
+	for _, m := range models {
+		byID[m.ModelID] = m
+	}
+
+	haiku := byID["anthropic.claude-3-haiku-20240307-v1:0"]
+	assert.Equal(t, awsBrains.ModelAccessGranted, haiku.Access)
+	assert.True(t, haiku.ToolUse)
+	assert.True(t, haiku.Streaming)
+	assert.NotNil(t, haiku.Pricing)
+	assert.Equal(t, 0.00125, haiku.Pricing.OutputCostPer1kTokens)
+
+	profile := byID["us.anthropic.claude-3-haiku-20240307-v1:0"]
+	assert.Equal(t, "anthropic.claude-3-haiku-20240307-v1:0", profile.BaseModelID)
+	assert.Equal(t, awsBrains.ModelAccessGranted, profile.Access)
+	assert.NotNil(t, profile.Pricing)
+
+	titan := byID["amazon.titan-embed-text-v2:0"]
+	assert.Equal(t, awsBrains.ModelAccessUnknown, titan.Access)
+	assert.False(t, titan.ToolUse)
+	assert.Nil(t, titan.Pricing)
+
+	inv.AssertExpectations(t)
+}
+
+func TestListModelsFilters(t *testing.T) {
+	cfg, _ := setupCatalog(t)
+
+	models, err := cfg.ListModels(context.Background(), awsBrains.ModelFilter{Streaming: true, ToolUse: true})
+	assert.NoError(t, err)
+	assert.Len(t, models, 2)
+	for _, m := range models {
+		assert.Equal(t, "Anthropic", m.ProviderName)
+	}
+}
+
+func TestListModelsPassesServerSideFilters(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	inv := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(inv)
+
+	inv.On("ListFoundationModels", mock.Anything, mock.MatchedBy(func(in *bedrock.ListFoundationModelsInput) bool {
+		return aws.ToString(in.ByProvider) == "Meta" && in.ByOutputModality == types.ModelModalityText
+	})).Return(nil, errors.New("list error"))
+
+	_, err := cfg.ListModels(context.Background(), awsBrains.ModelFilter{Provider: "Meta", Modality: "text"})
+	assert.ErrorContains(t, err, "list error")
+	inv.AssertExpectations(t)
+}
+
+func TestPrintModelsMarksActiveModel(t *testing.T) {
+	cfg, _ := setupCatalog(t)
+
+	models, err := cfg.ListModels(context.Background(), awsBrains.ModelFilter{})
+	assert.NoError(t, err)
+
+	out := mockBrains.CaptureAllOutput(func() {
+		assert.NoError(t, cfg.PrintModels(models, "anthropic.claude-3-haiku-20240307-v1:0"))
+	})
+	assert.Contains(t, out, "* anthropic.claude-3-haiku-20240307-v1:0")
+	assert.Contains(t, out, "us.anthropic.claude-3-haiku-20240307-v1:0")
+	assert.Contains(t, out, "unknown")
+}
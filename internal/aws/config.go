@@ -3,6 +3,8 @@ package aws
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -22,18 +24,54 @@ var newSTSClientFunc = func(cfg aws.Config, optFns ...func(*sts.Options)) STSCli
 }
 
 func NewAWSConfig(region string) AWSImpl {
-	modelsPricing, err := getModelsPricing()
+	modelsPricing, err := getModelsPricing(region)
 	if err != nil {
 		return nil
 	}
 
-	return &AWSConfig{
+	cfg := &AWSConfig{
 		region:  region,
 		pricing: modelsPricing,
 	}
+	if home, err := os.UserHomeDir(); err == nil {
+		cfg.credentialsCacheDir = filepath.Join(home, credentialsCacheDir)
+	}
+	if path := os.Getenv(ReplayEnv); path != "" {
+		invoker, err := NewReplayInvoker(path)
+		if err != nil {
+			pterm.Error.Printf("unable to load cassette for replay, %s\n", err.Error())
+			return nil
+		}
+		pterm.Info.Printfln("replaying model calls from %s", CassettePath(path))
+		cfg.SetInvoker(invoker)
+		cfg.offline = true
+	} else if path := os.Getenv(RecordEnv); path != "" {
+		pterm.Info.Printfln("recording model calls to %s", CassettePath(path))
+		cfg.recorder = newCassetteRecorder(path)
+	}
+	return cfg
+}
+
+// DetectRegion resolves the region from the AWS environment variables and shared config of profile, empty when unset
+func DetectRegion(ctx context.Context, profile string) string {
+	var opts []func(*config.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	cfg, err := loadConfigFunc(ctx, opts...)
+	if err != nil {
+		return ""
+	}
+	return cfg.Region
 }
 
-func getModelsPricing() ([]ModelPricing, error) {
+// getModelsPricing prefers pricing refreshed for region over the pricing embedded at build time
+func getModelsPricing(region string) ([]ModelPricing, error) {
+	if path, err := PricingCachePath(); err == nil {
+		if cached, ok := loadPricingCache(path, region); ok {
+			return cached, nil
+		}
+	}
 	var out []ModelPricing
 	if err := json.Unmarshal(rawModelsPricing, &out); err != nil {
 		pterm.Error.Printf("unable to load SDK config, %s\n", err.Error())
@@ -43,23 +81,51 @@ func getModelsPricing() ([]ModelPricing, error) {
 }
 
 func (a *AWSConfig) SetAndValidateCredentials() bool {
+	if a.offline {
+		pterm.Info.Println("replaying a recorded session, skipping credential checks")
+		return true
+	}
 	pterm.Info.Println("checking AWS credentials")
-	cfg, err := loadConfigFunc(context.Background(), config.WithRegion(a.region))
+	ctx := context.Background()
+	cfg, err := loadConfigFunc(ctx, a.loadOptions()...)
 	if err != nil {
 		pterm.Error.Printf("unable to load SDK config, %s\n", err.Error())
 		return false
 	}
+	if a.assumeRole.RoleARN != "" {
+		pterm.Info.Printfln("assuming role %s", a.assumeRole.RoleARN)
+		cfg.Credentials = a.assumeRoleProvider(cfg)
+	}
 	a.cfg = cfg
-	client := newSTSClientFunc(cfg)
-	_, err = client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	client := newSTSClientFunc(cfg, a.stsOptions()...)
+	out, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		pterm.Error.Printf("credentials invalid: %s\n", err.Error())
 		return false
 	}
+
+	a.identity = CallerIdentity{
+		Account: aws.ToString(out.Account),
+		ARN:     aws.ToString(out.Arn),
+	}
+	if cfg.Credentials != nil {
+		// already cached by the identity call, this only reads back where they came from
+		if creds, err := cfg.Credentials.Retrieve(ctx); err == nil {
+			a.identity.Source = creds.Source
+		}
+	}
 	pterm.Info.Println("valid credentials")
 	return true
 }
 
-func (a *AWSConfig) GetConfig() aws.Config                 { return a.cfg }
-func (a *AWSConfig) SetLogger(l brainsConfig.SimpleLogger) { a.logger = l }
-func (a *AWSConfig) SetPricing(pricing []ModelPricing)     { a.pricing = pricing }
+func (a *AWSConfig) SetCredentials(profile string, assumeRole brainsConfig.AssumeRoleConfig) {
+	a.profile, a.assumeRole = profile, assumeRole
+}
+
+func (a *AWSConfig) GetConfig() aws.Config                               { return a.cfg }
+func (a *AWSConfig) SetEndpoint(endpointURL string)                      { a.endpointURL = endpointURL }
+func (a *AWSConfig) SetGuardrail(guardrail brainsConfig.GuardrailConfig) { a.guardrail = guardrail }
+func (a *AWSConfig) SetLogger(l brainsConfig.SimpleLogger)               { a.logger = l }
+func (a *AWSConfig) SetPricing(pricing []ModelPricing)                   { a.pricing = pricing }
+func (a *AWSConfig) SetReasoningBudget(budget int)                       { a.reasoningBudget = budget }
+func (a *AWSConfig) SetTemperature(temperature *float64)                 { a.temperature = temperature }
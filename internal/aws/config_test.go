@@ -3,6 +3,8 @@ package aws
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -38,6 +40,8 @@ func TestSetAndValidateCredentialsSuccess(t *testing.T) {
 	cfg := NewAWSConfig("us-west-2")
 	ok := cfg.SetAndValidateCredentials()
 	assert.True(t, ok)
+	assert.Equal(t, "123456789012", cfg.GetIdentity().Account)
+	assert.Equal(t, "arn:aws:sts::123456789012:assumed-role/role-name", cfg.GetIdentity().ARN)
 }
 
 func TestSetAndValidateCredentialsLoadError(t *testing.T) {
@@ -83,3 +87,19 @@ func TestSetAndValidateCredentialsInvalidCredentials(t *testing.T) {
 	ok := cfg.SetAndValidateCredentials()
 	assert.False(t, ok)
 }
+
+func TestDetectRegion(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, os.WriteFile(configFile, []byte("[profile dev]\nregion = eu-central-1\n"), 0o600))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+
+	assert.Equal(t, "", DetectRegion(context.Background(), ""))
+	assert.Equal(t, "eu-central-1", DetectRegion(context.Background(), "dev"))
+
+	t.Setenv("AWS_REGION", "ap-southeast-2")
+	assert.Equal(t, "ap-southeast-2", DetectRegion(context.Background(), ""))
+}
@@ -2,10 +2,102 @@ package aws
 
 import (
 	_ "embed"
+	"time"
+
+	"github.com/madhuravius/brains/internal/llm"
 )
 
 //go:embed data/models_pricing.json
 var rawModelsPricing []byte
 
-// token limit is still a fixed safety bound (128 000)
-const TokenLimit = 128000
+const TokenLimit = llm.TokenLimit
+
+const (
+	ModelAccessGranted    ModelAccess = "granted"
+	ModelAccessNotGranted ModelAccess = "not granted"
+	ModelAccessUnknown    ModelAccess = "unknown"
+)
+
+const (
+	// defaultGuardrailVersion is used when a guardrail identifier is set without a version
+	defaultGuardrailVersion   = "DRAFT"
+	guardrailActionIntervened = "INTERVENED"
+	guardrailActionNone       = "NONE"
+)
+
+const (
+	// RecordEnv names a cassette file that every InvokeModel and Converse call is recorded to
+	RecordEnv = "BRAINS_RECORD"
+	// ReplayEnv names a cassette file that InvokeModel and Converse calls are served from
+	ReplayEnv = "BRAINS_REPLAY"
+
+	// cassetteDir is used for cassette paths given without a directory
+	cassetteDir = "testdata"
+
+	operationInvokeModel = "InvokeModel"
+	operationConverse    = "Converse"
+)
+
+const (
+	defaultRoleSessionName = "brains"
+	// credentialsCacheDir is relative to the home directory
+	credentialsCacheDir = ".brains/cache/credentials"
+	// credentialsExpiryWindow refreshes assumed role credentials this long before they expire
+	credentialsExpiryWindow = 5 * time.Minute
+)
+
+const (
+	// pricingCacheFile is relative to the home directory
+	pricingCacheFile = ".brains/pricing.json"
+	pricingAPIRegion = "us-east-1"
+
+	priceDimensionInput      = "input"
+	priceDimensionOutput     = "output"
+	priceDimensionCacheRead  = "cache read"
+	priceDimensionCacheWrite = "cache write"
+)
+
+// pricingServiceCodes hold Bedrock prices, third party models are sold under their own service code
+var pricingServiceCodes = []string{"AmazonBedrock", "AmazonBedrockFoundationModels"}
+
+// skippedPriceTiers are matched against the feature, inference type and usage type of a product,
+// brains only calls models on demand
+var skippedPriceTiers = []string{"batch", "provisioned", "latency", "flex", "priority", "reserved", "custom"}
+
+// inferenceProfilePrefixes are the geographies of cross-region inference profile IDs, a profile is priced
+// like the model behind it
+var inferenceProfilePrefixes = []string{"us.", "eu.", "apac.", "us-gov.", "global.", "jp.", "au.", "ca."}
+
+// modelAccessConcurrency bounds the number of GetFoundationModelAvailability calls in flight
+const modelAccessConcurrency = 8
+
+// toolUseModelPrefixes lists model ID prefixes that support tool use with the Converse API, see
+// https://docs.aws.amazon.com/bedrock/latest/userguide/conversation-inference-supported-models-features.html
+var toolUseModelPrefixes = []string{
+	"ai21.jamba",
+	"amazon.nova",
+	"anthropic.claude-3",
+	"anthropic.claude-haiku-4",
+	"anthropic.claude-opus-4",
+	"anthropic.claude-sonnet-4",
+	"cohere.command-r",
+	"deepseek.v3",
+	"meta.llama3-1",
+	"meta.llama3-2-11b",
+	"meta.llama3-2-90b",
+	"meta.llama3-3",
+	"meta.llama4",
+	"mistral.mistral-large",
+	"mistral.pixtral-large",
+	"openai.gpt-oss",
+	"qwen.",
+	"writer.palmyra-x",
+}
+
+const (
+	// minThinkingBudget is the smallest budget_tokens Claude accepts for extended thinking
+	minThinkingBudget = 1024
+	// thinkingAnswerTokens is room left for the answer on top of the thinking budget, Claude requires
+	// max_tokens to be larger than budget_tokens
+	thinkingAnswerTokens = 4096
+)
@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// the SDK's Converse types are unions and lazy documents that encoding/json cannot round trip, these
+// helpers convert them to and from the Bedrock wire format so they can be stored in cassettes
+
+func encodeConverseInput(in *bedrockruntime.ConverseInput) (converseWireRequest, error) {
+	req := converseWireRequest{ModelID: aws.ToString(in.ModelId)}
+	for _, m := range in.Messages {
+		message, err := encodeConverseMessage(m)
+		if err != nil {
+			return req, err
+		}
+		req.Messages = append(req.Messages, message)
+	}
+	for _, block := range in.System {
+		if text, ok := block.(*types.SystemContentBlockMemberText); ok {
+			req.System = append(req.System, converseWireText{Text: text.Value})
+		}
+	}
+	if in.ToolConfig != nil {
+		toolConfig := &converseWireToolConfig{}
+		for _, t := range in.ToolConfig.Tools {
+			spec, ok := t.(*types.ToolMemberToolSpec)
+			if !ok {
+				continue
+			}
+			tool := converseWireToolSpec{
+				Name:        aws.ToString(spec.Value.Name),
+				Description: aws.ToString(spec.Value.Description),
+			}
+			if schema, ok := spec.Value.InputSchema.(*types.ToolInputSchemaMemberJson); ok {
+				v, err := documentToAny(schema.Value)
+				if err != nil {
+					return req, err
+				}
+				tool.InputSchema = map[string]any{"json": v}
+			}
+			toolConfig.Tools = append(toolConfig.Tools, converseWireTool{ToolSpec: tool})
+		}
+		switch choice := in.ToolConfig.ToolChoice.(type) {
+		case *types.ToolChoiceMemberAny:
+			toolConfig.ToolChoice = map[string]any{"any": map[string]any{}}
+		case *types.ToolChoiceMemberAuto:
+			toolConfig.ToolChoice = map[string]any{"auto": map[string]any{}}
+		case *types.ToolChoiceMemberTool:
+			toolConfig.ToolChoice = map[string]any{"tool": map[string]any{"name": aws.ToString(choice.Value.Name)}}
+		}
+		req.ToolConfig = toolConfig
+	}
+	if in.InferenceConfig != nil {
+		req.InferenceConfig = &converseWireInferenceConfig{
+			MaxTokens:     in.InferenceConfig.MaxTokens,
+			StopSequences: in.InferenceConfig.StopSequences,
+			Temperature:   in.InferenceConfig.Temperature,
+			TopP:          in.InferenceConfig.TopP,
+		}
+	}
+	if in.GuardrailConfig != nil {
+		req.GuardrailConfig = &converseWireGuardrailConfig{
+			GuardrailIdentifier: aws.ToString(in.GuardrailConfig.GuardrailIdentifier),
+			GuardrailVersion:    aws.ToString(in.GuardrailConfig.GuardrailVersion),
+			Trace:               string(in.GuardrailConfig.Trace),
+		}
+	}
+	if in.AdditionalModelRequestFields != nil {
+		v, err := documentToAny(in.AdditionalModelRequestFields)
+		if err != nil {
+			return req, err
+		}
+		req.AdditionalModelRequestFields = v
+	}
+	return req, nil
+}
+
+func encodeConverseOutput(out *bedrockruntime.ConverseOutput) (converseWireResponse, error) {
+	resp := converseWireResponse{StopReason: string(out.StopReason)}
+	if message, ok := out.Output.(*types.ConverseOutputMemberMessage); ok {
+		m, err := encodeConverseMessage(message.Value)
+		if err != nil {
+			return resp, err
+		}
+		resp.Output.Message = &m
+	}
+	if out.Usage != nil {
+		resp.Usage = &converseWireUsage{
+			InputTokens:  aws.ToInt32(out.Usage.InputTokens),
+			OutputTokens: aws.ToInt32(out.Usage.OutputTokens),
+			TotalTokens:  aws.ToInt32(out.Usage.TotalTokens),
+		}
+	}
+	return resp, nil
+}
+
+func decodeConverseOutput(raw json.RawMessage) (*bedrockruntime.ConverseOutput, error) {
+	var resp converseWireResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("decode recorded converse response: %w", err)
+	}
+	out := &bedrockruntime.ConverseOutput{StopReason: types.StopReason(resp.StopReason)}
+	if resp.Output.Message != nil {
+		message := types.Message{Role: types.ConversationRole(resp.Output.Message.Role)}
+		for _, block := range resp.Output.Message.Content {
+			message.Content = append(message.Content, decodeConverseBlock(block))
+		}
+		out.Output = &types.ConverseOutputMemberMessage{Value: message}
+	}
+	if resp.Usage != nil {
+		out.Usage = &types.TokenUsage{
+			InputTokens:  aws.Int32(resp.Usage.InputTokens),
+			OutputTokens: aws.Int32(resp.Usage.OutputTokens),
+			TotalTokens:  aws.Int32(resp.Usage.TotalTokens),
+		}
+	}
+	return out, nil
+}
+
+func encodeConverseMessage(m types.Message) (converseWireMessage, error) {
+	message := converseWireMessage{Role: string(m.Role)}
+	for _, block := range m.Content {
+		b, err := encodeConverseBlock(block)
+		if err != nil {
+			return message, err
+		}
+		message.Content = append(message.Content, b)
+	}
+	return message, nil
+}
+
+func encodeConverseBlock(block types.ContentBlock) (converseWireBlock, error) {
+	switch b := block.(type) {
+	case *types.ContentBlockMemberText:
+		return converseWireBlock{Text: aws.String(b.Value)}, nil
+	case *types.ContentBlockMemberToolUse:
+		input, err := documentToAny(b.Value.Input)
+		if err != nil {
+			return converseWireBlock{}, err
+		}
+		return converseWireBlock{ToolUse: &converseWireToolUse{
+			ToolUseID: aws.ToString(b.Value.ToolUseId),
+			Name:      aws.ToString(b.Value.Name),
+			Input:     input,
+		}}, nil
+	case *types.ContentBlockMemberToolResult:
+		result := &converseWireToolResult{
+			ToolUseID: aws.ToString(b.Value.ToolUseId),
+			Status:    string(b.Value.Status),
+		}
+		for _, c := range b.Value.Content {
+			switch content := c.(type) {
+			case *types.ToolResultContentBlockMemberText:
+				result.Content = append(result.Content, converseWireToolResultContent{Text: aws.String(content.Value)})
+			case *types.ToolResultContentBlockMemberJson:
+				v, err := documentToAny(content.Value)
+				if err != nil {
+					return converseWireBlock{}, err
+				}
+				result.Content = append(result.Content, converseWireToolResultContent{JSON: v})
+			}
+		}
+		return converseWireBlock{ToolResult: result}, nil
+	case *types.ContentBlockMemberReasoningContent:
+		reasoning := &converseWireReasoning{}
+		switch r := b.Value.(type) {
+		case *types.ReasoningContentBlockMemberReasoningText:
+			reasoning.ReasoningText = &converseWireReasoningText{
+				Text:      aws.ToString(r.Value.Text),
+				Signature: aws.ToString(r.Value.Signature),
+			}
+		case *types.ReasoningContentBlockMemberRedactedContent:
+			reasoning.RedactedContent = r.Value
+		}
+		return converseWireBlock{ReasoningContent: reasoning}, nil
+	}
+	return converseWireBlock{Unsupported: fmt.Sprintf("%T", block)}, nil
+}
+
+func decodeConverseBlock(block converseWireBlock) types.ContentBlock {
+	switch {
+	case block.Text != nil:
+		return &types.ContentBlockMemberText{Value: *block.Text}
+	case block.ToolUse != nil:
+		return &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+			ToolUseId: aws.String(block.ToolUse.ToolUseID),
+			Name:      aws.String(block.ToolUse.Name),
+			Input:     document.NewLazyDocument(block.ToolUse.Input),
+		}}
+	case block.ToolResult != nil:
+		result := types.ToolResultBlock{
+			ToolUseId: aws.String(block.ToolResult.ToolUseID),
+			Status:    types.ToolResultStatus(block.ToolResult.Status),
+		}
+		for _, c := range block.ToolResult.Content {
+			if c.Text != nil {
+				result.Content = append(result.Content, &types.ToolResultContentBlockMemberText{Value: *c.Text})
+			} else {
+				result.Content = append(result.Content, &types.ToolResultContentBlockMemberJson{Value: document.NewLazyDocument(c.JSON)})
+			}
+		}
+		return &types.ContentBlockMemberToolResult{Value: result}
+	case block.ReasoningContent != nil && block.ReasoningContent.ReasoningText != nil:
+		return &types.ContentBlockMemberReasoningContent{Value: &types.ReasoningContentBlockMemberReasoningText{
+			Value: types.ReasoningTextBlock{
+				Text:      aws.String(block.ReasoningContent.ReasoningText.Text),
+				Signature: aws.String(block.ReasoningContent.ReasoningText.Signature),
+			},
+		}}
+	case block.ReasoningContent != nil:
+		return &types.ContentBlockMemberReasoningContent{Value: &types.ReasoningContentBlockMemberRedactedContent{
+			Value: block.ReasoningContent.RedactedContent,
+		}}
+	}
+	return &types.ContentBlockMemberText{Value: ""}
+}
+
+func documentToAny(doc document.Interface) (any, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	data, err := doc.MarshalSmithyDocument()
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,129 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/pterm/pterm"
+)
+
+var newAssumeRoleClientFunc = func(cfg aws.Config, optFns ...func(*sts.Options)) stscreds.AssumeRoleAPIClient {
+	return sts.NewFromConfig(cfg, optFns...)
+}
+
+// mfaTokenFunc prompts for the current code of the MFA device with the given serial
+var mfaTokenFunc = func(serial string) (string, error) {
+	return pterm.DefaultInteractiveTextInput.WithMask("*").Show("MFA code for " + serial)
+}
+
+// fileCachedProvider keeps assumed role credentials on disk so MFA is not prompted for on every command
+type fileCachedProvider struct {
+	inner aws.CredentialsProvider
+	path  string
+}
+
+func (a *AWSConfig) loadOptions() []func(*config.LoadOptions) error {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(a.region)}
+	if a.profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(a.profile))
+	}
+	return opts
+}
+
+func (a *AWSConfig) stsOptions() []func(*sts.Options) {
+	if a.endpointURL == "" {
+		return nil
+	}
+	return []func(*sts.Options){func(o *sts.Options) { o.BaseEndpoint = aws.String(a.endpointURL) }}
+}
+
+// assumeRoleProvider wraps the base credentials in cfg with an sts:AssumeRole call for the configured role
+func (a *AWSConfig) assumeRoleProvider(cfg aws.Config) aws.CredentialsProvider {
+	role := a.assumeRole
+	provider := stscreds.NewAssumeRoleProvider(newAssumeRoleClientFunc(cfg, a.stsOptions()...), role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = defaultRoleSessionName
+		if role.SessionName != "" {
+			o.RoleSessionName = role.SessionName
+		}
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+		if role.Duration > 0 {
+			o.Duration = role.Duration
+		}
+		if role.MFASerial != "" {
+			o.SerialNumber = aws.String(role.MFASerial)
+			o.TokenProvider = func() (string, error) { return mfaTokenFunc(role.MFASerial) }
+		}
+	})
+
+	var inner aws.CredentialsProvider = provider
+	if a.credentialsCacheDir != "" {
+		inner = &fileCachedProvider{inner: provider, path: filepath.Join(a.credentialsCacheDir, a.credentialsCacheKey()+".json")}
+	}
+	return aws.NewCredentialsCache(inner, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = credentialsExpiryWindow
+	})
+}
+
+// credentialsCacheKey identifies the cached credentials for a profile and role combination
+func (a *AWSConfig) credentialsCacheKey() string {
+	role := a.assumeRole
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		a.profile, role.RoleARN, role.SessionName, role.ExternalID, role.MFASerial,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p *fileCachedProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	if data, err := os.ReadFile(p.path); err == nil {
+		var creds aws.Credentials
+		if err := json.Unmarshal(data, &creds); err == nil && creds.CanExpire && time.Now().Add(credentialsExpiryWindow).Before(creds.Expires) {
+			creds.Source += " (cached)"
+			return creds, nil
+		}
+	}
+
+	creds, err := p.inner.Retrieve(ctx)
+	if err != nil {
+		return creds, err
+	}
+	if err := p.write(creds); err != nil {
+		pterm.Warning.Printfln("unable to cache assumed role credentials: %v", err)
+	}
+	return creds, nil
+}
+
+func (p *fileCachedProvider) write(creds aws.Credentials) error {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0o600)
+}
+
+func (a *AWSConfig) GetIdentity() CallerIdentity { return a.identity }
+
+// PrintIdentity shows who brains is calling Bedrock as, resolved by the last SetAndValidateCredentials
+func (a *AWSConfig) PrintIdentity() {
+	tableData := pterm.TableData{
+		{"Account", "ARN", "Credential source"},
+		{a.identity.Account, a.identity.ARN, a.identity.Source},
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("unable to render identity: %v", err)
+	}
+}
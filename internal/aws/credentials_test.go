@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+func TestSetAndValidateCredentialsAssumeRole(t *testing.T) {
+	origLoad := loadConfigFunc
+	origNewSTS := newSTSClientFunc
+	origAssumeRole := newAssumeRoleClientFunc
+	origMFA := mfaTokenFunc
+	defer func() {
+		loadConfigFunc = origLoad
+		newSTSClientFunc = origNewSTS
+		newAssumeRoleClientFunc = origAssumeRole
+		mfaTokenFunc = origMFA
+	}()
+
+	loadConfigFunc = func(ctx context.Context, opts ...func(*config.LoadOptions) error) (aws.Config, error) {
+		var loadOptions config.LoadOptions
+		for _, opt := range opts {
+			assert.NoError(t, opt(&loadOptions))
+		}
+		assert.Equal(t, "dev", loadOptions.SharedConfigProfile)
+		return aws.Config{Credentials: credentials.NewStaticCredentialsProvider("base", "base", "")}, nil
+	}
+	assumeRoleClient := &mockBrains.MockAssumeRoleClient{
+		Output: &sts.AssumeRoleOutput{Credentials: &stsTypes.Credentials{
+			AccessKeyId:     aws.String("assumed"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		}},
+	}
+	newAssumeRoleClientFunc = func(cfg aws.Config, optFns ...func(*sts.Options)) stscreds.AssumeRoleAPIClient {
+		return assumeRoleClient
+	}
+	newSTSClientFunc = func(cfg aws.Config, optFns ...func(*sts.Options)) STSClient {
+		creds, err := cfg.Credentials.Retrieve(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "assumed", creds.AccessKeyID)
+		return &mockBrains.MockSTSClient{Output: &sts.GetCallerIdentityOutput{
+			Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/brains/ci"),
+			Account: aws.String("123456789012"),
+		}}
+	}
+	mfaTokenFunc = func(serial string) (string, error) { return "123456", nil }
+
+	cacheDir := t.TempDir()
+	newConfig := func() *AWSConfig {
+		cfg := &AWSConfig{region: "us-east-1", credentialsCacheDir: cacheDir}
+		cfg.SetCredentials("dev", brainsConfig.AssumeRoleConfig{
+			RoleARN:     "arn:aws:iam::123456789012:role/brains",
+			SessionName: "ci",
+			ExternalID:  "ext-123",
+			Duration:    30 * time.Minute,
+			MFASerial:   "arn:aws:iam::123456789012:mfa/me",
+		})
+		return cfg
+	}
+
+	cfg := newConfig()
+	assert.True(t, cfg.SetAndValidateCredentials())
+	assert.Len(t, assumeRoleClient.Inputs, 1)
+	input := assumeRoleClient.Inputs[0]
+	assert.Equal(t, "ci", aws.ToString(input.RoleSessionName))
+	assert.Equal(t, "ext-123", aws.ToString(input.ExternalId))
+	assert.Equal(t, int32(1800), aws.ToInt32(input.DurationSeconds))
+	assert.Equal(t, "123456", aws.ToString(input.TokenCode))
+	assert.Equal(t, CallerIdentity{
+		Account: "123456789012",
+		ARN:     "arn:aws:sts::123456789012:assumed-role/brains/ci",
+		Source:  stscreds.ProviderName,
+	}, cfg.GetIdentity())
+
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	assert.Len(t, cached, 1)
+	info, err := os.Stat(cached[0])
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// a second run reuses the cached session instead of prompting for MFA again
+	mfaTokenFunc = func(serial string) (string, error) {
+		t.Fatal("MFA prompted with cached credentials")
+		return "", nil
+	}
+	cfg = newConfig()
+	assert.True(t, cfg.SetAndValidateCredentials())
+	assert.Len(t, assumeRoleClient.Inputs, 1)
+	assert.Equal(t, stscreds.ProviderName+" (cached)", cfg.GetIdentity().Source)
+}
+
+func TestCredentialsCacheKeyDiffersByRole(t *testing.T) {
+	a := &AWSConfig{profile: "dev", assumeRole: brainsConfig.AssumeRoleConfig{RoleARN: "arn:aws:iam::1:role/a"}}
+	b := &AWSConfig{profile: "dev", assumeRole: brainsConfig.AssumeRoleConfig{RoleARN: "arn:aws:iam::1:role/b"}}
+	assert.NotEqual(t, a.credentialsCacheKey(), b.credentialsCacheKey())
+	assert.Equal(t, a.credentialsCacheKey(), a.credentialsCacheKey())
+}
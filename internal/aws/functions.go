@@ -4,15 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
-	"github.com/charmbracelet/glamour"
-	"github.com/muesli/termenv"
 	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/llm"
 )
 
 func (a *AWSConfig) DescribeModel(model string) *types.FoundationModelSummary {
@@ -45,6 +47,7 @@ func (a *AWSConfig) CallAWSBedrock(ctx context.Context, modelID string, req Bedr
 		ContentType: aws.String("application/json"),
 		Accept:      aws.String("application/json"),
 	}
+	a.applyInvokeGuardrail(input)
 	spinner, _ := pterm.DefaultSpinner.Start("loading response from AWS Bedrock")
 	resp, err := client.InvokeModel(ctx, input)
 	if err != nil {
@@ -52,6 +55,9 @@ func (a *AWSConfig) CallAWSBedrock(ctx context.Context, modelID string, req Bedr
 		return nil, err
 	}
 	spinner.Success()
+	if err := a.checkInvokeGuardrail(resp.Body); err != nil {
+		return nil, err
+	}
 	return resp.Body, nil
 }
 
@@ -85,6 +91,9 @@ func (a *AWSConfig) CallAWSBedrockConverse(
 	if toolConfig != nil {
 		input.ToolConfig = toolConfig
 	}
+	a.applyConverseTemperature(input)
+	a.applyConverseReasoning(input)
+	a.applyConverseGuardrail(input)
 
 	spinner, _ := pterm.DefaultSpinner.Start("loading response from AWS Bedrock (Converse)")
 	resp, err := client.ConverseModel(ctx, input)
@@ -93,11 +102,15 @@ func (a *AWSConfig) CallAWSBedrockConverse(
 		return nil, err
 	}
 	spinner.Success()
+	if err := a.checkConverseGuardrail(resp); err != nil {
+		return nil, err
+	}
 
 	converseOutput, ok := resp.Output.(*bedrockruntimeTypes.ConverseOutputMemberMessage)
 	if !ok {
 		return nil, fmt.Errorf("unexpected response type from Converse API")
 	}
+	a.logReasoning(modelID, llm.ReasoningText(reasoningFromConverse(converseOutput.Value)))
 
 	// attempt json
 	for _, block := range converseOutput.Value.Content {
@@ -121,12 +134,194 @@ func (a *AWSConfig) CallAWSBedrockConverse(
 	return nil, fmt.Errorf("no tool use or text block found in the response")
 }
 
-func (a *AWSConfig) PrintBedrockMessage(content string) {
-	r, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(120),
-		glamour.WithColorProfile(termenv.ANSI256),
-	)
-	result, _ := r.Render(content)
-	fmt.Println(result)
+func (a *AWSConfig) Chat(ctx context.Context, modelID string, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	bedrockReq := bedrockRequestFromChat(req)
+	bedrockReq.Temperature = a.temperature
+	a.applyInvokeReasoning(modelID, &bedrockReq)
+	respBody, err := a.CallAWSBedrock(ctx, modelID, bedrockReq)
+	if err != nil {
+		return nil, err
+	}
+	var data llm.ChatResponse
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return nil, fmt.Errorf("json Unmarshal error (when parsing Bedrock Body): %w", err)
+	}
+	var reasoning []string
+	for idx := range data.Choices {
+		message := &data.Choices[idx].Message
+		if message.Reasoning == "" {
+			message.Reasoning, message.Content = llm.SplitReasoning(message.Content)
+		}
+		if message.Reasoning != "" {
+			reasoning = append(reasoning, message.Reasoning)
+		}
+	}
+	a.logReasoning(modelID, strings.Join(reasoning, "\n\n"))
+	data.Usage = withReasoningTokens(data.Usage, strings.Join(reasoning, ""))
+	return &data, nil
+}
+
+func (a *AWSConfig) StructuredOutput(ctx context.Context, modelID string, req llm.ChatRequest, tool llm.ToolSpec) ([]byte, error) {
+	return a.CallAWSBedrockConverse(ctx, modelID, bedrockRequestFromChat(req), toolConfigFromSpec(tool))
 }
+
+// ChatWithTools runs a single Converse turn, the model chooses whether to call any of the offered tools
+func (a *AWSConfig) ChatWithTools(ctx context.Context, modelID string, req llm.ToolRequest) (*llm.ToolResponse, error) {
+	input := &bedrockruntime.ConverseInput{
+		ModelId:  aws.String(modelID),
+		Messages: converseMessagesFromTurns(req.Turns),
+	}
+	if len(req.Tools) > 0 {
+		input.ToolConfig = toolConfigFromSpecs(req.Tools)
+	}
+	if req.System != "" {
+		input.System = []bedrockruntimeTypes.SystemContentBlock{
+			&bedrockruntimeTypes.SystemContentBlockMemberText{Value: req.System},
+		}
+	}
+	a.applyConverseTemperature(input)
+	a.applyConverseReasoning(input)
+	a.applyConverseGuardrail(input)
+
+	spinner, _ := pterm.DefaultSpinner.Start("loading response from AWS Bedrock (Converse)")
+	resp, err := a.GetInvoker().ConverseModel(ctx, input)
+	if err != nil {
+		spinner.Fail()
+		return nil, err
+	}
+	spinner.Success()
+	if err := a.checkConverseGuardrail(resp); err != nil {
+		return nil, err
+	}
+
+	converseOutput, ok := resp.Output.(*bedrockruntimeTypes.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type from Converse API")
+	}
+	turn, err := turnFromConverse(converseOutput.Value)
+	if err != nil {
+		return nil, err
+	}
+	reasoning := llm.ReasoningText(turn.Reasoning)
+	a.logReasoning(modelID, reasoning)
+
+	data := &llm.ToolResponse{
+		Turn: turn,
+		Done: resp.StopReason != bedrockruntimeTypes.StopReasonToolUse || len(turn.ToolCalls) == 0,
+	}
+	if resp.Usage != nil {
+		data.Usage = map[string]any{
+			"prompt_tokens":      int(aws.ToInt32(resp.Usage.InputTokens)),
+			"completion_tokens":  int(aws.ToInt32(resp.Usage.OutputTokens)),
+			"cache_read_tokens":  int(aws.ToInt32(resp.Usage.CacheReadInputTokens)),
+			"cache_write_tokens": int(aws.ToInt32(resp.Usage.CacheWriteInputTokens)),
+		}
+		data.Usage = withReasoningTokens(data.Usage, reasoning)
+	}
+	return data, nil
+}
+
+func converseMessagesFromTurns(turns []llm.Turn) []bedrockruntimeTypes.Message {
+	messages := []bedrockruntimeTypes.Message{}
+	for _, turn := range turns {
+		content := converseReasoningBlocks(turn.Reasoning)
+		if turn.Text != "" {
+			content = append(content, &bedrockruntimeTypes.ContentBlockMemberText{Value: turn.Text})
+		}
+		for _, call := range turn.ToolCalls {
+			content = append(content, &bedrockruntimeTypes.ContentBlockMemberToolUse{
+				Value: bedrockruntimeTypes.ToolUseBlock{
+					ToolUseId: aws.String(call.ID),
+					Name:      aws.String(call.Name),
+					Input:     document.NewLazyDocument(call.Input),
+				},
+			})
+		}
+		for _, result := range turn.ToolResults {
+			block := bedrockruntimeTypes.ToolResultBlock{
+				ToolUseId: aws.String(result.ToolCallID),
+				Content: []bedrockruntimeTypes.ToolResultContentBlock{
+					&bedrockruntimeTypes.ToolResultContentBlockMemberText{Value: result.Content},
+				},
+				Status: bedrockruntimeTypes.ToolResultStatusSuccess,
+			}
+			if result.IsError {
+				block.Status = bedrockruntimeTypes.ToolResultStatusError
+			}
+			content = append(content, &bedrockruntimeTypes.ContentBlockMemberToolResult{Value: block})
+		}
+		messages = append(messages, bedrockruntimeTypes.Message{
+			Role:    bedrockruntimeTypes.ConversationRole(turn.Role),
+			Content: content,
+		})
+	}
+	return messages
+}
+
+func turnFromConverse(message bedrockruntimeTypes.Message) (llm.Turn, error) {
+	turn := llm.Turn{Role: string(message.Role), Reasoning: reasoningFromConverse(message)}
+	var text []string
+	for _, block := range message.Content {
+		switch b := block.(type) {
+		case *bedrockruntimeTypes.ContentBlockMemberText:
+			text = append(text, b.Value)
+		case *bedrockruntimeTypes.ContentBlockMemberToolUse:
+			decoded, err := documentToAny(b.Value.Input)
+			if err != nil {
+				return turn, fmt.Errorf("decode input of tool %s: %w", aws.ToString(b.Value.Name), err)
+			}
+			input, _ := decoded.(map[string]any)
+			turn.ToolCalls = append(turn.ToolCalls, llm.ToolCall{
+				ID:    aws.ToString(b.Value.ToolUseId),
+				Name:  aws.ToString(b.Value.Name),
+				Input: input,
+			})
+		}
+	}
+	turn.Text = strings.Join(text, "\n")
+	return turn, nil
+}
+
+func bedrockRequestFromChat(req llm.ChatRequest) BedrockRequest {
+	bedrockReq := BedrockRequest{}
+	for _, m := range req.Messages {
+		bedrockReq.Messages = append(bedrockReq.Messages, BedrockMessage{
+			Role: m.Role,
+			Content: []BedrockContent{
+				{
+					Type: "text",
+					Text: m.Content,
+				},
+			},
+		})
+	}
+	return bedrockReq
+}
+
+// toolConfigFromSpec forces the model to answer through the single tool described by spec
+func toolConfigFromSpec(spec llm.ToolSpec) *bedrockruntimeTypes.ToolConfiguration {
+	toolConfig := toolConfigFromSpecs([]llm.ToolSpec{spec})
+	toolConfig.ToolChoice = &bedrockruntimeTypes.ToolChoiceMemberAny{
+		Value: bedrockruntimeTypes.AnyToolChoice{},
+	}
+	return toolConfig
+}
+
+// toolConfigFromSpecs offers every tool in specs and leaves the choice to the model
+func toolConfigFromSpecs(specs []llm.ToolSpec) *bedrockruntimeTypes.ToolConfiguration {
+	toolConfig := &bedrockruntimeTypes.ToolConfiguration{}
+	for _, spec := range specs {
+		toolConfig.Tools = append(toolConfig.Tools, &bedrockruntimeTypes.ToolMemberToolSpec{
+			Value: bedrockruntimeTypes.ToolSpecification{
+				Name:        aws.String(spec.Name),
+				Description: aws.String(spec.Description),
+				InputSchema: &bedrockruntimeTypes.ToolInputSchemaMemberJson{
+					Value: document.NewLazyDocument(spec.InputSchema),
+				},
+			},
+		})
+	}
+	return toolConfig
+}
+
+func (a *AWSConfig) PrintMessage(content string) { llm.PrintMarkdown(content) }
@@ -10,11 +10,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	awsBrains "github.com/madhuravius/brains/internal/aws"
+	"github.com/madhuravius/brains/internal/llm"
 	mockBrains "github.com/madhuravius/brains/internal/mock"
 )
 
@@ -135,12 +137,107 @@ func TestPrintContext(t *testing.T) {
 	})
 }
 
-func TestPrintBedrockMessage(t *testing.T) {
+func TestPrintMessage(t *testing.T) {
 	cfg := &awsBrains.AWSConfig{}
 	md := "# Header\n\n* item"
 	out := mockBrains.CaptureAllOutput(func() {
-		cfg.PrintBedrockMessage(md)
+		cfg.PrintMessage(md)
 	})
 	assert.NotEmpty(t, out)
 	assert.Contains(t, out, "Header")
 }
+
+func TestChat(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("InvokeModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.InvokeModelInput) bool {
+		return string(in.Body) == `{"messages":[{"role":"user","content":[{"type":"text","text":"hello"}]}]}`
+	})).Return(&bedrockruntime.InvokeModelOutput{
+		Body: []byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":1}}`),
+	}, nil)
+
+	resp, err := cfg.Chat(context.Background(), "model-id", llm.NewUserRequest("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", resp.Choices[0].Message.Content)
+	assert.Equal(t, float64(1), resp.Usage["prompt_tokens"])
+	invokerMock.AssertExpectations(t)
+}
+
+func TestStructuredOutputForcesTool(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		if in.ToolConfig == nil || len(in.ToolConfig.Tools) != 1 {
+			return false
+		}
+		spec, ok := in.ToolConfig.Tools[0].(*bedrockruntimeTypes.ToolMemberToolSpec)
+		_, forced := in.ToolConfig.ToolChoice.(*bedrockruntimeTypes.ToolChoiceMemberAny)
+		return ok && forced && aws.ToString(spec.Value.Name) == "coder"
+	})).Return(&bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: `{"ok":true}`}},
+		}},
+	}, nil)
+
+	out, err := cfg.StructuredOutput(context.Background(), "model-id", llm.NewUserRequest("hello"), llm.ToolSpec{
+		Name:        "coder",
+		InputSchema: map[string]any{"type": "object"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(out))
+	invokerMock.AssertExpectations(t)
+}
+
+func TestChatWithToolsRoundTrip(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		if in.ToolConfig == nil || in.ToolConfig.ToolChoice != nil || len(in.Messages) != 3 || len(in.System) != 1 {
+			return false
+		}
+		toolUse, ok := in.Messages[1].Content[0].(*bedrockruntimeTypes.ContentBlockMemberToolUse)
+		if !ok || aws.ToString(toolUse.Value.ToolUseId) != "call_1" {
+			return false
+		}
+		result, ok := in.Messages[2].Content[0].(*bedrockruntimeTypes.ContentBlockMemberToolResult)
+		return ok && result.Value.Status == bedrockruntimeTypes.ToolResultStatusError
+	})).Return(&bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Role: "assistant",
+			Content: []bedrockruntimeTypes.ContentBlock{
+				&bedrockruntimeTypes.ContentBlockMemberText{Value: "reading another file"},
+				&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+					ToolUseId: aws.String("call_2"),
+					Name:      aws.String("read_file"),
+					Input:     document.NewLazyDocument(map[string]any{"path": "go.mod"}),
+				}},
+			},
+		}},
+		StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		Usage:      &bedrockruntimeTypes.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(4)},
+	}, nil)
+
+	resp, err := cfg.ChatWithTools(context.Background(), "model-id", llm.ToolRequest{
+		System: "system prompt",
+		Turns: []llm.Turn{
+			{Role: "user", Text: "what module is this?"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "read_file", Input: map[string]any{"path": "missing"}}}},
+			{Role: "user", ToolResults: []llm.ToolResult{{ToolCallID: "call_1", Content: "not found", IsError: true}}},
+		},
+		Tools: []llm.ToolSpec{{Name: "read_file", InputSchema: map[string]any{"type": "object"}}},
+	})
+	assert.NoError(t, err)
+	assert.False(t, resp.Done)
+	assert.Equal(t, "reading another file", resp.Turn.Text)
+	assert.Equal(t, []llm.ToolCall{{ID: "call_2", Name: "read_file", Input: map[string]any{"path": "go.mod"}}}, resp.Turn.ToolCalls)
+	promptTokens, completionTokens := llm.UsageTokens(resp.Usage)
+	assert.Equal(t, 10, promptTokens)
+	assert.Equal(t, 4, completionTokens)
+	invokerMock.AssertExpectations(t)
+}
@@ -0,0 +1,265 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+)
+
+func (e *GuardrailInterventionError) Error() string {
+	var policies []string
+	for _, f := range e.Findings {
+		policies = append(policies, fmt.Sprintf("%s %s (%s)", f.Source, f.Policy, f.Type))
+	}
+	if len(policies) == 0 {
+		return fmt.Sprintf("guardrail %s intervened", e.GuardrailID)
+	}
+	return fmt.Sprintf("guardrail %s intervened: %s", e.GuardrailID, strings.Join(policies, ", "))
+}
+
+func (a *AWSConfig) guardrailEnabled() bool { return a.guardrail.Identifier != "" }
+
+func (a *AWSConfig) guardrailVersion() string {
+	if a.guardrail.Version == "" {
+		return defaultGuardrailVersion
+	}
+	return a.guardrail.Version
+}
+
+// guardrailTrace defaults to an enabled trace so that interventions can report the policy that fired
+func (a *AWSConfig) guardrailTrace() string {
+	if a.guardrail.Trace == "" {
+		return string(types.GuardrailTraceEnabled)
+	}
+	return strings.ToLower(a.guardrail.Trace)
+}
+
+func (a *AWSConfig) applyInvokeGuardrail(input *bedrockruntime.InvokeModelInput) {
+	if !a.guardrailEnabled() {
+		return
+	}
+	input.GuardrailIdentifier = aws.String(a.guardrail.Identifier)
+	input.GuardrailVersion = aws.String(a.guardrailVersion())
+	input.Trace = types.Trace(strings.ToUpper(a.guardrailTrace()))
+}
+
+func (a *AWSConfig) applyConverseGuardrail(input *bedrockruntime.ConverseInput) {
+	if !a.guardrailEnabled() {
+		return
+	}
+	input.GuardrailConfig = &types.GuardrailConfiguration{
+		GuardrailIdentifier: aws.String(a.guardrail.Identifier),
+		GuardrailVersion:    aws.String(a.guardrailVersion()),
+		Trace:               types.GuardrailTrace(a.guardrailTrace()),
+	}
+}
+
+// checkInvokeGuardrail inspects an InvokeModel response body for the guardrail action Bedrock adds to it
+func (a *AWSConfig) checkInvokeGuardrail(body []byte) error {
+	if !a.guardrailEnabled() {
+		return nil
+	}
+	var resp invokeGuardrailResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	if resp.Action != guardrailActionIntervened {
+		return nil
+	}
+
+	intervention := &GuardrailInterventionError{GuardrailID: a.guardrail.Identifier}
+	if resp.Trace != nil {
+		for _, assessment := range resp.Trace.Guardrail.Input {
+			intervention.Findings = append(intervention.Findings, assessment.findings("input")...)
+		}
+		for _, outputs := range resp.Trace.Guardrail.Outputs {
+			for _, assessment := range outputs {
+				intervention.Findings = append(intervention.Findings, assessment.findings("output")...)
+			}
+		}
+	}
+	var chat ChatResponse
+	if err := json.Unmarshal(body, &chat); err == nil && len(chat.Choices) > 0 {
+		intervention.Message = chat.Choices[0].Message.Content
+	}
+	return a.reportGuardrailIntervention(intervention)
+}
+
+// checkConverseGuardrail turns a guardrail_intervened stop reason into a GuardrailInterventionError
+func (a *AWSConfig) checkConverseGuardrail(resp *bedrockruntime.ConverseOutput) error {
+	if resp.StopReason != types.StopReasonGuardrailIntervened {
+		return nil
+	}
+
+	intervention := &GuardrailInterventionError{GuardrailID: a.guardrail.Identifier}
+	if resp.Trace != nil && resp.Trace.Guardrail != nil {
+		for _, assessment := range resp.Trace.Guardrail.InputAssessment {
+			intervention.Findings = append(intervention.Findings, assessmentFromSDK(assessment).findings("input")...)
+		}
+		for _, outputs := range resp.Trace.Guardrail.OutputAssessments {
+			for _, assessment := range outputs {
+				intervention.Findings = append(intervention.Findings, assessmentFromSDK(assessment).findings("output")...)
+			}
+		}
+	}
+	if message, ok := resp.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range message.Value.Content {
+			if text, ok := block.(*types.ContentBlockMemberText); ok {
+				intervention.Message = text.Value
+				break
+			}
+		}
+	}
+	return a.reportGuardrailIntervention(intervention)
+}
+
+func (a *AWSConfig) reportGuardrailIntervention(intervention *GuardrailInterventionError) error {
+	sort.SliceStable(intervention.Findings, func(i, j int) bool {
+		return intervention.Findings[i].Source < intervention.Findings[j].Source
+	})
+
+	pterm.Error.Printfln("guardrail %s (version %s) blocked this request", intervention.GuardrailID, a.guardrailVersion())
+	if len(intervention.Findings) > 0 {
+		tableData := pterm.TableData{{"Source", "Policy", "Type", "Match", "Action"}}
+		for _, f := range intervention.Findings {
+			tableData = append(tableData, []string{f.Source, f.Policy, f.Type, f.Match, f.Action})
+		}
+		_ = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+	} else {
+		pterm.Warning.Println("no guardrail trace returned, set guardrail.trace to \"enabled\" for policy details")
+	}
+	if intervention.Message != "" {
+		pterm.Info.Printfln("guardrail response: %s", intervention.Message)
+	}
+
+	if a.logger != nil {
+		findings, _ := json.Marshal(intervention.Findings)
+		a.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventGuardrail, Message: intervention.Error() + "\nfindings: " + string(findings)})
+	}
+	return intervention
+}
+
+func (g guardrailAssessment) findings(source string) []GuardrailFinding {
+	var findings []GuardrailFinding
+	add := func(policy, findingType, match, action string) {
+		if action == "" || action == guardrailActionNone {
+			return
+		}
+		findings = append(findings, GuardrailFinding{
+			Source: source,
+			Policy: policy,
+			Type:   findingType,
+			Match:  match,
+			Action: action,
+		})
+	}
+
+	if g.TopicPolicy != nil {
+		for _, t := range g.TopicPolicy.Topics {
+			add("topic", t.Name, "", t.Action)
+		}
+	}
+	if g.ContentPolicy != nil {
+		for _, f := range g.ContentPolicy.Filters {
+			add("content", f.Type, f.Confidence, f.Action)
+		}
+	}
+	if g.WordPolicy != nil {
+		for _, w := range g.WordPolicy.CustomWords {
+			add("word", "CUSTOM", w.Match, w.Action)
+		}
+		for _, w := range g.WordPolicy.ManagedWordLists {
+			add("word", w.Type, w.Match, w.Action)
+		}
+	}
+	if g.SensitiveInformationPolicy != nil {
+		for _, p := range g.SensitiveInformationPolicy.PiiEntities {
+			add("sensitive_information", p.Type, p.Match, p.Action)
+		}
+		for _, r := range g.SensitiveInformationPolicy.Regexes {
+			add("sensitive_information", r.Name, r.Match, r.Action)
+		}
+	}
+	if g.ContextualGroundingPolicy != nil {
+		for _, f := range g.ContextualGroundingPolicy.Filters {
+			add("contextual_grounding", f.Type, fmt.Sprintf("score %.2f < threshold %.2f", f.Score, f.Threshold), f.Action)
+		}
+	}
+	return findings
+}
+
+func assessmentFromSDK(a types.GuardrailAssessment) guardrailAssessment {
+	var out guardrailAssessment
+	if a.TopicPolicy != nil {
+		out.TopicPolicy = &guardrailTopicPolicy{}
+		for _, t := range a.TopicPolicy.Topics {
+			out.TopicPolicy.Topics = append(out.TopicPolicy.Topics, guardrailTopic{
+				Name:   aws.ToString(t.Name),
+				Action: string(t.Action),
+			})
+		}
+	}
+	if a.ContentPolicy != nil {
+		out.ContentPolicy = &guardrailContentPolicy{}
+		for _, f := range a.ContentPolicy.Filters {
+			out.ContentPolicy.Filters = append(out.ContentPolicy.Filters, guardrailContentFilter{
+				Type:       string(f.Type),
+				Confidence: string(f.Confidence),
+				Action:     string(f.Action),
+			})
+		}
+	}
+	if a.WordPolicy != nil {
+		out.WordPolicy = &guardrailWordPolicy{}
+		for _, w := range a.WordPolicy.CustomWords {
+			out.WordPolicy.CustomWords = append(out.WordPolicy.CustomWords, guardrailWord{
+				Match:  aws.ToString(w.Match),
+				Action: string(w.Action),
+			})
+		}
+		for _, w := range a.WordPolicy.ManagedWordLists {
+			out.WordPolicy.ManagedWordLists = append(out.WordPolicy.ManagedWordLists, guardrailWord{
+				Type:   string(w.Type),
+				Match:  aws.ToString(w.Match),
+				Action: string(w.Action),
+			})
+		}
+	}
+	if a.SensitiveInformationPolicy != nil {
+		out.SensitiveInformationPolicy = &guardrailSensitiveInformationPolicy{}
+		for _, p := range a.SensitiveInformationPolicy.PiiEntities {
+			out.SensitiveInformationPolicy.PiiEntities = append(out.SensitiveInformationPolicy.PiiEntities, guardrailSensitiveMatch{
+				Type:   string(p.Type),
+				Match:  aws.ToString(p.Match),
+				Action: string(p.Action),
+			})
+		}
+		for _, r := range a.SensitiveInformationPolicy.Regexes {
+			out.SensitiveInformationPolicy.Regexes = append(out.SensitiveInformationPolicy.Regexes, guardrailSensitiveMatch{
+				Name:   aws.ToString(r.Name),
+				Match:  aws.ToString(r.Match),
+				Action: string(r.Action),
+			})
+		}
+	}
+	if a.ContextualGroundingPolicy != nil {
+		out.ContextualGroundingPolicy = &guardrailContextualGroundingPolicy{}
+		for _, f := range a.ContextualGroundingPolicy.Filters {
+			out.ContextualGroundingPolicy.Filters = append(out.ContextualGroundingPolicy.Filters, guardrailGroundingFilter{
+				Type:      string(f.Type),
+				Score:     aws.ToFloat64(f.Score),
+				Threshold: aws.ToFloat64(f.Threshold),
+				Action:    string(f.Action),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	awsBrains "github.com/madhuravius/brains/internal/aws"
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+func TestCallAWSBedrockAppliesGuardrail(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+	cfg.SetGuardrail(brainsConfig.GuardrailConfig{Identifier: "gr-123"})
+
+	expectedBody := []byte(`{"choices":[],"amazon-bedrock-guardrailAction":"NONE"}`)
+	invokerMock.On("InvokeModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.InvokeModelInput) bool {
+		return aws.ToString(in.GuardrailIdentifier) == "gr-123" &&
+			aws.ToString(in.GuardrailVersion) == "DRAFT" &&
+			in.Trace == bedrockruntimeTypes.TraceEnabled
+	})).Return(&bedrockruntime.InvokeModelOutput{Body: expectedBody}, nil)
+
+	body, err := cfg.CallAWSBedrock(context.Background(), "model-id", awsBrains.BedrockRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBody, body)
+	invokerMock.AssertExpectations(t)
+}
+
+func TestCallAWSBedrockGuardrailIntervened(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	logger := &mockBrains.TestLogger{}
+	cfg.SetInvoker(invokerMock)
+	cfg.SetLogger(logger)
+	cfg.SetGuardrail(brainsConfig.GuardrailConfig{Identifier: "gr-123", Version: "2", Trace: "enabled"})
+
+	body := []byte(`{
+		"choices": [{"message": {"role": "assistant", "content": "Sorry, I can't help with that."}}],
+		"amazon-bedrock-guardrailAction": "INTERVENED",
+		"amazon-bedrock-trace": {"guardrail": {"input": {"gr-123": {
+			"topicPolicy": {"topics": [{"name": "Investments", "type": "DENY", "action": "BLOCKED"}]},
+			"contentPolicy": {"filters": [{"type": "HATE", "confidence": "NONE", "action": "NONE"}]},
+			"sensitiveInformationPolicy": {"piiEntities": [{"type": "EMAIL", "match": "a@b.com", "action": "ANONYMIZED"}]}
+		}}}}
+	}`)
+	invokerMock.On("InvokeModel", mock.Anything, mock.Anything).Return(&bedrockruntime.InvokeModelOutput{Body: body}, nil)
+
+	_, err := cfg.CallAWSBedrock(context.Background(), "model-id", awsBrains.BedrockRequest{})
+	var intervention *awsBrains.GuardrailInterventionError
+	assert.True(t, errors.As(err, &intervention))
+	assert.Equal(t, "gr-123", intervention.GuardrailID)
+	assert.Equal(t, "Sorry, I can't help with that.", intervention.Message)
+	assert.Equal(t, []awsBrains.GuardrailFinding{
+		{Source: "input", Policy: "topic", Type: "Investments", Action: "BLOCKED"},
+		{Source: "input", Policy: "sensitive_information", Type: "EMAIL", Match: "a@b.com", Action: "ANONYMIZED"},
+	}, intervention.Findings)
+	assert.Equal(t, brainsConfig.EventGuardrail, logger.Last().Type)
+	assert.Contains(t, logger.Last().Message, "Investments")
+}
+
+func TestCallAWSBedrockConverseGuardrailIntervened(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	invokerMock := &mockBrains.MockInvoker{}
+	logger := &mockBrains.TestLogger{}
+	cfg.SetInvoker(invokerMock)
+	cfg.SetLogger(logger)
+	cfg.SetGuardrail(brainsConfig.GuardrailConfig{Identifier: "gr-123", Trace: "enabled_full"})
+
+	out := &bedrockruntime.ConverseOutput{
+		StopReason: bedrockruntimeTypes.StopReasonGuardrailIntervened,
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Role:    bedrockruntimeTypes.ConversationRoleAssistant,
+			Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: "blocked"}},
+		}},
+		Trace: &bedrockruntimeTypes.ConverseTrace{Guardrail: &bedrockruntimeTypes.GuardrailTraceAssessment{
+			OutputAssessments: map[string][]bedrockruntimeTypes.GuardrailAssessment{
+				"gr-123": {{
+					WordPolicy: &bedrockruntimeTypes.GuardrailWordPolicyAssessment{
+						CustomWords: []bedrockruntimeTypes.GuardrailCustomWord{{
+							Match:  aws.String("secret"),
+							Action: bedrockruntimeTypes.GuardrailWordPolicyActionBlocked,
+						}},
+					},
+				}},
+			},
+		}},
+	}
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		return in.GuardrailConfig != nil &&
+			aws.ToString(in.GuardrailConfig.GuardrailIdentifier) == "gr-123" &&
+			in.GuardrailConfig.Trace == bedrockruntimeTypes.GuardrailTraceEnabledFull
+	})).Return(out, nil)
+
+	_, err := cfg.CallAWSBedrockConverse(context.Background(), "model-id", awsBrains.BedrockRequest{}, nil)
+	var intervention *awsBrains.GuardrailInterventionError
+	assert.True(t, errors.As(err, &intervention))
+	assert.Equal(t, "blocked", intervention.Message)
+	assert.Equal(t, []awsBrains.GuardrailFinding{
+		{Source: "output", Policy: "word", Type: "CUSTOM", Match: "secret", Action: "BLOCKED"},
+	}, intervention.Findings)
+	assert.Contains(t, err.Error(), "output word (CUSTOM)")
+	assert.Equal(t, brainsConfig.EventGuardrail, logger.Last().Type)
+	invokerMock.AssertExpectations(t)
+}
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 )
@@ -10,6 +11,8 @@ import (
 type BedrockInvoker interface {
 	InvokeModel(ctx context.Context, input *bedrockruntime.InvokeModelInput) (*bedrockruntime.InvokeModelOutput, error)
 	ListFoundationModels(ctx context.Context, input *bedrock.ListFoundationModelsInput) (*bedrock.ListFoundationModelsOutput, error)
+	ListInferenceProfiles(ctx context.Context, input *bedrock.ListInferenceProfilesInput) (*bedrock.ListInferenceProfilesOutput, error)
+	GetFoundationModelAvailability(ctx context.Context, input *bedrock.GetFoundationModelAvailabilityInput) (*bedrock.GetFoundationModelAvailabilityOutput, error)
 	ConverseModel(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error)
 }
 
@@ -26,6 +29,14 @@ func (c *clientInvoker) ListFoundationModels(ctx context.Context, input *bedrock
 	return c.bedrockClient.ListFoundationModels(ctx, input)
 }
 
+func (c *clientInvoker) ListInferenceProfiles(ctx context.Context, input *bedrock.ListInferenceProfilesInput) (*bedrock.ListInferenceProfilesOutput, error) {
+	return c.bedrockClient.ListInferenceProfiles(ctx, input)
+}
+
+func (c *clientInvoker) GetFoundationModelAvailability(ctx context.Context, input *bedrock.GetFoundationModelAvailabilityInput) (*bedrock.GetFoundationModelAvailabilityOutput, error) {
+	return c.bedrockClient.GetFoundationModelAvailability(ctx, input)
+}
+
 func (c *clientInvoker) ConverseModel(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
 	return c.bedrockruntimeClient.Converse(ctx, input)
 }
@@ -38,8 +49,20 @@ func (a *AWSConfig) GetInvoker() BedrockInvoker {
 	if a.invoker != nil {
 		return a.invoker
 	}
-	return &clientInvoker{
-		bedrockruntimeClient: bedrockruntime.NewFromConfig(a.cfg),
-		bedrockClient:        bedrock.NewFromConfig(a.cfg),
+	var (
+		runtimeOptions []func(*bedrockruntime.Options)
+		bedrockOptions []func(*bedrock.Options)
+	)
+	if a.endpointURL != "" {
+		runtimeOptions = append(runtimeOptions, func(o *bedrockruntime.Options) { o.BaseEndpoint = aws.String(a.endpointURL) })
+		bedrockOptions = append(bedrockOptions, func(o *bedrock.Options) { o.BaseEndpoint = aws.String(a.endpointURL) })
+	}
+	client := &clientInvoker{
+		bedrockruntimeClient: bedrockruntime.NewFromConfig(a.cfg, runtimeOptions...),
+		bedrockClient:        bedrock.NewFromConfig(a.cfg, bedrockOptions...),
+	}
+	if a.recorder != nil {
+		return a.recorder.wrap(client)
 	}
+	return client
 }
@@ -3,8 +3,12 @@ package aws
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +28,12 @@ func TestClientInvokerExecutesAllMethods(t *testing.T) {
 	mockInv.
 		On("ListFoundationModels", mock.Anything, mock.Anything).
 		Return((*bedrock.ListFoundationModelsOutput)(nil), errors.New("list error"))
+	mockInv.
+		On("ListInferenceProfiles", mock.Anything, mock.Anything).
+		Return((*bedrock.ListInferenceProfilesOutput)(nil), errors.New("profiles error"))
+	mockInv.
+		On("GetFoundationModelAvailability", mock.Anything, mock.Anything).
+		Return((*bedrock.GetFoundationModelAvailabilityOutput)(nil), errors.New("availability error"))
 	mockInv.
 		On("ConverseModel", mock.Anything, mock.Anything).
 		Return((*bedrockruntime.ConverseOutput)(nil), errors.New("converse error"))
@@ -37,8 +47,35 @@ func TestClientInvokerExecutesAllMethods(t *testing.T) {
 	_, err = inv.ListFoundationModels(context.Background(), &bedrock.ListFoundationModelsInput{})
 	assert.Error(t, err)
 
+	_, err = inv.ListInferenceProfiles(context.Background(), &bedrock.ListInferenceProfilesInput{})
+	assert.Error(t, err)
+
+	_, err = inv.GetFoundationModelAvailability(context.Background(), &bedrock.GetFoundationModelAvailabilityInput{})
+	assert.Error(t, err)
+
 	_, err = inv.ConverseModel(context.Background(), &bedrockruntime.ConverseInput{})
 	assert.Error(t, err)
 
 	mockInv.AssertExpectations(t)
 }
+
+func TestGetInvokerUsesEndpointOverride(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &AWSConfig{cfg: aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("fake", "fake", ""),
+	}}
+	cfg.SetEndpoint(srv.URL)
+
+	body, err := cfg.CallAWSBedrock(context.Background(), "model-id", BedrockRequest{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"choices":[]}`, string(body))
+	assert.Equal(t, "/model/model-id/invoke", path)
+}
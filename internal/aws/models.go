@@ -3,15 +3,21 @@ package aws
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 
 	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
 )
 
+// AWSImpl is the Bedrock implementation of llm.LLMImpl along with the
+// Bedrock-only operations used by the CLI.
 type AWSImpl interface {
+	llm.LLMImpl
+
 	CallAWSBedrock(ctx context.Context, modelID string, req BedrockRequest) ([]byte, error)
 	CallAWSBedrockConverse(
 		ctx context.Context,
@@ -21,28 +27,85 @@ type AWSImpl interface {
 	) ([]byte, error)
 	DescribeModel(model string) *types.FoundationModelSummary
 	GetConfig() aws.Config
-	PrintBedrockMessage(content string)
-	PrintContext(usage map[string]any, modelID string)
-	PrintCost(usage map[string]any, modelID string)
-	PrintPricing(modelID string) error
-	SetAndValidateCredentials() bool
-	SetLogger(l brainsConfig.SimpleLogger)
+	GetIdentity() CallerIdentity
+	HasPricing(modelID string) bool
+	ListModels(ctx context.Context, filter ModelFilter) ([]ModelListing, error)
+	PrintIdentity()
+	PrintModels(models []ModelListing, activeModelID string) error
+	RefreshPricing(ctx context.Context, exportPath string) ([]ModelPricing, error)
+	SetCredentials(profile string, assumeRole brainsConfig.AssumeRoleConfig)
+	SetEndpoint(endpointURL string)
+	SetGuardrail(guardrail brainsConfig.GuardrailConfig)
 	SetPricing(pricing []ModelPricing)
 }
 
-type AggregatedModelPricing struct {
-	ModelName             string
-	ModelID               string
-	ProviderName          string
-	InputCostPer1kTokens  float64
-	OutputCostPer1kTokens float64
+type ModelPricing struct {
+	ModelID                   string  `json:"ModelID"`
+	ModelName                 string  `json:"ModelName"`
+	InputCostPer1kTokens      float64 `json:"InputCostPer1kTokens"`
+	OutputCostPer1kTokens     float64 `json:"OutputCostPer1kTokens"`
+	CacheReadCostPer1kTokens  float64 `json:"CacheReadCostPer1kTokens,omitempty"`
+	CacheWriteCostPer1kTokens float64 `json:"CacheWriteCostPer1kTokens,omitempty"`
 }
 
-type ModelPricing struct {
-	ModelID               string  `json:"ModelID"`
-	ModelName             string  `json:"ModelName"`
-	InputCostPer1kTokens  float64 `json:"InputCostPer1kTokens"`
-	OutputCostPer1kTokens float64 `json:"OutputCostPer1kTokens"`
+// PricingCache is the pricing written by "brains pricing refresh" for a single region
+type PricingCache struct {
+	Region      string         `json:"region"`
+	RefreshedAt time.Time      `json:"refreshed_at"`
+	Models      []ModelPricing `json:"models"`
+}
+
+// the following are the parts of a Price List API product used to build pricing
+type priceListAttributes struct {
+	Model         string `json:"model"`
+	ModelID       string `json:"modelId"`
+	InferenceType string `json:"inferenceType"`
+	Feature       string `json:"feature"`
+	UsageType     string `json:"usagetype"`
+	RegionCode    string `json:"regionCode"`
+}
+
+type priceListDimension struct {
+	Unit         string `json:"unit"`
+	PricePerUnit struct {
+		USD string `json:"USD"`
+	} `json:"pricePerUnit"`
+}
+
+type priceListProduct struct {
+	Product struct {
+		SKU        string              `json:"sku"`
+		Attributes priceListAttributes `json:"attributes"`
+	} `json:"product"`
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]priceListDimension `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+type ModelFilter struct {
+	Provider  string
+	Modality  string
+	Streaming bool
+	ToolUse   bool
+}
+
+type ModelAccess string
+
+// ModelListing is a foundation model or cross-region inference profile joined
+// with its pricing and the account's access to it.
+type ModelListing struct {
+	ModelID          string
+	ModelName        string
+	ProviderName     string
+	BaseModelID      string
+	InputModalities  []string
+	OutputModalities []string
+	Streaming        bool
+	ToolUse          bool
+	Access           ModelAccess
+	Pricing          *ModelPricing
 }
 
 type AWSConfig struct {
@@ -51,23 +114,260 @@ type AWSConfig struct {
 	invoker BedrockInvoker
 	logger  brainsConfig.SimpleLogger
 
-	pricing []ModelPricing
+	// profile and assumeRole select the credentials used, the default chain is used when both are empty
+	profile    string
+	assumeRole brainsConfig.AssumeRoleConfig
+	// credentialsCacheDir holds assumed role credentials between runs, caching is off when empty
+	credentialsCacheDir string
+	identity            CallerIdentity
+
+	// endpointURL replaces the Bedrock and STS endpoints when set
+	endpointURL string
+
+	guardrail brainsConfig.GuardrailConfig
+	pricing   []ModelPricing
+	// reasoningBudget is the thinking budget in tokens, reasoning is left to the model default when zero
+	reasoningBudget int
+	// temperature comes from the persona, the model default is used when nil
+	temperature *float64
+
+	// recorder captures model calls to a cassette when BRAINS_RECORD is set
+	recorder *cassetteRecorder
+	// offline is set when replaying a cassette, no AWS calls are made
+	offline bool
 }
 
-type ResponseMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// CallerIdentity is the principal resolved while validating credentials
+type CallerIdentity struct {
+	Account string
+	ARN     string
+	// Source is the SDK provider the credentials came from (ex: SharedConfigCredentials, AssumeRoleProvider)
+	Source string
+}
+
+// Cassette is a recorded session of InvokeModel and Converse calls that can
+// be replayed without AWS access.
+type Cassette struct {
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+type CassetteInteraction struct {
+	Operation string          `json:"operation"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// the following are the Bedrock wire formats used to store requests and responses in cassettes
+type invokeWireRequest struct {
+	ModelID             string `json:"modelId"`
+	Body                any    `json:"body"`
+	GuardrailIdentifier string `json:"guardrailIdentifier,omitempty"`
+	GuardrailVersion    string `json:"guardrailVersion,omitempty"`
+	Trace               string `json:"trace,omitempty"`
+}
+
+type invokeWireResponse struct {
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyBytes   []byte          `json:"bodyBytes,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+}
+
+type converseWireText struct {
+	Text string `json:"text"`
+}
+
+type converseWireToolUse struct {
+	ToolUseID string `json:"toolUseId"`
+	Name      string `json:"name"`
+	Input     any    `json:"input"`
+}
+
+type converseWireToolResultContent struct {
+	Text *string `json:"text,omitempty"`
+	JSON any     `json:"json,omitempty"`
+}
+
+type converseWireToolResult struct {
+	ToolUseID string                          `json:"toolUseId"`
+	Status    string                          `json:"status,omitempty"`
+	Content   []converseWireToolResultContent `json:"content"`
+}
+
+type converseWireReasoningText struct {
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type converseWireReasoning struct {
+	ReasoningText   *converseWireReasoningText `json:"reasoningText,omitempty"`
+	RedactedContent []byte                     `json:"redactedContent,omitempty"`
+}
+
+type converseWireBlock struct {
+	Text             *string                 `json:"text,omitempty"`
+	ToolUse          *converseWireToolUse    `json:"toolUse,omitempty"`
+	ToolResult       *converseWireToolResult `json:"toolResult,omitempty"`
+	ReasoningContent *converseWireReasoning  `json:"reasoningContent,omitempty"`
+	Unsupported      string                  `json:"unsupported,omitempty"`
+}
+
+type converseWireMessage struct {
+	Role    string              `json:"role"`
+	Content []converseWireBlock `json:"content"`
+}
+
+type converseWireToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"inputSchema,omitempty"`
 }
 
-type ResponseChoice struct {
-	Message ResponseMessage `json:"message"`
+type converseWireTool struct {
+	ToolSpec converseWireToolSpec `json:"toolSpec"`
 }
 
-type ChatResponse struct {
-	Choices []ResponseChoice `json:"choices"`
-	Usage   map[string]any
+type converseWireToolConfig struct {
+	Tools      []converseWireTool `json:"tools"`
+	ToolChoice map[string]any     `json:"toolChoice,omitempty"`
 }
 
+type converseWireInferenceConfig struct {
+	MaxTokens     *int32   `json:"maxTokens,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+	Temperature   *float32 `json:"temperature,omitempty"`
+	TopP          *float32 `json:"topP,omitempty"`
+}
+
+type converseWireGuardrailConfig struct {
+	GuardrailIdentifier string `json:"guardrailIdentifier"`
+	GuardrailVersion    string `json:"guardrailVersion"`
+	Trace               string `json:"trace,omitempty"`
+}
+
+type converseWireRequest struct {
+	ModelID                      string                       `json:"modelId"`
+	Messages                     []converseWireMessage        `json:"messages"`
+	System                       []converseWireText           `json:"system,omitempty"`
+	ToolConfig                   *converseWireToolConfig      `json:"toolConfig,omitempty"`
+	InferenceConfig              *converseWireInferenceConfig `json:"inferenceConfig,omitempty"`
+	GuardrailConfig              *converseWireGuardrailConfig `json:"guardrailConfig,omitempty"`
+	AdditionalModelRequestFields any                          `json:"additionalModelRequestFields,omitempty"`
+}
+
+type converseWireUsage struct {
+	InputTokens  int32 `json:"inputTokens"`
+	OutputTokens int32 `json:"outputTokens"`
+	TotalTokens  int32 `json:"totalTokens"`
+}
+
+type converseWireOutput struct {
+	Message *converseWireMessage `json:"message,omitempty"`
+}
+
+type converseWireResponse struct {
+	Output     converseWireOutput `json:"output"`
+	StopReason string             `json:"stopReason"`
+	Usage      *converseWireUsage `json:"usage,omitempty"`
+}
+
+// GuardrailFinding is a single policy match from a guardrail assessment
+type GuardrailFinding struct {
+	Source string `json:"source"`
+	Policy string `json:"policy"`
+	Type   string `json:"type"`
+	Match  string `json:"match,omitempty"`
+	Action string `json:"action"`
+}
+
+// GuardrailInterventionError is returned when a configured guardrail blocks a
+// prompt or a model response.
+type GuardrailInterventionError struct {
+	GuardrailID string
+	Message     string
+	Findings    []GuardrailFinding
+}
+
+// the following mirror the guardrail trace that InvokeModel appends to response bodies
+type invokeGuardrailResponse struct {
+	Action string                `json:"amazon-bedrock-guardrailAction"`
+	Trace  *invokeGuardrailTrace `json:"amazon-bedrock-trace"`
+}
+
+type invokeGuardrailTrace struct {
+	Guardrail struct {
+		Input   map[string]guardrailAssessment   `json:"input"`
+		Outputs []map[string]guardrailAssessment `json:"outputs"`
+	} `json:"guardrail"`
+}
+
+type guardrailAssessment struct {
+	TopicPolicy                *guardrailTopicPolicy                `json:"topicPolicy,omitempty"`
+	ContentPolicy              *guardrailContentPolicy              `json:"contentPolicy,omitempty"`
+	WordPolicy                 *guardrailWordPolicy                 `json:"wordPolicy,omitempty"`
+	SensitiveInformationPolicy *guardrailSensitiveInformationPolicy `json:"sensitiveInformationPolicy,omitempty"`
+	ContextualGroundingPolicy  *guardrailContextualGroundingPolicy  `json:"contextualGroundingPolicy,omitempty"`
+}
+
+type guardrailTopic struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+type guardrailTopicPolicy struct {
+	Topics []guardrailTopic `json:"topics"`
+}
+
+type guardrailContentFilter struct {
+	Type       string `json:"type"`
+	Confidence string `json:"confidence"`
+	Action     string `json:"action"`
+}
+
+type guardrailContentPolicy struct {
+	Filters []guardrailContentFilter `json:"filters"`
+}
+
+type guardrailWord struct {
+	Type   string `json:"type,omitempty"`
+	Match  string `json:"match"`
+	Action string `json:"action"`
+}
+
+type guardrailWordPolicy struct {
+	CustomWords      []guardrailWord `json:"customWords"`
+	ManagedWordLists []guardrailWord `json:"managedWordLists"`
+}
+
+type guardrailSensitiveMatch struct {
+	Name   string `json:"name,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Match  string `json:"match"`
+	Action string `json:"action"`
+}
+
+type guardrailSensitiveInformationPolicy struct {
+	PiiEntities []guardrailSensitiveMatch `json:"piiEntities"`
+	Regexes     []guardrailSensitiveMatch `json:"regexes"`
+}
+
+type guardrailGroundingFilter struct {
+	Type      string  `json:"type"`
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+	Action    string  `json:"action"`
+}
+
+type guardrailContextualGroundingPolicy struct {
+	Filters []guardrailGroundingFilter `json:"filters"`
+}
+
+type (
+	ResponseMessage = llm.ResponseMessage
+	ResponseChoice  = llm.ResponseChoice
+	ChatResponse    = llm.ChatResponse
+)
+
 type BedrockSource struct {
 	Type      string `json:"type"`
 	MediaType string `json:"media_type"`
@@ -112,6 +412,7 @@ type BedrockRequest struct {
 	Tools            []BedrockTool      `json:"tools,omitempty"`
 	ToolChoice       *BedrockToolChoice `json:"tool_choice,omitempty"`
 	StopSequences    []string           `json:"stop_sequences,omitempty"`
+	ReasoningEffort  string             `json:"reasoning_effort,omitempty"`
 }
 
 type CodeUpdate struct {
@@ -2,8 +2,11 @@ package aws
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/llm"
 )
 
 func (c *AWSConfig) pricingFor(modelID string) (ModelPricing, bool) {
@@ -12,45 +15,38 @@ func (c *AWSConfig) pricingFor(modelID string) (ModelPricing, bool) {
 			return p, true
 		}
 	}
+	for _, prefix := range inferenceProfilePrefixes {
+		if baseModelID, ok := strings.CutPrefix(modelID, prefix); ok {
+			return c.pricingFor(baseModelID)
+		}
+	}
 	return ModelPricing{}, false
 }
 
-func (a *AWSConfig) PrintCost(usage map[string]any, modelID string) {
-	p := ModelPricing{}
-	if val, ok := a.pricingFor(modelID); ok {
-		p = val
-	}
-	promptTokens, completionTokens := 0, 0
-	if v, ok := usage["prompt_tokens"]; ok {
-		if n, ok := v.(float64); ok {
-			promptTokens = int(n)
-		}
-	}
-	if v, ok := usage["completion_tokens"]; ok {
-		if n, ok := v.(float64); ok {
-			completionTokens = int(n)
-		}
-	}
+// HasPricing reports whether modelID, or the model behind an inference profile, is in the pricing tables
+func (a *AWSConfig) HasPricing(modelID string) bool {
+	_, ok := a.pricingFor(modelID)
+	return ok
+}
+
+// Cost estimates the price of usage from the pricing tables, false when modelID is not in them
+func (a *AWSConfig) Cost(usage map[string]any, modelID string) (float64, bool) {
+	p, ok := a.pricingFor(modelID)
+	promptTokens, completionTokens := llm.UsageTokens(usage)
+	cacheReadTokens, cacheWriteTokens := llm.CacheTokens(usage)
 	cost := (float64(promptTokens)/1000.0)*p.InputCostPer1kTokens + (float64(completionTokens)/1000.0)*p.
-		OutputCostPer1kTokens
-	pterm.Info.Printf("estimated cost for this request (%s): $%.6f (prompt %d, completion %d)\n", modelID, cost, promptTokens,
-		completionTokens)
+		OutputCostPer1kTokens + (float64(cacheReadTokens)/1000.0)*p.CacheReadCostPer1kTokens +
+		(float64(cacheWriteTokens)/1000.0)*p.CacheWriteCostPer1kTokens
+	return cost, ok
+}
+
+func (a *AWSConfig) PrintCost(usage map[string]any, modelID string) {
+	cost, _ := a.Cost(usage, modelID)
+	pterm.Info.Printf("estimated cost for this request (%s): $%.6f (%s)\n", modelID, cost, llm.TokenSummary(usage))
 }
 
 func (a *AWSConfig) PrintContext(usage map[string]any, modelID string) {
-	promptTokens, completionTokens := 0, 0
-	if v, ok := usage["prompt_tokens"]; ok {
-		if n, ok := v.(float64); ok {
-			promptTokens = int(n)
-		}
-	}
-	if v, ok := usage["completion_tokens"]; ok {
-		if n, ok := v.(float64); ok {
-			completionTokens = int(n)
-		}
-	}
-	total := promptTokens + completionTokens
-	pterm.Info.Printf("current context used (%s): %d tokens (limit %d)\n", modelID, total, TokenLimit)
+	llm.PrintContextUsage(usage, modelID)
 }
 
 func (a *AWSConfig) PrintPricing(modelID string) error {
@@ -59,6 +55,8 @@ func (a *AWSConfig) PrintPricing(modelID string) error {
 		"Model Name",
 		"Input Cost / 1k Tokens",
 		"Output Cost / 1k Tokens",
+		"Cache Read / 1k Tokens",
+		"Cache Write / 1k Tokens",
 	}}
 	var activeModel ModelPricing
 	for _, p := range a.pricing {
@@ -70,6 +68,8 @@ func (a *AWSConfig) PrintPricing(modelID string) error {
 			p.ModelName,
 			fmt.Sprintf("%f", p.InputCostPer1kTokens),
 			fmt.Sprintf("%f", p.OutputCostPer1kTokens),
+			fmt.Sprintf("%f", p.CacheReadCostPer1kTokens),
+			fmt.Sprintf("%f", p.CacheWriteCostPer1kTokens),
 		})
 	}
 	if err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render(); err != nil {
@@ -0,0 +1,282 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingTypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/pterm/pterm"
+)
+
+var newPricingClientFunc = func(cfg aws.Config) pricing.GetProductsAPIClient {
+	// the Price List API is only served from a few regions, us-east-1 answers for all of them
+	return pricing.NewFromConfig(cfg, func(o *pricing.Options) { o.Region = pricingAPIRegion })
+}
+
+// PricingCachePath is where "brains pricing refresh" writes pricing, it is preferred over the embedded pricing
+func PricingCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, pricingCacheFile), nil
+}
+
+// FetchPriceList pages through the Bedrock products of the Price List API for a region
+func FetchPriceList(ctx context.Context, client pricing.GetProductsAPIClient, region string) ([]string, error) {
+	var priceList []string
+	for _, serviceCode := range pricingServiceCodes {
+		paginator := pricing.NewGetProductsPaginator(client, &pricing.GetProductsInput{
+			ServiceCode: aws.String(serviceCode),
+			Filters: []pricingTypes.Filter{{
+				Type:  pricingTypes.FilterTypeTermMatch,
+				Field: aws.String("regionCode"),
+				Value: aws.String(region),
+			}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("get %s products: %w", serviceCode, err)
+			}
+			priceList = append(priceList, page.PriceList...)
+		}
+	}
+	return priceList, nil
+}
+
+// LoadPriceListExport reads a saved Price List API export, either the output of
+// "aws pricing get-products" ({"PriceList": [...]}) or a bare list, entries may be JSON strings or objects
+func LoadPriceListExport(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export struct {
+		PriceList []json.RawMessage `json:"PriceList"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		if err := json.Unmarshal(data, &export.PriceList); err != nil {
+			return nil, fmt.Errorf("parse price list export %s: %w", path, err)
+		}
+	}
+
+	var priceList []string
+	for _, raw := range export.PriceList {
+		var item string
+		if err := json.Unmarshal(raw, &item); err != nil {
+			item = string(raw)
+		}
+		priceList = append(priceList, item)
+	}
+	return priceList, nil
+}
+
+// BuildPricing joins Price List products to foundation models in region. A product only prices the
+// models whose ID or name matches its attributes exactly, on-demand input, output and cache
+// dimensions are kept while batch, provisioned and other tiers are skipped.
+func BuildPricing(priceList []string, models []types.FoundationModelSummary, region string) []ModelPricing {
+	byID := map[string]types.FoundationModelSummary{}
+	byName := map[string][]string{}
+	for _, m := range models {
+		modelID := aws.ToString(m.ModelId)
+		byID[modelID] = m
+		name := normalizeModelName(aws.ToString(m.ModelName))
+		byName[name] = append(byName[name], modelID)
+	}
+
+	prices := map[string]*ModelPricing{}
+	for _, item := range priceList {
+		var product priceListProduct
+		if err := json.Unmarshal([]byte(item), &product); err != nil {
+			pterm.Warning.Printfln("skipping unreadable price list entry: %v", err)
+			continue
+		}
+		attributes := product.Product.Attributes
+		if attributes.RegionCode != region || !isOnDemandPrice(attributes) {
+			continue
+		}
+		dimension := priceDimension(attributes)
+		if dimension == "" {
+			continue
+		}
+		price, ok := product.pricePer1kTokens()
+		if !ok {
+			continue
+		}
+
+		var modelIDs []string
+		if _, ok := byID[attributes.ModelID]; ok {
+			modelIDs = []string{attributes.ModelID}
+		} else if attributes.Model != "" {
+			modelIDs = byName[normalizeModelName(attributes.Model)]
+		}
+		for _, modelID := range modelIDs {
+			p, ok := prices[modelID]
+			if !ok {
+				p = &ModelPricing{ModelID: modelID, ModelName: aws.ToString(byID[modelID].ModelName)}
+				prices[modelID] = p
+			}
+			switch dimension {
+			case priceDimensionInput:
+				p.InputCostPer1kTokens = price
+			case priceDimensionOutput:
+				p.OutputCostPer1kTokens = price
+			case priceDimensionCacheRead:
+				p.CacheReadCostPer1kTokens = price
+			case priceDimensionCacheWrite:
+				p.CacheWriteCostPer1kTokens = price
+			}
+		}
+	}
+
+	out := make([]ModelPricing, 0, len(prices))
+	for _, p := range prices {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModelID < out[j].ModelID })
+	return out
+}
+
+// RefreshPricing rebuilds pricing for the configured region and writes it to the pricing cache. The price
+// list is fetched from the Price List API unless exportPath points at a saved export.
+func (a *AWSConfig) RefreshPricing(ctx context.Context, exportPath string) ([]ModelPricing, error) {
+	var priceList []string
+	var err error
+	if exportPath != "" {
+		priceList, err = LoadPriceListExport(exportPath)
+	} else {
+		spinner, _ := pterm.DefaultSpinner.Start("loading prices from the AWS Price List API")
+		priceList, err = FetchPriceList(ctx, newPricingClientFunc(a.cfg), a.region)
+		if err != nil {
+			spinner.Fail()
+		} else {
+			spinner.Success()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := a.GetInvoker().ListFoundationModels(ctx, &bedrock.ListFoundationModelsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("list foundation models: %w", err)
+	}
+	modelsPricing := BuildPricing(priceList, out.ModelSummaries, a.region)
+	if len(modelsPricing) == 0 {
+		return nil, fmt.Errorf("no prices in the price list matched a model in %s", a.region)
+	}
+
+	path, err := PricingCachePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := writePricingCache(path, PricingCache{
+		Region:      a.region,
+		RefreshedAt: time.Now().UTC(),
+		Models:      modelsPricing,
+	}); err != nil {
+		return nil, err
+	}
+	a.SetPricing(modelsPricing)
+	return modelsPricing, nil
+}
+
+// loadPricingCache returns the refreshed pricing for region, ok is false when there is none
+func loadPricingCache(path, region string) ([]ModelPricing, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var cache PricingCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		pterm.Warning.Printfln("ignoring unreadable pricing cache %s: %v", path, err)
+		return nil, false
+	}
+	if cache.Region != region || len(cache.Models) == 0 {
+		return nil, false
+	}
+	return cache.Models, true
+}
+
+func writePricingCache(path string, cache PricingCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func isOnDemandPrice(attributes priceListAttributes) bool {
+	tier := strings.ToLower(strings.Join([]string{attributes.Feature, attributes.InferenceType, attributes.UsageType}, " "))
+	for _, skipped := range skippedPriceTiers {
+		if strings.Contains(tier, skipped) {
+			return false
+		}
+	}
+	return true
+}
+
+// priceDimension classifies a product as input, output, cache read or cache write tokens
+func priceDimension(attributes priceListAttributes) string {
+	kind := strings.ToLower(attributes.InferenceType + " " + attributes.UsageType)
+	kind = strings.NewReplacer("-", " ", "_", " ").Replace(kind)
+	switch {
+	case strings.Contains(kind, "cache read"):
+		return priceDimensionCacheRead
+	case strings.Contains(kind, "cache write"):
+		return priceDimensionCacheWrite
+	case strings.Contains(kind, "input"):
+		return priceDimensionInput
+	case strings.Contains(kind, "output"):
+		return priceDimensionOutput
+	}
+	return ""
+}
+
+// pricePer1kTokens reads the on-demand USD price, converting per million and per token units
+func (p priceListProduct) pricePer1kTokens() (float64, bool) {
+	for _, term := range p.Terms.OnDemand {
+		for _, dimension := range term.PriceDimensions {
+			price, err := strconv.ParseFloat(dimension.PricePerUnit.USD, 64)
+			if err != nil {
+				continue
+			}
+			unit := strings.ToLower(dimension.Unit)
+			switch {
+			case strings.Contains(unit, "1k"):
+				return price, true
+			case strings.Contains(unit, "1m"):
+				return price / 1000, true
+			case strings.Contains(unit, "token"):
+				return price * 1000, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// normalizeModelName ignores case, spacing and punctuation so "Claude 3.5 Sonnet" matches "claude-3-5-sonnet"
+func normalizeModelName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package aws_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	awsBrains "github.com/madhuravius/brains/internal/aws"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+const priceListExport = "testdata/price_list_export.json"
+
+var pricedModels = []types.FoundationModelSummary{
+	{ModelId: aws.String("anthropic.claude-3-haiku-20240307-v1:0"), ModelName: aws.String("Claude 3 Haiku")},
+	{ModelId: aws.String("anthropic.claude-v2:1"), ModelName: aws.String("Claude")},
+	{ModelId: aws.String("anthropic.claude-v2:1:200k"), ModelName: aws.String("Claude")},
+	{ModelId: aws.String("meta.llama3-1-8b-instruct-v1:0"), ModelName: aws.String("Llama 3.1 8B Instruct")},
+	{ModelId: aws.String("amazon.titan-image-generator-v1"), ModelName: aws.String("Titan Image Generator")},
+}
+
+func TestBuildPricing(t *testing.T) {
+	priceList, err := awsBrains.LoadPriceListExport(priceListExport)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []awsBrains.ModelPricing{
+		// batch and us-west-2 prices are ignored
+		{
+			ModelID:                   "anthropic.claude-3-haiku-20240307-v1:0",
+			ModelName:                 "Claude 3 Haiku",
+			InputCostPer1kTokens:      0.0008,
+			OutputCostPer1kTokens:     0.004,
+			CacheReadCostPer1kTokens:  0.00008,
+			CacheWriteCostPer1kTokens: 0.001,
+		},
+		// "Claude" must not pick up any "Claude 3 ..." prices
+		{ModelID: "anthropic.claude-v2:1", ModelName: "Claude", InputCostPer1kTokens: 0.008, OutputCostPer1kTokens: 0.024},
+		{ModelID: "anthropic.claude-v2:1:200k", ModelName: "Claude", InputCostPer1kTokens: 0.008, OutputCostPer1kTokens: 0.024},
+		// priced per million tokens
+		{ModelID: "meta.llama3-1-8b-instruct-v1:0", ModelName: "Llama 3.1 8B Instruct", InputCostPer1kTokens: 0.00022, OutputCostPer1kTokens: 0.00022},
+	}, awsBrains.BuildPricing(priceList, pricedModels, "us-east-1"))
+}
+
+func TestLoadPriceListExportBareList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"product":{"attributes":{"model":"Claude"}}}]`), 0o600))
+
+	priceList, err := awsBrains.LoadPriceListExport(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"product":{"attributes":{"model":"Claude"}}}`}, priceList)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`not json`), 0o600))
+	_, err = awsBrains.LoadPriceListExport(path)
+	assert.Error(t, err)
+}
+
+func TestRefreshPricingWritesCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := awsBrains.NewAWSConfig("us-east-1").(*awsBrains.AWSConfig)
+	inv := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(inv)
+	inv.On("ListFoundationModels", mock.Anything, mock.Anything).Return(&bedrock.ListFoundationModelsOutput{
+		ModelSummaries: pricedModels,
+	}, nil)
+
+	modelsPricing, err := cfg.RefreshPricing(context.Background(), priceListExport)
+	assert.NoError(t, err)
+	assert.Len(t, modelsPricing, 4)
+
+	path, err := awsBrains.PricingCachePath()
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var cache awsBrains.PricingCache
+	assert.NoError(t, json.Unmarshal(data, &cache))
+	assert.Equal(t, "us-east-1", cache.Region)
+	assert.Equal(t, modelsPricing, cache.Models)
+
+	// new configs for the same region prefer the cache, cross-region profiles are priced like their model
+	usage := map[string]any{"prompt_tokens": 1000, "completion_tokens": 1000, "cache_read_tokens": 1000}
+	out := mockBrains.CaptureAllOutput(func() {
+		awsBrains.NewAWSConfig("us-east-1").PrintCost(usage, "us.anthropic.claude-3-haiku-20240307-v1:0")
+	})
+	assert.Contains(t, out, "$0.004880")
+
+	// other regions keep the embedded pricing
+	out = mockBrains.CaptureAllOutput(func() {
+		awsBrains.NewAWSConfig("eu-west-1").PrintCost(usage, "anthropic.claude-3-haiku-20240307-v1:0")
+	})
+	assert.NotContains(t, out, "$0.004880")
+}
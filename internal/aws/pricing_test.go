@@ -54,3 +54,11 @@ func TestPrintPricingDisplaysModel(t *testing.T) {
 	})
 	assert.Contains(t, out, modelID)
 }
+
+func TestHasPricing(t *testing.T) {
+	cfg := &aws.AWSConfig{}
+	cfg.SetPricing([]aws.ModelPricing{{ModelID: "anthropic.claude-3-haiku-20240307-v1:0"}})
+	assert.True(t, cfg.HasPricing("anthropic.claude-3-haiku-20240307-v1:0"))
+	assert.True(t, cfg.HasPricing("eu.anthropic.claude-3-haiku-20240307-v1:0"))
+	assert.False(t, cfg.HasPricing("anthropic.claude-3-haiku"))
+}
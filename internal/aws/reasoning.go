@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+)
+
+func isAnthropicModel(modelID string) bool { return strings.Contains(modelID, "anthropic.") }
+func isGPTOSSModel(modelID string) bool    { return strings.Contains(modelID, "openai.gpt-oss") }
+
+func thinkingBudget(budget int) int { return max(budget, minThinkingBudget) }
+
+// applyInvokeReasoning maps reasoning_budget onto the OpenAI shaped InvokeModel body used by Chat, only
+// gpt-oss accepts it, Claude thinking is configured through Converse
+func (a *AWSConfig) applyInvokeReasoning(modelID string, req *BedrockRequest) {
+	if a.reasoningBudget > 0 && isGPTOSSModel(modelID) {
+		req.ReasoningEffort = llm.ReasoningEffort(a.reasoningBudget)
+	}
+}
+
+// applyConverseTemperature sets the persona temperature, applied ahead of reasoning which may clear it
+func (a *AWSConfig) applyConverseTemperature(input *bedrockruntime.ConverseInput) {
+	if a.temperature == nil {
+		return
+	}
+	if input.InferenceConfig == nil {
+		input.InferenceConfig = &bedrockruntimeTypes.InferenceConfiguration{}
+	}
+	input.InferenceConfig.Temperature = aws.Float32(float32(*a.temperature))
+}
+
+// applyConverseReasoning maps reasoning_budget onto the model specific request fields of Converse
+func (a *AWSConfig) applyConverseReasoning(input *bedrockruntime.ConverseInput) {
+	if a.reasoningBudget <= 0 {
+		return
+	}
+	modelID := aws.ToString(input.ModelId)
+	switch {
+	case isAnthropicModel(modelID):
+		budget := thinkingBudget(a.reasoningBudget)
+		input.AdditionalModelRequestFields = document.NewLazyDocument(map[string]any{
+			"thinking": map[string]any{"type": "enabled", "budget_tokens": budget},
+		})
+		if input.InferenceConfig == nil {
+			input.InferenceConfig = &bedrockruntimeTypes.InferenceConfiguration{}
+		}
+		input.InferenceConfig.MaxTokens = aws.Int32(int32(budget + thinkingAnswerTokens))
+		// Claude only accepts the default temperature while thinking
+		input.InferenceConfig.Temperature = nil
+		// Claude rejects a forced tool choice while thinking, the text fallback covers a model that skips the tool
+		if input.ToolConfig != nil {
+			input.ToolConfig.ToolChoice = nil
+		}
+	case isGPTOSSModel(modelID):
+		input.AdditionalModelRequestFields = document.NewLazyDocument(map[string]any{
+			"reasoning_effort": llm.ReasoningEffort(a.reasoningBudget),
+		})
+	}
+}
+
+// reasoningFromConverse collects the reasoning blocks of a Converse message
+func reasoningFromConverse(message bedrockruntimeTypes.Message) []llm.ReasoningBlock {
+	var blocks []llm.ReasoningBlock
+	for _, block := range message.Content {
+		reasoning, ok := block.(*bedrockruntimeTypes.ContentBlockMemberReasoningContent)
+		if !ok {
+			continue
+		}
+		switch r := reasoning.Value.(type) {
+		case *bedrockruntimeTypes.ReasoningContentBlockMemberReasoningText:
+			blocks = append(blocks, llm.ReasoningBlock{
+				Text:      aws.ToString(r.Value.Text),
+				Signature: aws.ToString(r.Value.Signature),
+			})
+		case *bedrockruntimeTypes.ReasoningContentBlockMemberRedactedContent:
+			blocks = append(blocks, llm.ReasoningBlock{Redacted: r.Value})
+		}
+	}
+	return blocks
+}
+
+// converseReasoningBlocks sends reasoning back unchanged, Claude requires it ahead of tool use blocks
+func converseReasoningBlocks(blocks []llm.ReasoningBlock) []bedrockruntimeTypes.ContentBlock {
+	var content []bedrockruntimeTypes.ContentBlock
+	for _, block := range blocks {
+		if block.Redacted != nil {
+			content = append(content, &bedrockruntimeTypes.ContentBlockMemberReasoningContent{
+				Value: &bedrockruntimeTypes.ReasoningContentBlockMemberRedactedContent{Value: block.Redacted},
+			})
+			continue
+		}
+		text := &bedrockruntimeTypes.ReasoningTextBlock{Text: aws.String(block.Text)}
+		if block.Signature != "" {
+			text.Signature = aws.String(block.Signature)
+		}
+		content = append(content, &bedrockruntimeTypes.ContentBlockMemberReasoningContent{
+			Value: &bedrockruntimeTypes.ReasoningContentBlockMemberReasoningText{Value: *text},
+		})
+	}
+	return content
+}
+
+// estimateReasoningTokens approximates reasoning tokens (four characters per token) as Converse only
+// reports them as part of the output tokens
+func estimateReasoningTokens(reasoning string) int {
+	if reasoning == "" {
+		return 0
+	}
+	return len(reasoning)/4 + 1
+}
+
+// withReasoningTokens fills in an estimate when the response did not report its reasoning tokens
+func withReasoningTokens(usage map[string]any, reasoning string) map[string]any {
+	if reasoning == "" || llm.ReasoningTokens(usage) > 0 {
+		return usage
+	}
+	if usage == nil {
+		usage = map[string]any{}
+	}
+	usage["reasoning_tokens"] = estimateReasoningTokens(reasoning)
+	return usage
+}
+
+func (a *AWSConfig) logReasoning(modelID, reasoning string) {
+	if a.logger != nil && reasoning != "" {
+		a.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventReasoning, Model: modelID, Response: reasoning})
+	}
+}
@@ -0,0 +1,189 @@
+package aws_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	awsBrains "github.com/madhuravius/brains/internal/aws"
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+func additionalFields(in *bedrockruntime.ConverseInput) string {
+	if in.AdditionalModelRequestFields == nil {
+		return ""
+	}
+	data, _ := in.AdditionalModelRequestFields.MarshalSmithyDocument()
+	return string(data)
+}
+
+func TestChatWithToolsReasoning(t *testing.T) {
+	logger := &mockBrains.TestLogger{}
+	cfg := &awsBrains.AWSConfig{}
+	cfg.SetLogger(logger)
+	cfg.SetReasoningBudget(2048)
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		if !strings.Contains(additionalFields(in), `"budget_tokens":2048`) {
+			return false
+		}
+		if in.InferenceConfig == nil || aws.ToInt32(in.InferenceConfig.MaxTokens) != 2048+4096 {
+			return false
+		}
+		// earlier thinking is sent back ahead of the tool use it led to
+		reasoning, ok := in.Messages[1].Content[0].(*bedrockruntimeTypes.ContentBlockMemberReasoningContent)
+		if !ok {
+			return false
+		}
+		text, ok := reasoning.Value.(*bedrockruntimeTypes.ReasoningContentBlockMemberReasoningText)
+		return ok && aws.ToString(text.Value.Signature) == "sig_1"
+	})).Return(&bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Role: "assistant",
+			Content: []bedrockruntimeTypes.ContentBlock{
+				&bedrockruntimeTypes.ContentBlockMemberReasoningContent{
+					Value: &bedrockruntimeTypes.ReasoningContentBlockMemberReasoningText{Value: bedrockruntimeTypes.ReasoningTextBlock{
+						Text:      aws.String("go.mod names the module"),
+						Signature: aws.String("sig_2"),
+					}},
+				},
+				&bedrockruntimeTypes.ContentBlockMemberText{Value: "the module is brains"},
+			},
+		}},
+		StopReason: bedrockruntimeTypes.StopReasonEndTurn,
+		Usage:      &bedrockruntimeTypes.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(20)},
+	}, nil)
+
+	resp, err := cfg.ChatWithTools(context.Background(), "us.anthropic.claude-sonnet-4-20250514-v1:0", llm.ToolRequest{
+		Turns: []llm.Turn{
+			{Role: "user", Text: "what module is this?"},
+			{
+				Role:      "assistant",
+				Reasoning: []llm.ReasoningBlock{{Text: "I should read go.mod", Signature: "sig_1"}},
+				ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "read_file", Input: map[string]any{"path": "go.mod"}}},
+			},
+			{Role: "user", ToolResults: []llm.ToolResult{{ToolCallID: "call_1", Content: "module brains"}}},
+		},
+		Tools: []llm.ToolSpec{{Name: "read_file", InputSchema: map[string]any{"type": "object"}}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.Done)
+	assert.Equal(t, "the module is brains", resp.Turn.Text)
+	assert.Equal(t, []llm.ReasoningBlock{{Text: "go.mod names the module", Signature: "sig_2"}}, resp.Turn.Reasoning)
+	assert.Positive(t, llm.ReasoningTokens(resp.Usage))
+	assert.Equal(t, brainsConfig.EventReasoning, logger.Last().Type)
+	assert.Equal(t, "go.mod names the module", logger.Last().Response)
+	invokerMock.AssertExpectations(t)
+}
+
+func TestStructuredOutputThinkingLeavesToolChoice(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	cfg.SetReasoningBudget(100)
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		// Claude rejects a forced tool while thinking and budgets are raised to its minimum
+		return in.ToolConfig.ToolChoice == nil && strings.Contains(additionalFields(in), `"budget_tokens":1024`)
+	})).Return(&bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: `{"ok":true}`}},
+		}},
+	}, nil)
+
+	out, err := cfg.StructuredOutput(context.Background(), "anthropic.claude-sonnet-4-20250514-v1:0", llm.NewUserRequest("hello"), llm.ToolSpec{
+		Name:        "coder",
+		InputSchema: map[string]any{"type": "object"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(out))
+	invokerMock.AssertExpectations(t)
+}
+
+func TestConverseReasoningEffort(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	cfg.SetReasoningBudget(20000)
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		return additionalFields(in) == `{"reasoning_effort":"high"}` && in.InferenceConfig == nil
+	})).Return(&bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: "done"}},
+		}},
+	}, nil)
+
+	_, err := cfg.ChatWithTools(context.Background(), "openai.gpt-oss-120b-1:0", llm.ToolRequest{
+		Turns: []llm.Turn{{Role: "user", Text: "hello"}},
+	})
+	assert.NoError(t, err)
+	invokerMock.AssertExpectations(t)
+}
+
+func TestConverseTemperature(t *testing.T) {
+	temperature := 0.2
+	cfg := &awsBrains.AWSConfig{}
+	cfg.SetTemperature(&temperature)
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+	output := &bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{Value: bedrockruntimeTypes.Message{
+			Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: "done"}},
+		}},
+	}
+
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		return in.InferenceConfig != nil && aws.ToFloat32(in.InferenceConfig.Temperature) == float32(0.2)
+	})).Return(output, nil).Once()
+	_, err := cfg.ChatWithTools(context.Background(), "openai.gpt-oss-120b-1:0", llm.ToolRequest{
+		Turns: []llm.Turn{{Role: "user", Text: "hello"}},
+	})
+	assert.NoError(t, err)
+
+	// Claude only accepts the default temperature while thinking
+	cfg.SetReasoningBudget(2000)
+	invokerMock.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		return in.InferenceConfig != nil && in.InferenceConfig.Temperature == nil
+	})).Return(output, nil).Once()
+	_, err = cfg.ChatWithTools(context.Background(), "anthropic.claude-sonnet-4-20250514-v1:0", llm.ToolRequest{
+		Turns: []llm.Turn{{Role: "user", Text: "hello"}},
+	})
+	assert.NoError(t, err)
+	invokerMock.AssertExpectations(t)
+}
+
+func TestChatSplitsInlineReasoning(t *testing.T) {
+	cfg := &awsBrains.AWSConfig{}
+	cfg.SetReasoningBudget(1000)
+	invokerMock := &mockBrains.MockInvoker{}
+	cfg.SetInvoker(invokerMock)
+
+	invokerMock.On("InvokeModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.InvokeModelInput) bool {
+		return strings.Contains(string(in.Body), `"reasoning_effort":"low"`)
+	})).Return(&bedrockruntime.InvokeModelOutput{
+		Body: []byte(`{
+			"choices":[{"message":{"role":"assistant","content":"<reasoning>the user greets me</reasoning>hi"}}],
+			"usage":{"prompt_tokens":1,"completion_tokens":9}
+		}`),
+	}, nil)
+
+	resp, err := cfg.Chat(context.Background(), "openai.gpt-oss-120b-1:0", llm.NewUserRequest("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", resp.Choices[0].Message.Content)
+	assert.Equal(t, "the user greets me", resp.Choices[0].Message.Reasoning)
+	_, completionTokens := llm.UsageTokens(resp.Usage)
+	assert.Equal(t, 9, completionTokens)
+	assert.Positive(t, llm.ReasoningTokens(resp.Usage))
+	invokerMock.AssertExpectations(t)
+}
@@ -1,52 +1,156 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/pterm/pterm"
 	"gopkg.in/yaml.v3"
 )
 
+// LoadConfig deep-merges the config layers, from lowest to highest precedence: defaults, "~/.brains.yml",
+// the repo's ".brains.yml", ".brains.local.yml" and BRAINS_* env vars. Flags are applied later with SetFlag.
+// Repo files are read from the repository root. Nothing is written, see CreateDefaultConfig.
 func LoadConfig() (BrainsConfigImpl, error) {
-	paths := []string{}
-	if cwd, err := os.Getwd(); err == nil {
-		paths = append(paths, filepath.Join(cwd, ".brains.yml"))
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
 	}
-	if home, err := os.UserHomeDir(); err == nil {
-		paths = append(paths, filepath.Join(home, ".brains.yml"))
+	repoRoot, inRepo := FindRepoRoot(cwd)
+	if !inRepo {
+		repoRoot = cwd
 	}
-	var cfgPath string
-	for _, p := range paths {
-		if _, err := os.Stat(p); err == nil {
-			cfgPath = p
-			break
-		}
+	repoPath := filepath.Join(repoRoot, ConfigFile)
+	paths := []string{}
+	if home, err := os.UserHomeDir(); err == nil && filepath.Join(home, ConfigFile) != repoPath {
+		paths = append(paths, filepath.Join(home, ConfigFile))
 	}
-	if cfgPath == "" {
-		target := paths[0]
-		data, _ := yaml.Marshal(&DefaultConfig)
-		if err := os.WriteFile(target, data, 0o600); err != nil {
+	paths = append(paths, repoPath, filepath.Join(repoRoot, LocalConfigFile))
+
+	cfg, err := newDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths[:len(paths)-1] {
+		found, err := cfg.applyFile(path)
+		if err != nil {
 			return nil, err
 		}
-		return &DefaultConfig, nil
+		if found {
+			cfg.path = path
+		}
 	}
-	b, err := os.ReadFile(cfgPath)
-	if err != nil {
+	if inRepo {
+		cfg.repoRoot = repoRoot
+	}
+	if _, err := cfg.applyFile(paths[len(paths)-1]); err != nil {
 		return nil, err
 	}
-	var cfg BrainsConfig
-	if err := yaml.Unmarshal(b, &cfg); err != nil {
+	if err := cfg.applyEnv(); err != nil {
 		return nil, err
 	}
+
 	if cfg.AWSRegion == "" {
 		cfg.AWSRegion = DefaultConfig.AWSRegion
 	}
 	if cfg.Model == "" {
 		cfg.Model = DefaultConfig.Model
 	}
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderBedrock
+	}
+	if cfg.ReasoningDisplay == "" {
+		cfg.ReasoningDisplay = DefaultConfig.ReasoningDisplay
+	}
+	if cfg.Research.MaxSteps == 0 {
+		cfg.Research.MaxSteps = DefaultConfig.Research.MaxSteps
+	}
+	if cfg.Research.MaxTokens == 0 {
+		cfg.Research.MaxTokens = DefaultConfig.Research.MaxTokens
+	}
+	if cfg.ContextConfig.SummaryChunkTokens == 0 {
+		cfg.ContextConfig.SummaryChunkTokens = DefaultConfig.ContextConfig.SummaryChunkTokens
+	}
+	if cfg.Git.DirtyFiles == "" {
+		cfg.Git.DirtyFiles = DefaultConfig.Git.DirtyFiles
+	}
+	if cfg.Git.Branch == "" {
+		cfg.Git.Branch = DefaultConfig.Git.Branch
+	}
 
+	if cfg.path == "" {
+		// the log directory is created with the config file
+		return cfg, nil
+	}
 	if err := cfg.InitLogger(cfg.LoggingEnabled); err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	return cfg, nil
+}
+
+// CreateDefaultConfig writes the default config at the repository root when no config file was loaded. Outside
+// of a repository it refuses, "brains init" asks where to write one.
+func (b *BrainsConfig) CreateDefaultConfig() error {
+	if b.path != "" {
+		return nil
+	}
+	if b.repoRoot == "" {
+		return fmt.Errorf("no %s found and this directory is not in a git repository, run \"brains init\" to create one", ConfigFile)
+	}
+	path := filepath.Join(b.repoRoot, ConfigFile)
+	if err := WriteConfigFile(path, &DefaultConfig); err != nil {
+		return err
+	}
+	pterm.Info.Printfln("created a default config at %s, run \"brains init\" to pick a model and personas", path)
+	b.path = path
+	return b.InitLogger(b.LoggingEnabled)
+}
+
+// setConfigFileValue rewrites a single top level key in the config file at path, keeping the
+// remaining keys and comments intact
+func setConfigFileValue(path, key, value string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a mapping", path)
+	}
+
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1].SetString(value)
+			found = true
+			break
+		}
+	}
+	if !found {
+		keyNode := &yaml.Node{}
+		keyNode.SetString(key)
+		valueNode := &yaml.Node{}
+		valueNode.SetString(value)
+		root.Content = append(root.Content, keyNode, valueNode)
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0o600)
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -15,33 +16,180 @@ func TestLoadConfigWhenNoFileExists(t *testing.T) {
 	origWD, _ := os.Getwd()
 	defer func() { _ = os.Chdir(origWD) }()
 	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
 
-	homeDir := t.TempDir()
-	_ = os.Setenv("HOME", homeDir)
-
+	// outside of a repository nothing is written until "brains init"
 	cfg, err := config.LoadConfig()
 	assert.NoError(t, err)
-	assert.NotNil(t, cfg)
+	assert.Empty(t, cfg.GetConfig().Path())
+	assert.ErrorContains(t, cfg.GetConfig().CreateDefaultConfig(), "brains init")
+	assert.Equal(t, config.DefaultConfig.Model, cfg.GetConfig().Model)
+	_, statErr := os.Stat(filepath.Join(tmpDir, ".brains.yml"))
+	assert.True(t, os.IsNotExist(statErr))
+	_, statErr = os.Stat(filepath.Join(tmpDir, ".brains"))
+	assert.True(t, os.IsNotExist(statErr))
+
+	// inside of one the default config goes to the repository root
+	assert.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".git"), 0o750))
+	subDir := filepath.Join(tmpDir, "internal", "pkg")
+	assert.NoError(t, os.MkdirAll(subDir, 0o750))
+	_ = os.Chdir(subDir)
+
+	cfg, err = config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.GetConfig().Path())
+	assert.NoError(t, cfg.GetConfig().CreateDefaultConfig())
 	assert.Equal(t, config.DefaultConfig.AWSRegion, cfg.GetConfig().AWSRegion)
 	assert.Equal(t, config.DefaultConfig.Model, cfg.GetConfig().Model)
 	assert.Empty(t, cfg.GetConfig().Personas)
 
 	expectedPath := filepath.Join(tmpDir, ".brains.yml")
-	_, statErr := os.Stat(expectedPath)
+	assert.Equal(t, expectedPath, cfg.GetConfig().Path())
+	data, statErr := os.ReadFile(expectedPath)
 	assert.NoError(t, statErr)
+	assert.Contains(t, string(data), "# model - model ID")
+	assert.Empty(t, cfg.GetConfig().Validate(nil))
 }
 
-func TestGetPersonaInstructions(t *testing.T) {
+func TestResolvePersonaFromConfig(t *testing.T) {
 	b := &config.BrainsConfig{
 		Personas: map[string]string{
 			"dev": "You are a helpful developer.",
 		},
 	}
 
-	instr := b.GetPersonaInstructions("dev")
-	expected := "Human: You are a helpful developer.\n\n"
-	assert.Equal(t, expected, instr)
+	persona, err := b.ResolvePersona("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "Human: You are a helpful developer.\n\n", persona.PromptInstructions())
+
+	persona, err = b.ResolvePersona("")
+	assert.NoError(t, err)
+	assert.Empty(t, persona.PromptInstructions())
+
+	_, err = b.ResolvePersona("nonexistent")
+	assert.EqualError(t, err, `persona "nonexistent" is not defined, expected one of dev`)
+}
+
+func TestLoadConfigEndpointURLFromEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".brains.yml"), []byte("endpoint_url: http://from-file:4566\n"), 0o600))
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://from-file:4566", cfg.GetConfig().EndpointURL)
+
+	t.Setenv(config.EndpointURLEnv, "http://127.0.0.1:9999")
+	cfg, err = config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:9999", cfg.GetConfig().EndpointURL)
+}
+
+func TestLoadConfigMaxRepairIterations(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, "model: m\n")
+	assert.Equal(t, config.DefaultConfig.MaxRepairIterations, cfg.MaxRepairIterations)
+
+	// 0 runs post_edit_commands without repairing, it is not replaced with the default
+	cfg, _ = loadConfigFrom(t, "max_repair_iterations: 0\n")
+	assert.Equal(t, 0, cfg.MaxRepairIterations)
+}
+
+func TestLoadConfigAssumeRole(t *testing.T) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+
+	contents := `aws_profile: dev
+assume_role:
+  role_arn: arn:aws:iam::123456789012:role/brains
+  external_id: ext-123
+  duration: 1h
+  mfa_serial: arn:aws:iam::123456789012:mfa/me
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".brains.yml"), []byte(contents), 0o600))
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", cfg.GetConfig().AWSProfile)
+	assert.Equal(t, config.AssumeRoleConfig{
+		RoleARN:    "arn:aws:iam::123456789012:role/brains",
+		ExternalID: "ext-123",
+		Duration:   time.Hour,
+		MFASerial:  "arn:aws:iam::123456789012:mfa/me",
+	}, cfg.GetConfig().AssumeRole)
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	userPath := filepath.Join(homeDir, ".brains.yml")
+	repoPath := filepath.Join(tmpDir, ".brains.yml")
+	localPath := filepath.Join(tmpDir, ".brains.local.yml")
+	assert.NoError(t, os.WriteFile(userPath, []byte(`aws_region: eu-west-1
+personas:
+  dev: You are a helpful developer.
+research:
+  max_steps: 3
+`), 0o600))
+	assert.NoError(t, os.WriteFile(repoPath, []byte(`model: team-model
+personas:
+  reviewer: You review code.
+research:
+  max_tokens: 5000
+`), 0o600))
+	assert.NoError(t, os.WriteFile(localPath, []byte("model: my-model\n"), 0o600))
+	t.Setenv("BRAINS_RESEARCH_MAX_STEPS", "6")
+	t.Setenv("BRAINS_PRE_COMMANDS", "[make lint, make test]")
+	t.Setenv("BRAINS_ASSUME_ROLE_DURATION", "30m")
+
+	brainsConfig, err := config.LoadConfig()
+	assert.NoError(t, err)
+	cfg := brainsConfig.GetConfig()
+	assert.NoError(t, cfg.SetFlag("aws-profile", "aws_profile", "dev"))
+	assert.Error(t, cfg.SetFlag("nope", "nope", "x"))
+
+	assert.Equal(t, "eu-west-1", cfg.AWSRegion)
+	assert.Equal(t, "my-model", cfg.Model)
+	assert.Equal(t, "dev", cfg.AWSProfile)
+	assert.Equal(t, map[string]string{"dev": "You are a helpful developer.", "reviewer": "You review code."}, cfg.Personas)
+	assert.Equal(t, config.ResearchConfig{MaxSteps: 6, MaxTokens: 5000}, cfg.Research)
+	assert.Equal(t, []string{"make lint", "make test"}, cfg.PreCommands)
+	assert.Equal(t, 30*time.Minute, cfg.AssumeRole.Duration)
+	assert.Empty(t, config.DefaultConfig.Personas)
+
+	origins := map[string]string{}
+	values := map[string]string{}
+	for _, setting := range cfg.Settings() {
+		origins[setting.Key] = setting.Origin
+		values[setting.Key] = setting.Value
+	}
+	assert.Equal(t, userPath, origins["aws_region"])
+	assert.Equal(t, localPath, origins["model"])
+	assert.Equal(t, userPath, origins["personas.dev"])
+	assert.Equal(t, repoPath, origins["personas.reviewer"])
+	assert.Equal(t, "env BRAINS_RESEARCH_MAX_STEPS", origins["research.max_steps"])
+	assert.Equal(t, repoPath, origins["research.max_tokens"])
+	assert.Equal(t, "env BRAINS_PRE_COMMANDS", origins["pre_commands"])
+	assert.Equal(t, "flag --aws-profile", origins["aws_profile"])
+	assert.Equal(t, config.OriginDefault, origins["default_context"])
+	assert.Equal(t, "[make lint, make test]", values["pre_commands"])
+	assert.Equal(t, "30m0s", values["assume_role.duration"])
 
-	empty := b.GetPersonaInstructions("nonexistent")
-	assert.Empty(t, empty)
+	// the model is written back to the layer that set it
+	assert.NoError(t, cfg.SetModel("other-model"))
+	local, _ := os.ReadFile(localPath)
+	assert.Contains(t, string(local), "other-model")
+	repo, _ := os.ReadFile(repoPath)
+	assert.Contains(t, string(repo), "team-model")
 }
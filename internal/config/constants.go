@@ -1,12 +1,221 @@
 package config
 
-const LogPath = "./.brains/.brains.log"
+import "time"
+
+// LogPath is the event log, one JSON Event per line
+const LogPath = "./.brains/events.jsonl"
+
+// DefaultSession is the session events are recorded in until another one is switched to
+const DefaultSession = "default"
+
+// SessionFile holds the name of the active session, it is kept between runs
+const SessionFile = "./.brains/session"
+
+// maxEventSize bounds a line of the event log, code responses hold whole files
+const maxEventSize = 16 * 1024 * 1024
+
+// LastRun selects the latest run in a LogFilter
+const LastRun = "last"
+
+// event types of the event log
+const (
+	EventRequest      EventType = "request"
+	EventResponse     EventType = "response"
+	EventCodeResponse EventType = "code_response"
+	EventValidation   EventType = "validation"
+	EventResearch     EventType = "research"
+	EventTool         EventType = "tool"
+	EventReasoning    EventType = "reasoning"
+	EventGuardrail    EventType = "guardrail"
+	EventPostEdit     EventType = "post_edit"
+	EventLogSummary   EventType = "log_summary"
+	EventHealthCheck  EventType = "health_check"
+)
+
+// EventTypes are every event type with the emoji shown by brains log
+var EventTypes = map[EventType]string{
+	EventRequest:      "❓",
+	EventResponse:     "⚡",
+	EventCodeResponse: "🧠",
+	EventValidation:   "⚠️",
+	EventResearch:     "🔍",
+	EventTool:         "🔧",
+	EventReasoning:    "💭",
+	EventGuardrail:    "🛡️",
+	EventPostEdit:     "🛠️",
+	EventLogSummary:   "📝",
+	EventHealthCheck:  "🩺",
+}
+
+// contextExcludedEvents are left out of the log sent as context, they only repeat what other events hold
+var contextExcludedEvents = []EventType{EventHealthCheck, EventLogSummary, EventReasoning}
+
+// config files, the local file is meant for untracked personal overrides of the repo's file
+const (
+	ConfigFile      = ".brains.yml"
+	LocalConfigFile = ".brains.local.yml"
+)
+
+// PersonasDir holds persona files, in the repository and in the home directory
+const PersonasDir = ".brains/personas"
+
+// TrustFile records the approved project hook commands of every repository, in the home directory
+const TrustFile = ".brains/trusted.json"
+
+// RedactionKeyFile holds the key redaction placeholders are derived from, in the home directory
+const RedactionKeyFile = ".brains/redaction.key"
+
+// hookEnv are the environment variables kept for hook commands when hooks.minimal_env is set
+var hookEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "TERM", "TMPDIR"}
+
+// hookKillDelay is how long a timed out hook command gets to exit once killed
+const hookKillDelay = 5 * time.Second
+
+// PromptsDir holds the prompt templates overriding the embedded ones, in the repository
+const PromptsDir = ".brains/prompts"
+
+const (
+	personaExt           = ".md"
+	frontmatterDelimiter = "---"
+)
+
+// EnvPrefix prefixes env vars overriding a config key, ex: BRAINS_AWS_REGION for aws_region
+const EnvPrefix = "BRAINS_"
+
+// OriginDefault is the origin of values that no layer sets
+const OriginDefault = "default"
+
+// EndpointURLEnv overrides endpoint_url, used to point brains at a fake or proxied Bedrock
+const EndpointURLEnv = "BRAINS_BEDROCK_ENDPOINT"
+
+const (
+	ProviderBedrock = "bedrock"
+	ProviderOpenAI  = "openai"
+)
+
+// reasoning_display values, reasoning is always written to the log and hidden only skips the terminal
+const (
+	ReasoningDisplayHidden    = "hidden"
+	ReasoningDisplayCollapsed = "collapsed"
+	ReasoningDisplayDimmed    = "dimmed"
+)
+
+// git.dirty_files values
+const (
+	GitDirtyWarn   = "warn"
+	GitDirtyRefuse = "refuse"
+	GitDirtyIgnore = "ignore"
+)
+
+// git.branch values
+const (
+	GitBranchCurrent = "current"
+	GitBranchNew     = "new"
+)
+
+// GitignoreEntries keep logs and personal overrides out of the repository while personas and prompts can be committed
+var GitignoreEntries = []string{".brains/*", "!" + PersonasDir + "/", "!" + PromptsDir + "/", LocalConfigFile}
+
+const configFileHeader = `brains configuration, "brains init" walks through the main options
+settings are merged from ~/.brains.yml, this file, .brains.local.yml, BRAINS_* env vars and flags
+run "brains config validate" after editing and see .brains.example.yml for every option`
+
+// configComments are written above the top level keys of config files created by brains
+var configComments = map[string]string{
+	"logging_enabled":       "logging_enabled - append the events of every session to .brains/events.jsonl, see brains log",
+	"aws_region":            "aws_region - region Bedrock is called in",
+	"aws_profile":           "aws_profile - shared config profile, the default credential chain is used when unset",
+	"assume_role":           "assume_role - role assumed on top of the base credentials",
+	"endpoint_url":          "endpoint_url - override for the Bedrock and STS endpoints",
+	"model":                 "model - model ID, see \"brains models\" for the models available in aws_region",
+	"provider":              "provider - bedrock or openai for an OpenAI-compatible server",
+	"openai":                "openai - base_url and api_key_env of the OpenAI-compatible server",
+	"personas":              "personas - instructions prepended to prompts, pick one with --persona",
+	"default_context":       "default_context - glob of the files sent as context when --add is not set",
+	"default_persona":       "default_persona - persona used when --persona is not set",
+	"pre_commands":          "pre_commands - commands run with \"bash -c\" before every command (ex: aws sso login)",
+	"post_edit_commands":    "post_edit_commands - commands run after code edits (ex: go build ./...), failures are sent back to the model",
+	"max_repair_iterations": "max_repair_iterations - rounds of edits made to get post_edit_commands to pass",
+	"hooks":                 "hooks - timeout and minimal_env of pre_commands and post_edit_commands, disabled skips them (--no-hooks)",
+	"context_config":        "context_config - extra context sent with each request: logs, their summary, repo map tags and file list",
+	"research":              "research - bounds the tool loop run before ask and code",
+	"reasoning_budget":      "reasoning_budget - tokens models may spend reasoning",
+	"reasoning_display":     "reasoning_display - hidden, collapsed or dimmed",
+	"guardrail":             "guardrail - Bedrock Guardrail applied to every model call",
+	"redaction":             "redaction - extra patterns and deny_files scrubbed from prompts and logs, see brains context --show-redactions",
+	"git":                   "git - dirty_files (warn, refuse or ignore) and auto_commit of code edits on the current or a new branch",
+}
+
+// PersonaPresets are offered by "brains init", the same personas as in .brains.example.yml
+var PersonaPresets = map[string]string{
+	"arch": `ROLE: Software Architect & Senior Engineer
+OBJECTIVE: Define clear, scalable, and maintainable designs that guide developers toward idiomatic solutions.
+BEHAVIOR:
+  - Think at system level; focus on components, interactions, and failure boundaries.
+  - Avoid code unless pseudocode clarifies an idea.
+  - Prioritize simplicity, reliability, and cost-effectiveness.
+  - Communicate in short, structured statements—no filler text.
+OUTPUT: Concise architecture notes, diagrams (in text if needed), or pseudocode only.
+`,
+	"dev": `ROLE: Expert Software Developer
+OBJECTIVE: Write idiomatic, maintainable, and easy-to-read code.
+BEHAVIOR:
+  - Produce minimal, functional, and self-explanatory code.
+  - Avoid unnecessary abstractions and excessive comments.
+  - Prefer clarity over cleverness; optimize for readability.
+  - Keep responses code-first and under two brief sentences of explanation.
+OUTPUT: Complete, ready-to-run code blocks with no inline commentary.
+`,
+	"review": `ROLE: Code Reviewer
+OBJECTIVE: Improve correctness, clarity, and maintainability through concise feedback.
+BEHAVIOR:
+  - Identify only actionable issues or clear improvements.
+  - Explain rationale briefly (one line per point).
+  - Do not include inline comments in code; list them before or after.
+  - When suggesting edits, output as a valid diff or patch.
+  - Maintain a neutral, factual tone—no fluff.
+OUTPUT: Minimal diff or patch plus a short bullet list of review notes (if needed).
+`,
+	"plan": `ROLE: Technical Planner
+OBJECTIVE: Break down a problem into minimal, independent, testable work units that support development.
+BEHAVIOR:
+  - For each unit, define: purpose, inputs, outputs, and success criteria.
+  - Map dependencies and highlight the shortest critical path.
+  - Suggest isolation techniques (e.g., feature flags, services, containers).
+  - Avoid long prose; use structured lists or tables where possible.
+  - Optimize for incremental delivery and developer clarity.
+OUTPUT: Concise structured plan with work units, dependencies, and clear success metrics.
+`,
+}
+
+// guardrailTraces are the trace values accepted by Bedrock Guardrails
+var guardrailTraces = []string{"enabled", "disabled", "enabled_full"}
+
+// maxSuggestionDistance bounds the edits between an unknown key and the key suggested for it
+const maxSuggestionDistance = 3
 
 var DefaultConfig = BrainsConfig{
-	LoggingEnabled: true,
-	AWSRegion:      "us-east-1",
-	Model:          "openai.gpt-oss-120b-1:0",
-	Personas:       map[string]string{},
-	DefaultContext: "**/*",
-	DefaultPersona: "",
+	LoggingEnabled:      true,
+	AWSRegion:           "us-east-1",
+	Model:               "openai.gpt-oss-120b-1:0",
+	Provider:            ProviderBedrock,
+	Personas:            map[string]string{},
+	DefaultContext:      "**/*",
+	DefaultPersona:      "",
+	ReasoningDisplay:    ReasoningDisplayHidden,
+	MaxRepairIterations: 3,
+	Hooks: HooksConfig{
+		Timeout: 10 * time.Minute,
+	},
+	Research: ResearchConfig{
+		MaxSteps:  8,
+		MaxTokens: 100000,
+	},
+	ContextConfig: ContextConfig{
+		SummaryChunkTokens: 8000,
+	},
+	Git: GitConfig{
+		DirtyFiles: GitDirtyWarn,
+		Branch:     GitBranchCurrent,
+	},
 }
@@ -2,27 +2,75 @@ package config
 
 import (
 	"fmt"
-	"os/exec"
+	"path/filepath"
 
 	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
 )
 
-func (b *BrainsConfig) GetPersonaInstructions(persona string) string {
-	personaText, found := b.Personas[persona]
-	if !found {
-		return ""
+func (b *BrainsConfig) GetConfig() *BrainsConfig { return b }
+
+// SetModel switches the active model and persists the choice to the config file that set it, or the repo's
+// config file when model comes from another layer
+func (b *BrainsConfig) SetModel(modelID string) error {
+	path := b.path
+	if origin := b.Origin("model"); filepath.IsAbs(origin) {
+		// files are the only layers recorded by path
+		path = origin
+	}
+	if path == "" {
+		path = ConfigFile
 	}
-	pterm.Debug.Printfln("user electing to leverage persona (%s) with text: %s", persona, personaText)
-	return fmt.Sprintf("Human: %s\n\n", personaText)
+	if err := setConfigFileValue(path, "model", modelID); err != nil {
+		return err
+	}
+	b.Model = modelID
+	return nil
 }
 
-func (b *BrainsConfig) GetConfig() *BrainsConfig { return b }
+// PrintConfig prints the effective configuration as YAML, or with withOrigin a table of every key and the layer that set it
+func (b *BrainsConfig) PrintConfig(withOrigin bool) error {
+	if !withOrigin {
+		data, err := yaml.Marshal(b)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+	tableData := pterm.TableData{{"Key", "Value", "Origin"}}
+	for _, setting := range b.Settings() {
+		tableData = append(tableData, []string{setting.Key, setting.Value, setting.Origin})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+}
+
+// PostEditCommandsHook runs post_edit_commands in order and stops at the first one failing, the results of the
+// commands that ran are returned with their combined output
+func (b *BrainsConfig) PostEditCommandsHook() []CommandResult {
+	if b.skipHooks("post_edit_commands", b.PostEditCommands) {
+		return nil
+	}
+	var results []CommandResult
+	for _, postEditCommand := range b.PostEditCommands {
+		pterm.Info.Printfln("running command as part of post_edit_commands sequence %s", postEditCommand)
+		out, err := b.runHook(postEditCommand)
+		results = append(results, CommandResult{Command: postEditCommand, Output: string(out), Err: err})
+		if err != nil {
+			pterm.Warning.Printfln("post edit command (%s) failed: %v", postEditCommand, err)
+			return results
+		}
+	}
+	return results
+}
 
 func (b *BrainsConfig) PreCommandsHook() error {
+	if b.skipHooks("pre_commands", b.PreCommands) {
+		return nil
+	}
 	for _, preCommand := range b.PreCommands {
 		pterm.Info.Printfln("running command as part of pre_commands sequence %s", preCommand)
-		cmd := exec.Command("bash", "-c", preCommand) // #nosec G204 -- preCommand is controlled intentionally and is variadic by design
-		out, err := cmd.CombinedOutput()
+		out, err := b.runHook(preCommand)
 		if err != nil {
 			pterm.Error.Printf("error executing precommand (%s) in: %v\noutput: %s\n", preCommand, err, out)
 			return err
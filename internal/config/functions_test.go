@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,16 +9,6 @@ import (
 	"github.com/madhuravius/brains/internal/config"
 )
 
-func TestGetPersonaInstructionsFormatting(t *testing.T) {
-	b := &config.BrainsConfig{
-		Personas: map[string]string{
-			"tester": "Test persona text.",
-		},
-	}
-	result := b.GetPersonaInstructions("tester")
-	assert.Equal(t, "Human: Test persona text.\n\n", result)
-}
-
 func TestPreCommandsSuccess(t *testing.T) {
 	b := &config.BrainsConfig{
 		PreCommands: []string{"exit 0"},
@@ -33,3 +24,59 @@ func TestPreCommandsFailure(t *testing.T) {
 	err := b.PreCommandsHook()
 	assert.NotNil(t, err)
 }
+
+func TestPostEditCommandsStopAtFirstFailure(t *testing.T) {
+	b := &config.BrainsConfig{
+		PostEditCommands: []string{"echo built", "echo failed tests; exit 2", "echo linted"},
+	}
+	results := b.PostEditCommandsHook()
+	assert.Len(t, results, 2)
+	assert.Equal(t, config.CommandResult{Command: "echo built", Output: "built\n"}, results[0])
+	assert.Equal(t, "failed tests\n", results[1].Output)
+	assert.EqualError(t, results[1].Err, "exit status 2")
+}
+
+func TestSetModelPersistsToConfigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+
+	raw := "# team settings\naws_region: us-west-2\nmodel: openai.gpt-oss-20b-1:0 # small model\nlogging_enabled: false\n"
+	assert.NoError(t, os.WriteFile(".brains.yml", []byte(raw), 0o600))
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+
+	assert.NoError(t, cfg.GetConfig().SetModel("us.anthropic.claude-3-haiku-20240307-v1:0"))
+	assert.Equal(t, "us.anthropic.claude-3-haiku-20240307-v1:0", cfg.GetConfig().Model)
+
+	data, err := os.ReadFile(".brains.yml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# team settings")
+	assert.Contains(t, string(data), "model: us.anthropic.claude-3-haiku-20240307-v1:0")
+	assert.Contains(t, string(data), "aws_region: us-west-2")
+
+	reloaded, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "us.anthropic.claude-3-haiku-20240307-v1:0", reloaded.GetConfig().Model)
+}
+
+func TestSetModelAddsMissingKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+
+	assert.NoError(t, os.WriteFile(".brains.yml", []byte("aws_region: us-east-1\nlogging_enabled: false\n"), 0o600))
+
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.GetConfig().SetModel("meta.llama3-8b-instruct-v1:0"))
+
+	data, err := os.ReadFile(".brains.yml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "model: meta.llama3-8b-instruct-v1:0")
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FindRepoRoot walks up from dir to the directory holding .git, ok is false outside of a repository
+func FindRepoRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Path is the config file that was loaded or created, it is empty when brains runs on defaults only
+func (b *BrainsConfig) Path() string { return b.path }
+
+// PromptsPath is the directory of prompt template overrides at the repository root
+func (b *BrainsConfig) PromptsPath() string { return filepath.Join(b.baseDir(), PromptsDir) }
+
+// WriteConfigFile writes cfg to path as YAML with a comment above each top level key explaining it
+func WriteConfigFile(path string, cfg *BrainsConfig) error {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if comment, ok := configComments[root.Content[i].Value]; ok {
+			root.Content[i].HeadComment = comment
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	doc := yaml.Node{Kind: yaml.DocumentNode, HeadComment: configFileHeader, Content: []*yaml.Node{&root}}
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0o600)
+}
+
+// EnsureGitignore appends the entries missing from the .gitignore in dir, returning the ones added. A line ignoring
+// a whole directory is replaced by its dir/* entry, the negations that follow it would have no effect otherwise.
+func EnsureGitignore(dir string, entries ...string) ([]string, error) {
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	wildcards := map[string]string{}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(gitignoreName(entry), "/*"); ok {
+			wildcards[name] = entry
+		}
+	}
+
+	var added []string
+	existing := map[string]bool{}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if entry, ok := wildcards[gitignoreName(trimmed)]; ok {
+			trimmed = strings.TrimSuffix(trimmed, "/") + "/*"
+			lines[i] = trimmed
+			added = append(added, entry)
+		}
+		existing[gitignoreName(trimmed)] = true
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines, "\n"))
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		b.WriteString("\n")
+	}
+	for _, entry := range entries {
+		if existing[gitignoreName(entry)] {
+			continue
+		}
+		b.WriteString(entry + "\n")
+		added = append(added, entry)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// gitignoreName is a .gitignore line without the leading and trailing slashes, which don't change what it matches
+// at the root
+func gitignoreName(line string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(line, "/"), "/")
+}
@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/config"
+)
+
+func TestFindRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	assert.NoError(t, os.MkdirAll(nested, 0o750))
+
+	_, ok := config.FindRepoRoot(nested)
+	assert.False(t, ok)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o750))
+	found, ok := config.FindRepoRoot(nested)
+	assert.True(t, ok)
+	assert.Equal(t, root, found)
+}
+
+func TestWriteConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".brains.yml")
+	assert.NoError(t, config.WriteConfigFile(path, &config.BrainsConfig{
+		AWSRegion:      "eu-west-1",
+		Model:          "anthropic.claude-3-haiku-20240307-v1:0",
+		Provider:       config.ProviderBedrock,
+		Personas:       map[string]string{"dev": config.PersonaPresets["dev"]},
+		DefaultPersona: "dev",
+		DefaultContext: "**/*.go",
+		ContextConfig:  config.ContextConfig{SendFileList: true},
+	}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# brains configuration, \"brains init\" walks through the main options\n")
+	assert.Contains(t, string(data), "# aws_region - region Bedrock is called in\naws_region: eu-west-1\n")
+	assert.Contains(t, string(data), "ROLE: Expert Software Developer")
+
+	// the written file loads back without problems
+	tmpDir := filepath.Dir(path)
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+	cfg, err := config.LoadConfig()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.GetConfig().Validate(nil))
+	assert.Equal(t, "dev", cfg.GetConfig().DefaultPersona)
+	assert.Equal(t, config.PersonaPresets["dev"], cfg.GetConfig().Personas["dev"])
+}
+
+func TestEnsureGitignore(t *testing.T) {
+	dir := t.TempDir()
+	added, err := config.EnsureGitignore(dir, config.GitignoreEntries...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".brains/*", "!.brains/personas/", "!.brains/prompts/", ".brains.local.yml"}, added)
+
+	path := filepath.Join(dir, ".gitignore")
+	assert.NoError(t, os.WriteFile(path, []byte("bin\n/.brains"), 0o600))
+	added, err = config.EnsureGitignore(dir, config.GitignoreEntries...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".brains/*", "!.brains/personas/", "!.brains/prompts/", ".brains.local.yml"}, added)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "bin\n/.brains/*\n!.brains/personas/\n!.brains/prompts/\n.brains.local.yml\n", string(data))
+
+	added, err = config.EnsureGitignore(dir, config.GitignoreEntries...)
+	assert.NoError(t, err)
+	assert.Empty(t, added)
+
+	// .brains/ from before personas ignores the whole directory, the negations only work after .brains/*
+	assert.NoError(t, os.WriteFile(path, []byte(".brains/\nbin\n.brains.local.yml\n"), 0o600))
+	added, err = config.EnsureGitignore(dir, config.GitignoreEntries...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".brains/*", "!.brains/personas/", "!.brains/prompts/"}, added)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, ".brains/*\nbin\n.brains.local.yml\n!.brains/personas/\n!.brains/prompts/\n", string(data))
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configField is a settable key of BrainsConfig, structured fields (lists and maps) take YAML from env vars
+type configField struct {
+	key        string
+	structured bool
+}
+
+// newDefaultConfig copies DefaultConfig so that merging layers never writes to it
+func newDefaultConfig() (*BrainsConfig, error) {
+	data, err := yaml.Marshal(&DefaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &BrainsConfig{origins: map[string]string{}, lines: map[string]int{}}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyFile merges a config file over the current values, only the keys present in the file change. Unknown
+// keys and values of the wrong type are kept as problems for Validate.
+func (b *BrainsConfig) applyFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return true, nil
+	}
+	root := doc.Content[0]
+	if err := root.Decode(b); err != nil {
+		problems, err := decodeProblems(path, err)
+		if err != nil {
+			return false, fmt.Errorf("parse %s: %w", path, err)
+		}
+		b.problems = append(b.problems, problems...)
+	}
+	b.problems = append(b.problems, unknownKeys(reflect.TypeOf(BrainsConfig{}), root, "", path)...)
+	b.recordOrigins("", root, path, root.Line)
+	return true, nil
+}
+
+// applyEnv merges BRAINS_* variables named after their key, ex: BRAINS_RESEARCH_MAX_STEPS for research.max_steps
+func (b *BrainsConfig) applyEnv() error {
+	for _, field := range configFields(reflect.TypeOf(BrainsConfig{}), "") {
+		name := envName(field.key)
+		if value := os.Getenv(name); value != "" {
+			if err := b.setValue(field, value, "env "+name); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if endpointURL := os.Getenv(EndpointURLEnv); endpointURL != "" {
+		return b.setValue(configField{key: "endpoint_url"}, endpointURL, "env "+EndpointURLEnv)
+	}
+	return nil
+}
+
+// SetFlag applies a command line flag on top of every other layer
+func (b *BrainsConfig) SetFlag(flagName, key, value string) error {
+	for _, field := range configFields(reflect.TypeOf(BrainsConfig{}), "") {
+		if field.key == key {
+			return b.setValue(field, value, "flag --"+flagName)
+		}
+	}
+	return fmt.Errorf("flag --%s sets unknown config key %s", flagName, key)
+}
+
+// setValue decodes value into the field at key and records origin as the layer that set it, values of the wrong
+// type are kept as problems for Validate
+func (b *BrainsConfig) setValue(field configField, value, origin string) error {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	if field.structured {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+			return err
+		}
+		if len(doc.Content) > 0 {
+			node = doc.Content[0]
+		}
+	}
+	leaf := node
+	parts := strings.Split(field.key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		keyNode := &yaml.Node{}
+		keyNode.SetString(parts[i])
+		node = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, node}}
+	}
+	if err := node.Decode(b); err != nil {
+		problems, err := decodeProblems(origin, err)
+		if err != nil {
+			return err
+		}
+		for i := range problems {
+			problems[i].Key = field.key
+		}
+		b.problems = append(b.problems, problems...)
+	}
+	b.recordOrigins(field.key, leaf, origin, 0)
+	return nil
+}
+
+// recordOrigins remembers origin and the line of every key set by node, line is 0 for layers other than files
+func (b *BrainsConfig) recordOrigins(prefix string, node *yaml.Node, origin string, line int) {
+	if b.origins == nil {
+		b.origins = map[string]string{}
+		b.lines = map[string]int{}
+	}
+	if node.Kind != yaml.MappingNode {
+		b.origins[prefix] = origin
+		b.lines[prefix] = line
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyLine := 0
+		if line > 0 {
+			keyLine = node.Content[i].Line
+		}
+		b.recordOrigins(joinKey(prefix, node.Content[i].Value), node.Content[i+1], origin, keyLine)
+	}
+}
+
+// Origin names the layer that set key: default, a config file, an env var or a flag
+func (b *BrainsConfig) Origin(key string) string {
+	if origin, ok := b.origins[key]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// Settings lists the effective value of every config key, entries of maps such as personas are listed one by one
+func (b *BrainsConfig) Settings() []Setting {
+	var settings []Setting
+	root := reflect.ValueOf(b).Elem()
+	for _, field := range configFields(root.Type(), "") {
+		value := fieldValue(root, field.key)
+		if value.Kind() == reflect.Map && value.Len() > 0 {
+			keys := value.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, k := range keys {
+				key := joinKey(field.key, k.String())
+				settings = append(settings, Setting{Key: key, Value: formatValue(value.MapIndex(k)), Origin: b.Origin(key)})
+			}
+			continue
+		}
+		settings = append(settings, Setting{Key: field.key, Value: formatValue(value), Origin: b.Origin(field.key)})
+	}
+	return settings
+}
+
+// configFields walks the yaml tags of t, nested structs are flattened into dotted keys
+func configFields(t reflect.Type, prefix string) []configField {
+	var fields []configField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if !f.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+		switch {
+		case f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Duration(0)):
+			fields = append(fields, configFields(f.Type, key)...)
+		default:
+			kind := f.Type.Kind()
+			fields = append(fields, configField{key: key, structured: kind == reflect.Map || kind == reflect.Slice})
+		}
+	}
+	return fields
+}
+
+func fieldValue(v reflect.Value, key string) reflect.Value {
+	for _, part := range strings.Split(key, ".") {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] == part {
+				v = v.Field(i)
+				break
+			}
+		}
+	}
+	return v
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		var node yaml.Node
+		if err := node.Encode(v.Interface()); err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		node.Style = yaml.FlowStyle
+		data, err := yaml.Marshal(&node)
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return strings.TrimSpace(string(data))
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
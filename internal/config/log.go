@@ -1,12 +1,19 @@
 package config
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/muesli/termenv"
@@ -30,51 +37,222 @@ func (b *BrainsConfig) InitLogger(enabled bool) error {
 	return nil
 }
 
-func (b *BrainsConfig) LogMessage(msg string) {
+// LogEvent appends e to the event log with the run ID and session of this run, its text is redacted
+func (b *BrainsConfig) LogEvent(e Event) {
 	if !b.logger.enabled || b.logger.file == nil {
 		return
 	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.RunID = b.RunID()
+	e.Session = b.Session()
+	r := b.Redactor()
+	e.Prompt = r.Redact(e.Prompt)
+	e.Response = r.Redact(e.Response)
+	e.Message = r.Redact(e.Message)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
 	b.logger.mu.Lock()
-	_, _ = b.logger.file.WriteString(msg + "\n")
+	_, _ = b.logger.file.Write(append(data, '\n'))
 	b.logger.mu.Unlock()
 }
 
-func (b *BrainsConfig) GetLogContext() string {
-	data, err := os.ReadFile(LogPath)
+// RunID identifies this run of brains in the event log, run IDs sort by start time
+func (b *BrainsConfig) RunID() string {
+	b.logger.mu.Lock()
+	defer b.logger.mu.Unlock()
+	if b.logger.runID == "" {
+		suffix := make([]byte, 3)
+		_, _ = rand.Read(suffix)
+		b.logger.runID = time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+	}
+	return b.logger.runID
+}
+
+// ReadEvents returns the events of the log selected by filter, oldest first. Lines that are not events are skipped.
+func (b *BrainsConfig) ReadEvents(filter LogFilter) ([]Event, error) {
+	f, err := os.Open(LogPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
-		return ""
+		return nil, err
 	}
+	defer func() { _ = f.Close() }()
 
-	newline := "\n"
-	if bytes.Contains(data, []byte("\r\n")) {
-		newline = "\r\n"
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Type == "" {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
 
-	re := regexp.MustCompile(`(?m)(?:^[ \t]*\r?\n){3,}`)
-	out := re.ReplaceAll(data, []byte(newline+newline))
+	runID := filter.RunID
+	if runID == LastRun && len(events) > 0 {
+		runID = events[len(events)-1].RunID
+	}
+	selected := events[:0]
+	for _, e := range events {
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, e.Type) {
+			continue
+		}
+		if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+			continue
+		}
+		if runID != "" && e.RunID != runID {
+			continue
+		}
+		if filter.Session != "" && e.sessionName() != filter.Session {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	return selected, nil
+}
 
-	return string(out)
+// GetLogContext is the previous activity of the current session sent to the model with send_logs
+func (b *BrainsConfig) GetLogContext() string {
+	return strings.Join(b.GetLogEntries(), "")
 }
 
-func (b *BrainsConfig) PrintLogs() {
+// GetLogEntries are the events of the current session as GetLogContext formats them, oldest first. They are
+// summarized in chunks with summarize_logs.
+func (b *BrainsConfig) GetLogEntries() []string {
+	events, err := b.ReadEvents(LogFilter{Session: b.Session()})
+	if err != nil {
+		return nil
+	}
+	var entries []string
+	for _, e := range events {
+		if slices.Contains(contextExcludedEvents, e.Type) {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("[%s %s]\n%s\n\n", e.Time.Format(time.RFC3339), e.Type, e.text()))
+	}
+	return entries
+}
+
+// PrintLogs prints the events selected by filter as markdown, or as JSON lines with asJSON
+func (b *BrainsConfig) PrintLogs(filter LogFilter, asJSON bool) error {
+	events, err := b.ReadEvents(filter)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		}
+		return nil
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&sb, "### %s %s", EventTypes[e.Type], e.Type)
+		if e.Tool != "" {
+			fmt.Fprintf(&sb, " %s", e.Tool)
+		}
+		fmt.Fprintf(&sb, "\n`%s` · run `%s`", e.Time.Local().Format(time.DateTime), e.RunID)
+		if e.Model != "" {
+			fmt.Fprintf(&sb, " · %s", e.Model)
+		}
+		if e.InputTokens > 0 || e.OutputTokens > 0 {
+			fmt.Fprintf(&sb, " · %d in / %d out tokens", e.InputTokens, e.OutputTokens)
+		}
+		if e.Cost > 0 {
+			fmt.Fprintf(&sb, " · $%.6f", e.Cost)
+		}
+		body := e.text()
+		if e.Type == EventCodeResponse {
+			body = "```json\n" + body + "\n```"
+		}
+		fmt.Fprintf(&sb, "\n\n%s\n\n", body)
+	}
+
 	r, _ := glamour.NewTermRenderer(
 		glamour.WithAutoStyle(),
 		glamour.WithWordWrap(120),
 		glamour.WithColorProfile(termenv.ANSI256),
 	)
+	rendered, _ := r.Render(sb.String())
+	fmt.Println(rendered)
+	return nil
+}
 
-	logCtx := b.GetLogContext()
+// ParseSince reads the --since of brains log, a duration before now (ex: 2h) or a date (2006-01-02 or RFC 3339)
+func ParseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration (ex: 2h) or a date (ex: 2006-01-02)", value)
+}
 
-	logCtx = strings.ReplaceAll(logCtx, "[REQUEST]", "[❓ REQUEST]")
-	logCtx = strings.ReplaceAll(logCtx, "[RESPONSE]", "[⚡ RESPONSE]")
-	logCtx = strings.ReplaceAll(logCtx, "[RESPONSE FOR CODE]", "[🧠 RESPONSE FOR CODE]")
-	logCtx = strings.ReplaceAll(logCtx, "[RESPONSE FOR RESEARCH]", "[🔍 RESPONSE FOR RESEARCH]")
+// ParseEventTypes checks the --type values of brains log
+func ParseEventTypes(values []string) ([]EventType, error) {
+	types := []EventType{}
+	for _, value := range values {
+		eventType := EventType(strings.TrimSpace(value))
+		if _, ok := EventTypes[eventType]; !ok {
+			known := []string{}
+			for t := range EventTypes {
+				known = append(known, string(t))
+			}
+			slices.Sort(known)
+			return nil, fmt.Errorf("unknown event type %q, expected one of %s", value, strings.Join(known, ", "))
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
 
-	rendered, _ := r.Render(logCtx)
-	fmt.Println(rendered)
+// text is the content of the event, prompt, response and message in that order
+func (e Event) text() string {
+	parts := []string{}
+	for _, part := range []string{e.Prompt, e.Response, e.Message} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// sessionName is the session of e, events logged before sessions existed belong to DefaultSession
+func (e Event) sessionName() string {
+	if e.Session == "" {
+		return DefaultSession
+	}
+	return e.Session
 }
 
+// Reset clears the events of the current session, other sessions are kept
 func (b *BrainsConfig) Reset() error {
+	session := b.Session()
+	return b.removeEvents(func(e Event) bool { return e.sessionName() == session })
+}
+
+// ResetAll clears the events of every session
+func (b *BrainsConfig) ResetAll() error {
 	if !b.logger.enabled {
 		return nil
 	}
@@ -92,3 +270,44 @@ func (b *BrainsConfig) Reset() error {
 	b.logger.file = f
 	return nil
 }
+
+// removeEvents rewrites the event log without the events matching remove, lines that are not events are kept
+func (b *BrainsConfig) removeEvents(remove func(Event) bool) error {
+	b.logger.mu.Lock()
+	defer b.logger.mu.Unlock()
+
+	data, err := os.ReadFile(LogPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var kept []byte
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var e Event
+		if err := json.Unmarshal(line, &e); err == nil && e.Type != "" && remove(e) {
+			continue
+		}
+		kept = append(kept, line...)
+	}
+
+	tmp := LogPath + ".tmp"
+	if err := os.WriteFile(tmp, kept, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, LogPath); err != nil {
+		return err
+	}
+	if b.logger.file == nil {
+		return nil
+	}
+	// the open file still points to the replaced log
+	_ = b.logger.file.Close()
+	f, err := os.OpenFile(LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	b.logger.file = f
+	return nil
+}
@@ -3,17 +3,63 @@ package config
 import (
 	"os"
 	"sync"
+	"time"
+
+	"github.com/madhuravius/brains/internal/redact"
 )
 
 type logger struct {
 	enabled bool
 	file    *os.File
+	runID   string
+	// session is set by --session for one run, the active session of SessionFile otherwise
+	session string
 	mu      sync.Mutex
 }
 
 type SimpleLogger interface {
-	LogMessage(string)
+	LogEvent(Event)
 	GetLogContext() string
+	GetLogEntries() []string
+}
+
+// EventType classifies the events of the log, see EventTypes
+type EventType string
+
+// Event is a line of the event log, Time, RunID and Session are set by LogEvent
+type Event struct {
+	Time    time.Time `json:"time"`
+	RunID   string    `json:"run_id"`
+	Session string    `json:"session"`
+	Type    EventType `json:"type"`
+	Model   string    `json:"model,omitempty"`
+	// Tool is the research tool of tool events
+	Tool         string  `json:"tool,omitempty"`
+	Prompt       string  `json:"prompt,omitempty"`
+	Response     string  `json:"response,omitempty"`
+	Message      string  `json:"message,omitempty"`
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
+}
+
+// LogFilter selects events, the zero value selects all of them
+type LogFilter struct {
+	Types []EventType
+	Since time.Time
+	// RunID is a run ID or LastRun
+	RunID string
+	// Session keeps the events of a session, every session when empty
+	Session string
+}
+
+// SessionInfo describes a session of the event log for brains session list
+type SessionInfo struct {
+	Name         string
+	Events       int
+	Runs         int
+	LastActivity time.Time
+	Active       bool
 }
 
 type ContextConfig struct {
@@ -21,22 +67,152 @@ type ContextConfig struct {
 	SummarizeLogs bool `yaml:"summarize_logs"`
 	SendAllTags   bool `yaml:"send_all_tags"`
 	SendFileList  bool `yaml:"send_file_list"`
+	// SummaryChunkTokens bounds each chunk of the log summarized with summarize_logs and each merge of the summaries
+	SummaryChunkTokens int `yaml:"summary_chunk_tokens,omitempty"`
+}
+
+// AssumeRoleConfig has brains assume an IAM role on top of the base credentials (aws_profile or the default chain)
+type AssumeRoleConfig struct {
+	RoleARN     string        `yaml:"role_arn"`
+	SessionName string        `yaml:"session_name,omitempty"`
+	ExternalID  string        `yaml:"external_id,omitempty"`
+	Duration    time.Duration `yaml:"duration,omitempty"`
+	MFASerial   string        `yaml:"mfa_serial,omitempty"`
+}
+
+// ResearchConfig bounds the tool-use loop that gathers context before ask and code run
+type ResearchConfig struct {
+	MaxSteps  int `yaml:"max_steps"`
+	MaxTokens int `yaml:"max_tokens"`
+}
+
+type GuardrailConfig struct {
+	Identifier string `yaml:"identifier"`
+	Version    string `yaml:"version"`
+	Trace      string `yaml:"trace"`
+}
+
+// HooksConfig controls how pre_commands and post_edit_commands run
+type HooksConfig struct {
+	// Disabled skips every hook command, set by --no-hooks
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Timeout stops a hook command running longer
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MinimalEnv runs hook commands with only the variables in hookEnv instead of the whole environment
+	MinimalEnv bool `yaml:"minimal_env,omitempty"`
+}
+
+// GitConfig controls how brains code works with the git repository of the files it edits
+type GitConfig struct {
+	// DirtyFiles is what brains code does when a file it is about to edit has uncommitted changes: warn, refuse or ignore
+	DirtyFiles string `yaml:"dirty_files,omitempty"`
+	// AutoCommit commits the applied edits with a message made from the prompt and the summary of the response
+	AutoCommit bool `yaml:"auto_commit,omitempty"`
+	// Branch is where the edits are committed: current, or new for a brains/<slug> branch named after the prompt
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// TrustedCommands are the project hook commands approved for a repository
+type TrustedCommands struct {
+	Hash       string    `json:"hash"`
+	Commands   []string  `json:"commands"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// OpenAIConfig points brains at an OpenAI-compatible server (llama.cpp, vLLM, Ollama, ...)
+type OpenAIConfig struct {
+	BaseURL   string `yaml:"base_url"`
+	APIKeyEnv string `yaml:"api_key_env"`
+}
+
+// CommandResult is the outcome of a command run with "bash -c"
+type CommandResult struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+// RedactionConfig scrubs secrets from prompts and logs, Patterns and DenyFiles add to the builtin detectors and
+// redact.DefaultDenyFiles
+type RedactionConfig struct {
+	Disabled bool     `yaml:"disabled,omitempty"`
+	Patterns []string `yaml:"patterns,omitempty"`
+	// DenyFiles are never read into the context, gitignore syntax
+	DenyFiles []string `yaml:"deny_files,omitempty"`
 }
 
 type BrainsConfig struct {
 	LoggingEnabled bool              `yaml:"logging_enabled"`
 	AWSRegion      string            `yaml:"aws_region"`
+	AWSProfile     string            `yaml:"aws_profile,omitempty"`
+	AssumeRole     AssumeRoleConfig  `yaml:"assume_role,omitempty"`
+	EndpointURL    string            `yaml:"endpoint_url,omitempty"`
 	Model          string            `yaml:"model"`
+	Provider       string            `yaml:"provider,omitempty"`
+	OpenAI         OpenAIConfig      `yaml:"openai,omitempty"`
 	Personas       map[string]string `yaml:"personas"`
 	DefaultContext string            `yaml:"default_context"`
 	DefaultPersona string            `yaml:"default_persona"`
 	PreCommands    []string          `yaml:"pre_commands"`
-	ContextConfig  ContextConfig     `yaml:"context_config"`
+	// PostEditCommands run after code edits are applied, their failures are sent back to the model to repair
+	PostEditCommands    []string        `yaml:"post_edit_commands,omitempty"`
+	MaxRepairIterations int             `yaml:"max_repair_iterations,omitempty"`
+	Hooks               HooksConfig     `yaml:"hooks,omitempty"`
+	ContextConfig       ContextConfig   `yaml:"context_config"`
+	Research            ResearchConfig  `yaml:"research"`
+	ReasoningBudget     int             `yaml:"reasoning_budget,omitempty"`
+	ReasoningDisplay    string          `yaml:"reasoning_display,omitempty"`
+	Guardrail           GuardrailConfig `yaml:"guardrail,omitempty"`
+	Redaction           RedactionConfig `yaml:"redaction,omitempty"`
+	Git                 GitConfig       `yaml:"git,omitempty"`
+
+	logger       logger            `yaml:"-"`
+	redactor     *redact.Redactor  `yaml:"-"`
+	redactorOnce sync.Once         `yaml:"-"`
+	path         string            `yaml:"-"`
+	repoRoot     string            `yaml:"-"`
+	origins      map[string]string `yaml:"-"`
+	lines        map[string]int    `yaml:"-"`
+	problems     []Problem         `yaml:"-"`
+}
+
+// Problem is an invalid config value, Line is 0 unless a config file set it. Warnings don't stop brains.
+type Problem struct {
+	Origin  string
+	Line    int
+	Key     string
+	Message string
+	Warning bool
+}
+
+// Setting is the effective value of a config key and the layer it comes from
+type Setting struct {
+	Key    string
+	Value  string
+	Origin string
+}
 
-	logger logger `yaml:"-"`
+// Persona is a markdown file in .brains/personas, its frontmatter overrides settings while it is used and
+// extends names a persona whose settings and instructions it builds on. Personas of the personas map only
+// have instructions.
+type Persona struct {
+	Name        string   `yaml:"-"`
+	Extends     string   `yaml:"extends,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Model       string   `yaml:"model,omitempty"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	// Tools limits the research tools the model may call, all of them when empty
+	Tools []string `yaml:"tools,omitempty"`
+	// Context is the glob sent as context when --add is not set
+	Context string `yaml:"context,omitempty"`
+	// Commands limits the brains commands the persona runs with (ex: ask but not code), all of them when empty
+	Commands     []string `yaml:"commands,omitempty"`
+	Instructions string   `yaml:"-"`
+	// Source is the file or config layer the persona was loaded from
+	Source string `yaml:"-"`
 }
 
 type BrainsConfigImpl interface {
 	GetConfig() *BrainsConfig
-	GetPersonaInstructions(persona string) string
+	ResolvePersona(name string) (*Persona, error)
 }
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+)
+
+// PromptInstructions formats the persona instructions ahead of a prompt, empty when there are none
+func (p *Persona) PromptInstructions() string {
+	if p == nil || p.Instructions == "" {
+		return ""
+	}
+	return fmt.Sprintf("Human: %s\n\n", p.Instructions)
+}
+
+// AllowsCommand reports whether the persona may run a brains command, personas without commands allow all
+func (p *Persona) AllowsCommand(command string) bool {
+	return p == nil || len(p.Commands) == 0 || slices.Contains(p.Commands, command)
+}
+
+// ResolvePersona returns the persona called name with the settings of the personas it extends applied
+// underneath, the empty name resolves to an empty persona
+func (b *BrainsConfig) ResolvePersona(name string) (*Persona, error) {
+	if name == "" {
+		return &Persona{}, nil
+	}
+	personas, err := b.loadPersonas()
+	if err != nil {
+		return nil, err
+	}
+	return resolvePersona(personas, name, nil)
+}
+
+// ListPersonas returns every persona resolved, sorted by name
+func (b *BrainsConfig) ListPersonas() ([]*Persona, error) {
+	personas, err := b.loadPersonas()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(personas))
+	for name := range personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resolved := make([]*Persona, 0, len(names))
+	for _, name := range names {
+		p, err := resolvePersona(personas, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// PrintPersonas lists every persona with its settings and where it is defined
+func (b *BrainsConfig) PrintPersonas() error {
+	personas, err := b.ListPersonas()
+	if err != nil {
+		return err
+	}
+	tableData := pterm.TableData{{"Name", "Extends", "Model", "Commands", "Description", "Source"}}
+	for _, p := range personas {
+		tableData = append(tableData, []string{p.Name, p.Extends, p.Model, strings.Join(p.Commands, ", "), p.Description, p.Source})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+}
+
+// PrintPersona prints a persona with the settings it inherits resolved, as a persona file would hold them
+func (b *BrainsConfig) PrintPersona(name string) error {
+	p, err := b.ResolvePersona(name)
+	if err != nil {
+		return err
+	}
+	frontmatter, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	pterm.Info.Printfln("persona %s from %s", p.Name, p.Source)
+	fmt.Printf("%s\n%s%s\n\n%s\n", frontmatterDelimiter, frontmatter, frontmatterDelimiter, p.Instructions)
+	return nil
+}
+
+// NewPersonaFile writes a persona file into the repository persona directory and returns its path
+func (b *BrainsConfig) NewPersonaFile(p *Persona) (string, error) {
+	if err := validateName("persona", p.Name); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(b.baseDir(), PersonasDir)
+	path := filepath.Join(dir, p.Name+personaExt)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("persona %s already exists at %s", p.Name, path)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	frontmatter, err := yaml.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	out.WriteString(frontmatterDelimiter + "\n")
+	if !bytes.Equal(frontmatter, []byte("{}\n")) {
+		out.Write(frontmatter)
+	}
+	out.WriteString(frontmatterDelimiter + "\n\n")
+	out.WriteString(strings.TrimSpace(p.Instructions) + "\n")
+	return path, os.WriteFile(path, out.Bytes(), 0o600)
+}
+
+// loadPersonas collects personas from the personas map, then ~/.brains/personas and the repository persona
+// directory, later sources replace earlier personas of the same name
+func (b *BrainsConfig) loadPersonas() (map[string]*Persona, error) {
+	personas := map[string]*Persona{}
+	for name, instructions := range b.Personas {
+		personas[name] = &Persona{Name: name, Instructions: strings.TrimSpace(instructions), Source: b.Origin("personas." + name)}
+	}
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, PersonasDir))
+	}
+	if repoDir := filepath.Join(b.baseDir(), PersonasDir); !slices.Contains(dirs, repoDir) {
+		dirs = append(dirs, repoDir)
+	}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*"+personaExt))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			p, err := loadPersonaFile(path)
+			if err != nil {
+				return nil, err
+			}
+			personas[p.Name] = p
+		}
+	}
+	return personas, nil
+}
+
+// baseDir is where the repository persona directory lives, the current directory outside of a repository
+func (b *BrainsConfig) baseDir() string {
+	if b.repoRoot != "" {
+		return b.repoRoot
+	}
+	return "."
+}
+
+// loadPersonaFile reads a markdown persona, an optional YAML frontmatter between "---" lines holds its settings
+func loadPersonaFile(path string) (*Persona, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Persona{}
+	body := string(data)
+	if rest, ok := strings.CutPrefix(body, frontmatterDelimiter+"\n"); ok {
+		frontmatter, instructions, found := strings.Cut(rest, "\n"+frontmatterDelimiter)
+		if !found {
+			return nil, fmt.Errorf("persona %s: frontmatter is not closed with %s", path, frontmatterDelimiter)
+		}
+		dec := yaml.NewDecoder(strings.NewReader(frontmatter))
+		dec.KnownFields(true)
+		if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("persona %s: %w", path, err)
+		}
+		body = instructions
+	}
+	p.Name = strings.TrimSuffix(filepath.Base(path), personaExt)
+	p.Instructions = strings.TrimSpace(body)
+	p.Source = path
+	return p, nil
+}
+
+// resolvePersona applies the chain of extended personas, seen guards against cycles
+func resolvePersona(personas map[string]*Persona, name string, seen []string) (*Persona, error) {
+	p, ok := personas[name]
+	if !ok {
+		return nil, fmt.Errorf("persona %q is not defined, %s", name, personaChoices(personas))
+	}
+	if slices.Contains(seen, name) {
+		return nil, fmt.Errorf("persona %s extends itself through %s", name, strings.Join(append(seen, name), " -> "))
+	}
+	resolved := *p
+	if p.Extends == "" {
+		return &resolved, nil
+	}
+	parent, err := resolvePersona(personas, p.Extends, append(seen, name))
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Model == "" {
+		resolved.Model = parent.Model
+	}
+	if resolved.Temperature == nil {
+		resolved.Temperature = parent.Temperature
+	}
+	if resolved.Tools == nil {
+		resolved.Tools = parent.Tools
+	}
+	if resolved.Context == "" {
+		resolved.Context = parent.Context
+	}
+	if resolved.Commands == nil {
+		resolved.Commands = parent.Commands
+	}
+	if resolved.Description == "" {
+		resolved.Description = parent.Description
+	}
+	// instructions add up, the persona's own come last so they win over the ones it extends
+	resolved.Instructions = strings.TrimSpace(parent.Instructions + "\n\n" + p.Instructions)
+	return &resolved, nil
+}
+
+func personaChoices[T any](personas map[string]T) string {
+	if len(personas) == 0 {
+		return "no personas are configured"
+	}
+	names := make([]string, 0, len(personas))
+	for name := range personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "expected one of " + strings.Join(names, ", ")
+}
@@ -0,0 +1,145 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/config"
+)
+
+// personaRepo loads the config of a repository holding the given persona files
+func personaRepo(t *testing.T, files map[string]string) *config.BrainsConfig {
+	root := t.TempDir()
+	origWD, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(origWD) })
+	_ = os.Chdir(root)
+	t.Setenv("HOME", t.TempDir())
+
+	assert.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o750))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".brains.yml"), []byte("personas:\n  base: Be concise.\n"), 0o600))
+	dir := filepath.Join(root, config.PersonasDir)
+	assert.NoError(t, os.MkdirAll(dir, 0o750))
+	for name, contents := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+	brainsConfig, err := config.LoadConfig()
+	assert.NoError(t, err)
+	return brainsConfig.GetConfig()
+}
+
+func TestResolvePersonaExtends(t *testing.T) {
+	cfg := personaRepo(t, map[string]string{
+		"dev.md": `---
+extends: base
+model: anthropic.claude-3-haiku-20240307-v1:0
+temperature: 0.2
+context: "**/*.go"
+---
+
+Write idiomatic Go.
+`,
+		"review.md": `---
+extends: dev
+description: reviews without editing
+commands: [ask]
+tools: [read_file, glob]
+---
+Only point out actionable issues.
+`,
+		"notes.md": "Just instructions, no frontmatter.\n",
+	})
+
+	review, err := cfg.ResolvePersona("review")
+	assert.NoError(t, err)
+	temperature := 0.2
+	assert.Equal(t, &config.Persona{
+		Name:         "review",
+		Extends:      "dev",
+		Description:  "reviews without editing",
+		Model:        "anthropic.claude-3-haiku-20240307-v1:0",
+		Temperature:  &temperature,
+		Tools:        []string{"read_file", "glob"},
+		Context:      "**/*.go",
+		Commands:     []string{"ask"},
+		Instructions: "Be concise.\n\nWrite idiomatic Go.\n\nOnly point out actionable issues.",
+		Source:       filepath.Join(cfg.Path(), "..", config.PersonasDir, "review.md"),
+	}, review)
+	assert.True(t, review.AllowsCommand("ask"))
+	assert.False(t, review.AllowsCommand("code"))
+
+	dev, err := cfg.ResolvePersona("dev")
+	assert.NoError(t, err)
+	assert.True(t, dev.AllowsCommand("code"))
+	assert.Nil(t, dev.Tools)
+
+	notes, err := cfg.ResolvePersona("notes")
+	assert.NoError(t, err)
+	assert.Equal(t, "Just instructions, no frontmatter.", notes.Instructions)
+
+	personas, err := cfg.ListPersonas()
+	assert.NoError(t, err)
+	names := []string{}
+	for _, p := range personas {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"base", "dev", "notes", "review"}, names)
+	assert.Equal(t, cfg.Path(), personas[0].Source)
+}
+
+func TestResolvePersonaErrors(t *testing.T) {
+	cfg := personaRepo(t, map[string]string{
+		"a.md":      "---\nextends: b\n---\na",
+		"b.md":      "---\nextends: a\n---\nb",
+		"orphan.md": "---\nextends: missing\n---\n",
+	})
+
+	_, err := cfg.ResolvePersona("a")
+	assert.EqualError(t, err, "persona a extends itself through a -> b -> a")
+	_, err = cfg.ResolvePersona("orphan")
+	assert.ErrorContains(t, err, `persona "missing" is not defined`)
+
+	// a broken persona file fails every lookup so it is noticed
+	broken := personaRepo(t, map[string]string{"open.md": "---\nmodel: x\n"})
+	_, err = broken.ResolvePersona("base")
+	assert.ErrorContains(t, err, "frontmatter is not closed")
+}
+
+func TestResolvePersonaFrontmatterErrors(t *testing.T) {
+	cfg := personaRepo(t, map[string]string{"typo.md": "---\nmodels: x\n---\n"})
+	_, err := cfg.ResolvePersona("typo")
+	assert.ErrorContains(t, err, "field models not found")
+
+	cfg = personaRepo(t, map[string]string{"open.md": "---\nmodel: x\n"})
+	_, err = cfg.ResolvePersona("open")
+	assert.ErrorContains(t, err, "frontmatter is not closed")
+}
+
+func TestNewPersonaFile(t *testing.T) {
+	cfg := personaRepo(t, nil)
+	path, err := cfg.NewPersonaFile(&config.Persona{
+		Name:         "docs",
+		Extends:      "base",
+		Commands:     []string{"ask"},
+		Instructions: "Write documentation.",
+	})
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "---\nextends: base\ncommands:\n    - ask\n---\n\nWrite documentation.\n", string(data))
+
+	docs, err := cfg.ResolvePersona("docs")
+	assert.NoError(t, err)
+	assert.Equal(t, "Be concise.\n\nWrite documentation.", docs.Instructions)
+
+	_, err = cfg.NewPersonaFile(&config.Persona{Name: "docs"})
+	assert.ErrorContains(t, err, "already exists")
+
+	for _, name := range []string{"../../foo", "nested/docs", "..", ".hidden", ""} {
+		_, err = cfg.NewPersonaFile(&config.Persona{Name: name})
+		assert.EqualError(t, err, fmt.Sprintf("invalid persona name %q, use letters, digits, dots, dashes and underscores", name))
+	}
+}
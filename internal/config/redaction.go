@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/redact"
+)
+
+// Redactor scrubs secrets from the prompts and logs of this run, it is nil when redaction is disabled. Values of
+// the .env files at the repository root are redacted as well.
+func (b *BrainsConfig) Redactor() *redact.Redactor {
+	b.redactorOnce.Do(func() {
+		if b.Redaction.Disabled {
+			return
+		}
+		key, err := redactionKey()
+		if err != nil {
+			pterm.Warning.Printfln("redaction: placeholders only hold for this run, failed to read the key: %v", err)
+		}
+		r, err := redact.New(key, b.Redaction.Patterns)
+		if err != nil {
+			pterm.Warning.Printfln("redaction: %v", err)
+		}
+		if err := r.LoadEnvFiles(b.baseDir()); err != nil {
+			pterm.Warning.Printfln("redaction: failed to read env files: %v", err)
+		}
+		b.redactor = r
+	})
+	return b.redactor
+}
+
+// DenyFiles are the files never read into the context, redact.DefaultDenyFiles and redaction.deny_files
+func (b *BrainsConfig) DenyFiles() []string {
+	return append(append([]string{}, redact.DefaultDenyFiles...), b.Redaction.DenyFiles...)
+}
+
+// redactionKey reads RedactionKeyFile, creating it on first use so placeholders stay the same across runs
+func redactionKey() ([]byte, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(home, RedactionKeyFile)
+	key, err := os.ReadFile(path) // #nosec G304 -- the key file is in the home directory
+	if err == nil && len(key) > 0 {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	key = []byte(hex.EncodeToString(redact.NewKey()))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	return key, os.WriteFile(path, key, 0o600)
+}
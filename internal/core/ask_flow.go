@@ -2,27 +2,21 @@ package core
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pterm/pterm"
 
-	"github.com/madhuravius/brains/internal/aws"
+	brainsConfig "github.com/madhuravius/brains/internal/config"
 	"github.com/madhuravius/brains/internal/dag"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/prompts"
 )
 
 func (a *AskData) generateAskFunction(coreConfig *CoreConfig, req *LLMRequest) askDataDAGFunction {
-	additionalContext := a.generateInitialContextRun()
 	return func(inputs map[string]string) (string, error) {
-		coreConfig.Ask(
-			a.RepoMapContext+"\n\nAbove is a mapping of the current repository\n\n"+
-				additionalContext+"\n\n\nis hydrated as initial context, you can now return to answering the prompt.\n\n\n"+
-				req.Prompt,
-			req.PersonaInstructions,
-			req.ModelID,
-			req.Glob,
-		)
+		// the context is read once the steps ahead of ask have gathered it
+		coreConfig.Ask(a.promptVars(req), req.ModelID, req.Glob)
 
 		return "", nil
 	}
@@ -57,7 +51,7 @@ func (c *CoreConfig) AskFlow(ctx context.Context, llmRequest *LLMRequest) error
 	logSummaryVertex := &dag.Vertex[string, *AskData]{
 		Name: "logSummary",
 		DAG:  askDAG,
-		Run:  generateLogSummary(c, llmRequest, ctx, askData),
+		Run:  generateLogSummary(c, ctx, askData),
 	}
 	if !c.brainsConfig.GetConfig().ContextConfig.SummarizeLogs {
 		logSummaryVertex.SkipConfig = &dag.SkipVertexConfig{
@@ -112,51 +106,36 @@ func (c *CoreConfig) AskFlow(ctx context.Context, llmRequest *LLMRequest) error
 	return nil
 }
 
-func (c *CoreConfig) Ask(prompt, personaInstructions, modelID, glob string) bool {
+func (c *CoreConfig) Ask(vars prompts.Vars, modelID, glob string) bool {
 	pterm.Info.Println("starting ask operation")
 	ctx := context.Background()
-	promptToSendBedrock := c.addLogContextToPrompt(prompt)
-	if personaInstructions != "" {
-		prompt = fmt.Sprintf("%s%s", personaInstructions, prompt)
-	}
-	c.logger.LogMessage("[REQUEST] \n " + prompt)
+	c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventRequest, Model: modelID, Prompt: vars.Persona + vars.Prompt})
 
-	addedContext, err := c.enrichWithGlob(glob)
-	if err != nil {
+	if err := c.addGlobFiles(&vars, glob); err != nil {
 		return false
 	}
-	if addedContext != "" {
-		promptToSendBedrock = fmt.Sprintf("%s%s", prompt, addedContext)
-	}
-	req := aws.BedrockRequest{
-		Messages: []aws.BedrockMessage{
-			{
-				Role: "user",
-				Content: []aws.BedrockContent{
-					{
-						Type: "text",
-						Text: promptToSendBedrock,
-					},
-				},
-			},
-		},
+	prompt, err := c.render(prompts.Ask, vars)
+	if err != nil {
+		return false
 	}
-
-	respBody, err := c.awsImpl.CallAWSBedrock(ctx, modelID, req)
+	promptToSendBedrock, err := c.addLogContextToPrompt(prompt)
 	if err != nil {
-		pterm.Error.Printf("invokeModel error: %v\n", err)
 		return false
 	}
-	var data aws.ChatResponse
-	if err := json.Unmarshal(respBody, &data); err != nil {
-		pterm.Error.Printf("json Unmarshal error (when parsing Bedrock Body): %v\n", err)
+	c.printEstimatedContext(modelID, promptToSendBedrock)
+	data, err := c.redactedLLM().Chat(ctx, modelID, llm.NewUserRequest(promptToSendBedrock))
+	if err != nil {
+		pterm.Error.Printf("chat error: %v\n", err)
 		return false
 	}
+	answers := []string{}
 	for _, choice := range data.Choices {
-		c.logger.LogMessage("[RESPONSE] \n " + choice.Message.Content)
-		c.awsImpl.PrintBedrockMessage(choice.Message.Content)
+		c.printReasoning(choice.Message.Reasoning)
+		answers = append(answers, choice.Message.Content)
+		c.llmImpl.PrintMessage(choice.Message.Content)
 	}
-	c.awsImpl.PrintCost(data.Usage, modelID)
-	c.awsImpl.PrintContext(data.Usage, modelID)
+	c.logResponse(brainsConfig.EventResponse, modelID, strings.Join(answers, "\n\n"), data.Usage)
+	c.llmImpl.PrintCost(data.Usage, modelID)
+	c.llmImpl.PrintContext(data.Usage, modelID)
 	return true
 }
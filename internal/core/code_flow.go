@@ -3,38 +3,36 @@ package core
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/pterm/pterm"
 
-	"github.com/madhuravius/brains/internal/aws"
+	brainsConfig "github.com/madhuravius/brains/internal/config"
 	"github.com/madhuravius/brains/internal/dag"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/prompts"
+	"github.com/madhuravius/brains/internal/tools/file_system"
 )
 
 func (c *CodeData) generateDetermineCodeChangesFunction(coreConfig *CoreConfig, req *LLMRequest) codeDataDAGFunction {
-	additionalContext := ""
-	for url, data := range c.ResearchData {
-		additionalContext += "------ scraped content from: " + url + "\n\n\n" + data + "\n\n\n" + "------------"
-	}
-	for filePath, fileContents := range c.FileMapData {
-		additionalContext += "----- requested file content: " + filePath + "\n\n\n" + fileContents + "\n\n\n" + "------------"
-	}
 	return func(inputs map[string]string) (string, error) {
-		c.CodeModelResponse = coreConfig.DetermineCodeChanges(
-			additionalContext+"\n\n\nwere visited above with content if available, you can now return to answering the prompt.\n\n\n"+req.Prompt,
-			req.PersonaInstructions,
-			req.ModelID,
-			req.Glob,
-		)
+		// the context is read once the steps ahead of determine_code_changes have gathered it
+		c.CodeModelResponse = coreConfig.DetermineCodeChanges(c.promptVars(req), req.ModelID, req.Glob)
+		if c.CodeModelResponse == nil {
+			return "", fmt.Errorf("unable to determine code changes")
+		}
 
 		return "", nil
 	}
 }
 
-func (c *CodeData) generateExecuteCodeEditsFunction(coreConfig *CoreConfig) codeDataDAGFunction {
+func (c *CodeData) generateExecuteCodeEditsFunction(coreConfig *CoreConfig, req *LLMRequest) codeDataDAGFunction {
 	return func(inputs map[string]string) (string, error) {
-		if !coreConfig.ExecuteEditCode(c.CodeModelResponse) {
+		applied, ok := coreConfig.ExecuteEditCode(req.Prompt, c.CodeModelResponse)
+		if !ok {
 			return "", fmt.Errorf("error in generateExecuteCodeEditsFunction, unable to execute edits")
 		}
+		c.Applied = applied
 		return "", nil
 	}
 }
@@ -68,7 +66,7 @@ func (c *CoreConfig) CodeFlow(ctx context.Context, llmRequest *LLMRequest) error
 	logSummaryVertex := &dag.Vertex[string, *CodeData]{
 		Name: "logSummary",
 		DAG:  codeDAG,
-		Run:  generateLogSummary(c, llmRequest, ctx, codeData),
+		Run:  generateLogSummary(c, ctx, codeData),
 	}
 	if !c.brainsConfig.GetConfig().ContextConfig.SummarizeLogs {
 		logSummaryVertex.SkipConfig = &dag.SkipVertexConfig{
@@ -109,16 +107,30 @@ func (c *CoreConfig) CodeFlow(ctx context.Context, llmRequest *LLMRequest) error
 	executeCodeEditsVertex := &dag.Vertex[string, *CodeData]{
 		Name:        "execute_code_edits",
 		DAG:         codeDAG,
-		Run:         codeData.generateExecuteCodeEditsFunction(c),
+		Run:         codeData.generateExecuteCodeEditsFunction(c, llmRequest),
 		EnableRetry: true,
 	}
 	_ = codeDAG.AddVertex(executeCodeEditsVertex)
 
+	postEditRepairVertex := &dag.Vertex[string, *CodeData]{
+		Name: "post_edit_repair",
+		DAG:  codeDAG,
+		Run:  codeData.generatePostEditRepairFunction(c, llmRequest),
+	}
+	if reason := c.brainsConfig.GetConfig().PostEditCommandsSkipReason(); reason != "" {
+		postEditRepairVertex.SkipConfig = &dag.SkipVertexConfig{
+			Enabled: true,
+			Reason:  reason,
+		}
+	}
+	_ = codeDAG.AddVertex(postEditRepairVertex)
+
 	codeDAG.Connect(fileListVertex.Name, researchVertex.Name)
 	codeDAG.Connect(logSummaryVertex.Name, researchVertex.Name)
 	codeDAG.Connect(repoMapVertex.Name, researchVertex.Name)
 	codeDAG.Connect(researchVertex.Name, determineCodeChangesVertex.Name)
 	codeDAG.Connect(determineCodeChangesVertex.Name, executeCodeEditsVertex.Name)
+	codeDAG.Connect(executeCodeEditsVertex.Name, postEditRepairVertex.Name)
 
 	pterm.Success.Println("codeDAG beginning execution, planned flow printed")
 	codeDAG.Visualize()
@@ -132,88 +144,109 @@ func (c *CoreConfig) CodeFlow(ctx context.Context, llmRequest *LLMRequest) error
 	return nil
 }
 
-func (c *CoreConfig) ExecuteEditCode(data *CodeModelResponse) bool {
-	pterm.Info.Printfln("reviewing each code update, for review one at a time. %d pending updates", len(data.CodeUpdates))
-
-	for updateIdx, update := range data.CodeUpdates {
-		pterm.Info.Printfln("updating file: %s (%d/%d)", update.Path, updateIdx+1, len(data.CodeUpdates))
-
-		if _, err := c.toolsConfig.fsToolConfig.UpdateFile(update.Path, update.OldCode, update.NewCode, true); err != nil {
-			pterm.Error.Printfln("failed to update %s: %v", update.Path, err)
-			return false
+// ExecuteEditCode reviews the changes of data one at a time and applies the accepted ones as a single transaction,
+// the files are snapshotted first so "brains undo" can restore them. With git.auto_commit the applied changes are
+// committed with a message made from prompt and the summary of data. It returns the number of changes applied.
+func (c *CoreConfig) ExecuteEditCode(prompt string, data *CodeModelResponse) (int, bool) {
+	changes, conflicts := c.planChanges(data)
+	if len(conflicts) > 0 {
+		// the files may have changed since the edits were validated, conflicting files are left as they are
+		for _, conflict := range conflicts {
+			pterm.Warning.Printfln("conflict, not written: %s", conflict)
 		}
+		c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventValidation, Message: "conflicts, not written:\n" + strings.Join(conflicts, "\n")})
 	}
 
-	for addIdx, add := range data.AddCodeFiles {
-		pterm.Info.Printfln("adding new file: %s (%d/%d)", add.Path, addIdx+1, len(data.AddCodeFiles))
-		ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultText(fmt.Sprintf("Create file %s?", add.Path)).Show()
-		if !ok {
-			pterm.Warning.Printfln("skipped creation of: %s", add.Path)
-			continue
+	if !c.checkDirtyFiles(changes) {
+		return 0, false
+	}
+
+	pterm.Info.Printfln("reviewing each change, for review one at a time. %d pending changes", len(changes))
+	var accepted []file_system.Change
+	for changeIdx, change := range changes {
+		pterm.Info.Printfln("%s file: %s (%d/%d)", change.Op, change.Path, changeIdx+1, len(changes))
+		for _, note := range change.Notes {
+			pterm.Warning.Println(note)
 		}
-		if err := c.toolsConfig.fsToolConfig.CreateFile(add.Path, add.Content); err != nil {
-			pterm.Error.Printfln("failed to write %s: %v", add.Path, err)
-			return false
+		if c.toolsConfig.fsToolConfig.ReviewChange(change.Change) {
+			accepted = append(accepted, change.Change)
 		}
 	}
+	if len(accepted) == 0 {
+		pterm.Info.Println("no changes to apply")
+		return 0, true
+	}
 
-	for remIdx, rem := range data.RemoveCodeFiles {
-		pterm.Info.Printfln("removing file: %s (%d/%d)", rem.Path, remIdx+1, len(data.RemoveCodeFiles))
+	runID := c.brainsConfig.GetConfig().RunID()
+	if err := c.toolsConfig.fsToolConfig.ApplyChanges(runID, accepted); err != nil {
+		pterm.Error.Printfln("failed to apply the changes: %v", err)
+		return 0, false
+	}
+	pterm.Success.Printfln("applied %d change(s), \"brains undo %s\" restores the files", len(accepted), runID)
 
-		ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultText(fmt.Sprintf("Delete file %s?", rem.Path)).Show()
-		if !ok {
-			pterm.Warning.Printfln("skipped deletion of: %s", rem.Path)
-			continue
-		}
-		if err := c.toolsConfig.fsToolConfig.DeleteFile(rem.Path); err != nil {
-			pterm.Error.Printfln("failed to write %s: %v", rem.Path, err)
-			return false
+	paths := make([]string, len(accepted))
+	for i, change := range accepted {
+		paths[i] = change.Path
+		c.editedPaths[change.Path] = true
+		// later rounds plan their edits against what was written
+		if change.Op == file_system.OpRemove {
+			c.forgetRead(change.Path)
+		} else {
+			c.recordRead(change.Path, change.After)
 		}
 	}
-	return true
+	c.commitEdits(prompt, data.MarkdownSummary, paths)
+	return len(accepted), true
 }
 
-func (c *CoreConfig) DetermineCodeChanges(prompt, personaInstructions, modelID, glob string) *CodeModelResponse {
+func (c *CoreConfig) DetermineCodeChanges(vars prompts.Vars, modelID, glob string) *CodeModelResponse {
 	ctx := context.Background()
+	c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventRequest, Model: modelID, Prompt: vars.Persona + vars.Prompt})
 
-	promptToSendBedrock, err := c.enrichWithGlob(glob)
-	if err != nil {
+	if err := c.addGlobFiles(&vars, glob); err != nil {
 		return nil
 	}
-	promptToSendBedrock = c.addLogContextToPrompt(fmt.Sprintf("%s\n%s\n%s", promptToSendBedrock, prompt, CoderPromptPostProcess))
-
-	req := aws.BedrockRequest{
-		Messages: []aws.BedrockMessage{
-			{
-				Role: "user",
-				Content: []aws.BedrockContent{
-					{
-						Type: "text",
-						Text: promptToSendBedrock,
-					},
-				},
-			},
-		},
-	}
-
-	respBody, err := c.awsImpl.CallAWSBedrockConverse(ctx, modelID, req, coderToolConfig)
+	prompt, err := c.render(prompts.Coder, vars)
 	if err != nil {
-		pterm.Error.Printf("converse error: %v\n", err)
 		return nil
 	}
-	c.logger.LogMessage("[RESPONSE FOR CODE] \n " + string(respBody) + "\n\n")
-
-	data, err := ExtractResponse(
-		respBody,
-		UnwrapFunc[CodeModelResponse, CodeModelResponseWithParameters](),
-	)
+	promptToSendBedrock, err := c.addLogContextToPrompt(prompt)
 	if err != nil {
-		pterm.Error.Printf("unable to ExtractResponse (code): %v\n", err)
 		return nil
 	}
 
-	c.logger.LogMessage("[RESPONSE] \n " + data.MarkdownSummary + "\n\n")
-	c.awsImpl.PrintBedrockMessage(data.MarkdownSummary)
+	req := llm.NewUserRequest(promptToSendBedrock)
+	var data *CodeModelResponse
+	for attempt := 1; ; attempt++ {
+		c.printEstimatedContext(modelID, messagesText(req.Messages)...)
+		respBody, err := c.redactedLLM().StructuredOutput(ctx, modelID, req, coderToolSpec)
+		if err != nil {
+			pterm.Error.Printf("structured output error: %v\n", err)
+			return nil
+		}
+		c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventCodeResponse, Model: modelID, Response: string(respBody)})
+
+		var problems []string
+		data, problems = c.parseCodeResponse(respBody)
+		if len(problems) == 0 {
+			break
+		}
+		feedback := "- " + strings.Join(problems, "\n- ")
+		c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventValidation, Model: modelID, Message: feedback})
+		if attempt >= maxStructuredOutputAttempts {
+			pterm.Error.Printfln("code response failed validation after %d attempts:\n%s", attempt, feedback)
+			return nil
+		}
+		pterm.Warning.Printfln("code response failed validation (attempt %d/%d), asking for a correction:\n%s",
+			attempt, maxStructuredOutputAttempts, feedback)
+		req.Messages = append(req.Messages,
+			llm.Message{Role: "assistant", Content: string(respBody)},
+			llm.Message{Role: "user", Content: fmt.Sprintf(CorrectionPrompt, feedback)},
+		)
+	}
+
+	c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventResponse, Model: modelID, Response: data.MarkdownSummary})
+	c.llmImpl.PrintMessage(data.MarkdownSummary)
 	return data
 
 }
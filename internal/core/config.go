@@ -5,13 +5,14 @@ import (
 
 	"github.com/pterm/pterm"
 
-	"github.com/madhuravius/brains/internal/aws"
 	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
 	"github.com/madhuravius/brains/internal/tools/browser"
 	"github.com/madhuravius/brains/internal/tools/file_system"
+	"github.com/madhuravius/brains/internal/tools/git"
 )
 
-func NewCoreConfig(awsConfig aws.AWSImpl, brainsConfig brainsConfig.BrainsConfigImpl) CoreImpl {
+func NewCoreConfig(llmImpl llm.LLMImpl, brainsConfig brainsConfig.BrainsConfigImpl) CoreImpl {
 	fsToolConfig, err := file_system.NewFileSystemConfig()
 	if err != nil {
 		pterm.Error.Printf("Failed to load fs tool configuration: %v\n", err)
@@ -22,15 +23,25 @@ func NewCoreConfig(awsConfig aws.AWSImpl, brainsConfig brainsConfig.BrainsConfig
 		pterm.Error.Printf("Failed to load browser tool configuration: %v\n", err)
 		os.Exit(1)
 	}
+	fsToolConfig.SetDenyPatterns(brainsConfig.GetConfig().DenyFiles())
 	return &CoreConfig{
 		brainsConfig: brainsConfig,
 		toolsConfig: &toolsConfig{
 			fsToolConfig:      fsToolConfig,
 			browserToolConfig: browserToolConfig,
+			gitToolConfig:     git.NewGitConfig(""),
 		},
-		awsImpl: awsConfig,
+		editedPaths: map[string]bool{},
+		dirtyPaths:  map[string]bool{},
+		llmImpl:     llmImpl,
 	}
 }
-func (c *CoreConfig) GetAWSConfig() aws.AWSImpl             { return c.awsImpl }
-func (c *CoreConfig) SetAWSConfig(a aws.AWSImpl)            { c.awsImpl = a }
+func (c *CoreConfig) GetLLM() llm.LLMImpl                   { return c.llmImpl }
+func (c *CoreConfig) SetLLM(l llm.LLMImpl)                  { c.llmImpl = l }
 func (c *CoreConfig) SetLogger(l brainsConfig.SimpleLogger) { c.logger = l }
+
+// redactedLLM is the LLM every prompt goes through, secrets are replaced with placeholders on the way out and
+// restored in the answers
+func (c *CoreConfig) redactedLLM() llm.LLMImpl {
+	return llm.Redacting(c.llmImpl, c.brainsConfig.GetConfig().Redactor())
+}
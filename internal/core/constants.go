@@ -1,175 +1,169 @@
 package core
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
-	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/madhuravius/brains/internal/llm"
 )
 
-const HealthCheck = `"This is a health check via API call to make sure a connection to this LLM is established. Please reply with a short three to five word affirmation if you are able to interpret this message that the health check is successful.`
+const ResearchSystemPrompt = `
+You are a code assistant gathering context for a parent prompt that will be answered after you finish.
 
-const LogSummary = `
-You are a specialized log summarizer for LLM preprocessing.
-
-Parent prompt:
-%s
+You must:
+- Use the tools to read only the files, symbols and urls the parent prompt directly depends on.
+- Prefer repo_map_search and glob to locate code before reading whole files.
+- Avoid redundant or speculative research outside the task scope.
+- Stop calling tools as soon as you have enough context.
 
-Logs:
-%s
+When done, reply without calling a tool with a short markdown summary of what you found and why it is relevant.
+Do NOT answer the parent prompt itself.
+`
 
-Instructions:
-Summarize and condense these logs, keeping only the parts relevant to the parent prompt.
-Focus on errors, decisions, state transitions, or any parts directly connected to the parent prompt topic.
-Output a short structured summary.`
+const (
+	toolReadFile      = "read_file"
+	toolListFiles     = "list_files"
+	toolRepoMapSearch = "repo_map_search"
+	toolFetchURL      = "fetch_url"
+	toolGlob          = "glob"
+)
 
-const GeneralResearchActivities = `
-You are a code assistant.
+// maxToolResultChars truncates tool results so a single large file cannot exhaust the context
+const maxToolResultChars = 20000
 
-Your sole purpose is to support the parent prompt by gathering *only the minimal additional information necessary* to complete its specific task accurately.
+// maxCommandOutputChars keeps the end of a failing post edit command's output, where errors are usually summed up
+const maxCommandOutputChars = 20000
 
-You must:
-- Suggest fetching urls if recommended and may help provide answers later.
-- Strictly limit all research and retrieval to the direct requirements of the parent task.
-- Avoid redundant or speculative research outside the task scope.
-- If you need data from files and the associated tags, specify which files you wish to have access to.
-- Include a short markdown summary of why you are asking for details.
-
-Return **only JSON**, with the following exact schema:
-{
-  "markdown_summary": "string",
-  "research_actions": {
-    "urls_recommended": ["url"],
-    "files_requested": ["./file/path.ext"]
-  }
-}
+// repoMapSearchLimit bounds the number of matches returned by repo_map_search
+const repoMapSearchLimit = 50
 
-- "markdown_summary": a short summary of why you are requesting data
-- "research_actions": a possible collection of activities (ex: urls_recommended) to act on
+// LogSummaryCachePath keeps the summaries of log chunks and the rolling summaries of each session's log
+const LogSummaryCachePath = "./.brains/log_summaries.json"
 
-Do NOT include extra text or commentary. Only return JSON.
-Analyze the code changes and generate the JSON accordingly.
-`
+// maxCachedSummaries bounds each map of LogSummaryCachePath, the least recently used summaries are dropped first
+const maxCachedSummaries = 500
 
-var researcherToolConfig = &types.ToolConfiguration{
-	Tools: []types.Tool{
-		&types.ToolMemberToolSpec{
-			Value: types.ToolSpecification{
-				Name:        aws.String("data_extractor"),
-				Description: aws.String("Generate code in a specific schema"),
-				InputSchema: &types.ToolInputSchemaMemberJson{
-					Value: document.NewLazyDocument(map[string]any{
-						"type": "object",
-						"properties": map[string]any{
-							"markdown_summary": map[string]any{
-								"type": "string",
-							},
-							"research_actions": map[string]any{
-								"type": "object",
-								"properties": map[string]any{
-									"urls_recommended": map[string]any{
-										"type":        "array",
-										"items":       map[string]any{"type": "string"},
-										"description": "List of URLs that should be researched to supplement the parent prompt.",
-									},
-									"files_requested": map[string]any{
-										"type":        "array",
-										"items":       map[string]any{"type": "string"},
-										"description": "List of files with their content that should be read to supplement the parent prompt.",
-									},
-								},
-								"required": []string{"urls_recommended", "files_requested"},
-							},
-						},
-						"required": []string{"markdown_summary", "research_actions"},
-					}),
-				},
+var researchToolSpecs = []llm.ToolSpec{
+	{
+		Name:        toolReadFile,
+		Description: "Read the contents of a file in the repository.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{"type": "string", "description": "Path relative to the repository root."},
 			},
+			"required": []string{"path"},
 		},
 	},
-	ToolChoice: &types.ToolChoiceMemberAny{
-		Value: types.AnyToolChoice{},
+	{
+		Name:        toolListFiles,
+		Description: "List the files and directories under a directory of the repository.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{"type": "string", "description": "Directory relative to the repository root, defaults to the root."},
+			},
+		},
+	},
+	{
+		Name:        toolRepoMapSearch,
+		Description: "Search file paths and symbol names (functions, methods, types) in the repository, case insensitive.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string", "description": "Substring of a file path or symbol name."},
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        toolFetchURL,
+		Description: "Fetch a web page and return its text content.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url": map[string]any{"type": "string"},
+			},
+			"required": []string{"url"},
+		},
+	},
+	{
+		Name:        toolGlob,
+		Description: "List repository files matching a glob pattern (ex: internal/**/*.go).",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"pattern": map[string]any{"type": "string"},
+			},
+			"required": []string{"pattern"},
+		},
 	},
 }
 
-const CoderPromptPostProcess = `
-You are a code-editing assistant.
-
-Ensure that when outputting the file with changes, you absolutely include the full file output instead of snipping sections and adding comments such as "// (rest of the code remains unchanged)" as that can cause the code to be overwritten instead of skipped.
-
-Return **only JSON** describing the changes, no explanations. 
-
-Return JSON in this format, strictly with the following schema:
-{
-  "markdown_summary": "string",
-  "code_updates": [
-    {"path": "string", "old_code": "string", "new_code": "string"}
-  ],
-  "add_code_files": [
-    {"path": "string", "content": "string"}
-  ],
-  "remove_code_files": [
-    {"path": "string"}
-  ]
-}
+const CorrectionPrompt = `
+Your previous response could not be applied, it failed validation with the following problems:
+%s
 
-Do NOT include extra text or commentary. Only return JSON.
-Analyze the code changes and generate the JSON accordingly.
+Return the complete corrected response through the tool, fixing every problem listed above.
 `
 
-var coderToolConfig = &types.ToolConfiguration{
-	Tools: []types.Tool{
-		&types.ToolMemberToolSpec{
-			Value: types.ToolSpecification{
-				Name:        aws.String("coder"),
-				Description: aws.String("Generate code changes in a specific schema"),
-				InputSchema: &types.ToolInputSchemaMemberJson{
-					Value: document.NewLazyDocument(map[string]any{
-						"type": "object",
-						"properties": map[string]any{
-							"markdown_summary": map[string]any{
-								"type": "string",
-							},
-							"code_updates": map[string]any{
-								"type": "array",
-								"items": map[string]any{
-									"type": "object",
-									"properties": map[string]any{
-										"path":     map[string]any{"type": "string"},
-										"old_code": map[string]any{"type": "string"},
-										"new_code": map[string]any{"type": "string"},
-									},
-									"required": []string{"path", "old_code", "new_code"},
-								},
-							},
-							"add_code_files": map[string]any{
-								"type": "array",
-								"items": map[string]any{
-									"type": "object",
-									"properties": map[string]any{
-										"path":    map[string]any{"type": "string"},
-										"content": map[string]any{"type": "string"},
-									},
-									"required": []string{"path", "content"},
-								},
-							},
-							"remove_code_files": map[string]any{
-								"type": "array",
-								"items": map[string]any{
-									"type": "object",
-									"properties": map[string]any{
-										"path": map[string]any{"type": "string"},
-									},
-									"required": []string{"path"},
-								},
-							},
-						},
-						"required": []string{"markdown_summary", "code_updates", "add_code_files", "remove_code_files"},
-					}),
+// maxCommitSubjectLength keeps the subject of the commits of git.auto_commit short enough for git log --oneline
+const maxCommitSubjectLength = 72
+
+// maxStructuredOutputAttempts bounds the number of times a structured output is requested, including corrections
+const maxStructuredOutputAttempts = 3
+
+var coderToolSpec = llm.ToolSpec{
+	Name:        "coder",
+	Description: "Generate code changes in a specific schema",
+	InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"markdown_summary": map[string]any{
+				"type": "string",
+			},
+			"code_updates": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path":     map[string]any{"type": "string"},
+						"old_code": map[string]any{"type": "string"},
+						"new_code": map[string]any{"type": "string"},
+					},
+					"required": []string{"path", "old_code", "new_code"},
+				},
+			},
+			"replace_files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path":    map[string]any{"type": "string"},
+						"content": map[string]any{"type": "string"},
+					},
+					"required": []string{"path", "content"},
+				},
+			},
+			"add_code_files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path":    map[string]any{"type": "string"},
+						"content": map[string]any{"type": "string"},
+					},
+					"required": []string{"path", "content"},
+				},
+			},
+			"remove_code_files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path": map[string]any{"type": "string"},
+					},
+					"required": []string{"path"},
 				},
 			},
 		},
-	},
-	ToolChoice: &types.ToolChoiceMemberAny{
-		Value: types.AnyToolChoice{},
+		"required": []string{"markdown_summary", "code_updates", "add_code_files", "remove_code_files"},
 	},
 }
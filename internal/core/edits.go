@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+// recordRead keeps a hash of the contents path was read into the context with
+func (c *CoreConfig) recordRead(path, contents string) {
+	if c.readHashes == nil {
+		c.readHashes = map[string]string{}
+	}
+	c.readHashes[filepath.Clean(path)] = hashText(contents)
+}
+
+// forgetRead drops the hash of a removed path
+func (c *CoreConfig) forgetRead(path string) {
+	delete(c.readHashes, filepath.Clean(path))
+}
+
+// changedSinceRead reports whether contents differ from what was read into the context for path, files that were
+// never read have nothing to compare with
+func (c *CoreConfig) changedSinceRead(path, contents string) bool {
+	hash, ok := c.readHashes[filepath.Clean(path)]
+	return ok && hash != hashText(contents)
+}
+
+// planChanges turns data into the changes of a transaction. replace_files and code_updates are applied in memory
+// to the files on disk, in the order the files are first edited, followed by add_code_files and remove_code_files.
+// Edits that can't be placed are returned as conflicts and their file is left out.
+func (c *CoreConfig) planChanges(data *CodeModelResponse) ([]plannedChange, []string) {
+	var updates []*plannedChange
+	var conflicts []string
+	byPath := map[string]*plannedChange{}
+	conflicted := map[string]bool{}
+
+	load := func(field, path string) *plannedChange {
+		if problem := checkEditPath(field, path); problem != "" {
+			conflicts = append(conflicts, problem)
+			return nil
+		}
+		if update, ok := byPath[path]; ok {
+			return update
+		}
+		fsTool := c.toolsConfig.fsToolConfig
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s does not exist, use add_code_files for new files", field, path))
+			return nil
+		case err == nil && info.IsDir():
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s is a directory", field, path))
+			return nil
+		case err == nil && (fsTool.IsIgnored(path) || fsTool.IsDenied(path)):
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s is ignored or on the redaction deny list, it is never read or edited", field, path))
+			return nil
+		}
+		contents, err := os.ReadFile(path) // #nosec G304 -- checked to be inside the repository
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s cannot be read: %v", field, path, err))
+			return nil
+		}
+		if c.changedSinceRead(path, string(contents)) {
+			if !conflicted[path] {
+				conflicts = append(conflicts, fmt.Sprintf("%s.path: %s changed on disk since it was read, it is left as it is", field, path))
+			}
+			conflicted[path] = true
+			return nil
+		}
+		update := &plannedChange{Change: file_system.Change{Op: file_system.OpUpdate, Path: path, Before: string(contents), After: string(contents)}}
+		byPath[path] = update
+		updates = append(updates, update)
+		return update
+	}
+
+	for idx, replace := range data.ReplaceFiles {
+		field := fmt.Sprintf("$.replace_files[%d]", idx)
+		if slices.ContainsFunc(data.CodeUpdates, func(u CodeUpdate) bool { return u.Path == replace.Path }) {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s is also edited by code_updates, put every change in the replacement", field, replace.Path))
+			conflicted[replace.Path] = true
+			continue
+		}
+		if update := load(field, replace.Path); update != nil {
+			update.After = replace.Content
+		}
+	}
+
+	for idx, edit := range data.CodeUpdates {
+		field := fmt.Sprintf("$.code_updates[%d]", idx)
+		update := load(field, edit.Path)
+		if update == nil || conflicted[edit.Path] {
+			continue
+		}
+		after, match, err := file_system.ApplyEdit(update.After, edit.OldCode, edit.NewCode)
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s.old_code: %v (%s)", field, err, edit.Path))
+			conflicted[edit.Path] = true
+			continue
+		}
+		update.After = after
+		if match != file_system.MatchExact {
+			update.Notes = append(update.Notes, fmt.Sprintf("%s.old_code is not exactly in %s, applied with a %s match", field, edit.Path, match))
+		}
+	}
+
+	planned := make([]plannedChange, 0, len(updates)+len(data.AddCodeFiles)+len(data.RemoveCodeFiles))
+	for _, update := range updates {
+		if !conflicted[update.Path] && update.Before != update.After {
+			planned = append(planned, *update)
+		}
+	}
+	for idx, add := range data.AddCodeFiles {
+		field := fmt.Sprintf("$.add_code_files[%d]", idx)
+		if problem := checkEditPath(field, add.Path); problem != "" {
+			conflicts = append(conflicts, problem)
+			continue
+		}
+		if _, err := os.Stat(add.Path); err == nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s already exists, use code_updates or replace_files to change it", field, add.Path))
+			continue
+		}
+		planned = append(planned, plannedChange{Change: file_system.Change{Op: file_system.OpAdd, Path: add.Path, After: add.Content}})
+	}
+	for idx, rem := range data.RemoveCodeFiles {
+		field := fmt.Sprintf("$.remove_code_files[%d]", idx)
+		if problem := checkEditPath(field, rem.Path); problem != "" {
+			conflicts = append(conflicts, problem)
+			continue
+		}
+		if _, ok := byPath[rem.Path]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s is also edited, either edit or remove it", field, rem.Path))
+			continue
+		}
+		contents, err := os.ReadFile(rem.Path)
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s does not exist", field, rem.Path))
+			continue
+		}
+		if c.changedSinceRead(rem.Path, string(contents)) {
+			conflicts = append(conflicts, fmt.Sprintf("%s.path: %s changed on disk since it was read, it is left as it is", field, rem.Path))
+			continue
+		}
+		planned = append(planned, plannedChange{Change: file_system.Change{Op: file_system.OpRemove, Path: rem.Path, Before: string(contents)}})
+	}
+	return planned, conflicts
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+func TestPlanChangesFileStates(t *testing.T) {
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile(".gitignore", []byte("ignored.txt\n"), 0o600))
+	assert.NoError(t, os.WriteFile("empty.go", nil, 0o600))
+	assert.NoError(t, os.WriteFile("ignored.txt", []byte("secret\n"), 0o600))
+	assert.NoError(t, os.Mkdir("dir", 0o750))
+	fs, err := file_system.NewFileSystemConfig()
+	assert.NoError(t, err)
+	c := &CoreConfig{toolsConfig: &toolsConfig{fsToolConfig: fs}}
+
+	planned, conflicts := c.planChanges(&CodeModelResponse{
+		ReplaceFiles: []ReplaceFile{
+			{Path: "empty.go", Content: "package main\n"},
+			{Path: "ignored.txt", Content: "public\n"},
+			{Path: "missing.go", Content: "package main\n"},
+			{Path: "dir", Content: "package main\n"},
+		},
+	})
+	assert.Len(t, planned, 1)
+	assert.Equal(t, file_system.Change{Op: file_system.OpUpdate, Path: "empty.go", After: "package main\n"}, planned[0].Change)
+	assert.Equal(t, []string{
+		"$.replace_files[1].path: ignored.txt is ignored or on the redaction deny list, it is never read or edited",
+		"$.replace_files[2].path: missing.go does not exist, use add_code_files for new files",
+		"$.replace_files[3].path: dir is a directory",
+	}, conflicts)
+}
+
+func TestPlanChangesRejectsFilesChangedSinceRead(t *testing.T) {
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile("app.go", []byte("a()\n"), 0o600))
+	assert.NoError(t, os.WriteFile("old.go", []byte("b()\n"), 0o600))
+	assert.NoError(t, os.WriteFile("unread.go", []byte("c()\n"), 0o600))
+	fs, err := file_system.NewFileSystemConfig()
+	assert.NoError(t, err)
+	c := &CoreConfig{toolsConfig: &toolsConfig{fsToolConfig: fs}}
+
+	// the files go into the context, then the user edits them before the response is applied
+	_, err = c.enrichWithGlob("*.go")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile("app.go", []byte("a()\nmine()\n"), 0o600))
+	assert.NoError(t, os.WriteFile("old.go", []byte("b()\nmine()\n"), 0o600))
+
+	planned, conflicts := c.planChanges(&CodeModelResponse{
+		ReplaceFiles:    []ReplaceFile{{Path: "unread.go", Content: "e()\n"}},
+		CodeUpdates:     []CodeUpdate{{Path: "app.go", OldCode: "a()", NewCode: "f()"}},
+		RemoveCodeFiles: []RemoveCodeFile{{Path: "old.go"}},
+	})
+	assert.Equal(t, []string{
+		"$.code_updates[0].path: app.go changed on disk since it was read, it is left as it is",
+		"$.remove_code_files[0].path: old.go changed on disk since it was read, it is left as it is",
+	}, conflicts)
+	assert.Len(t, planned, 1)
+	assert.Equal(t, "unread.go", planned[0].Path)
+}
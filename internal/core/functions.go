@@ -3,11 +3,13 @@ package core
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"unicode/utf8"
 
 	"github.com/pterm/pterm"
 
-	"github.com/madhuravius/brains/internal/aws"
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/prompts"
 	"github.com/madhuravius/brains/internal/tools/repo_map"
 )
 
@@ -33,23 +35,33 @@ func (c *CommonData) SetFileMapData(filePath, fileMapData string) {
 	c.FileMapData[filePath] = fileMapData
 }
 
+func (c *CommonData) SetResearchSummary(summary string) {
+	c.ResearchSummary = summary
+}
+
 func (c *CommonData) SetLogSummaryContext(logSummary string) {
 	c.LogSummaryContext = logSummary
 }
 
-func (c *CommonData) generateInitialContextRun() string {
-	additionalContext := ""
+// promptVars gathers the context collected by the DAG steps into the variables of the prompt templates
+func (c *CommonData) promptVars(req *LLMRequest) prompts.Vars {
+	vars := prompts.Vars{
+		Prompt:     req.Prompt,
+		Persona:    req.PersonaInstructions,
+		RepoMap:    c.RepoMapContext,
+		FileList:   c.FileListContext,
+		LogSummary: c.LogSummaryContext,
+	}
+	if c.ResearchSummary != "" {
+		vars.Research += "----- research summary: \n" + c.ResearchSummary + "\n\n\n" + "------------"
+	}
 	for url, data := range c.ResearchData {
-		additionalContext += "------ scraped content from: " + url + "\n\n\n" + data + "\n\n\n" + "------------"
+		vars.Research += "------ scraped content from: " + url + "\n\n\n" + data + "\n\n\n" + "------------"
 	}
 	for filePath, fileContents := range c.FileMapData {
-		additionalContext += "----- requested file content: " + filePath + "\n\n\n" + fileContents + "\n\n\n" + "------------"
+		vars.Files += "----- requested file content: " + filePath + "\n\n\n" + fileContents + "\n\n\n" + "------------"
 	}
-	if c.FileListContext != "" {
-		additionalContext += "----- requested file list context: \n" + c.FileListContext
-	}
-
-	return additionalContext
+	return vars
 }
 
 func generateResearchRun[T Researchable](
@@ -60,49 +72,20 @@ func generateResearchRun[T Researchable](
 ) commonDataDAGFunction {
 	return func(inputs map[string]string) (string, error) {
 		pterm.Info.Println("starting research operation")
-		researchActions := coreConfig.Research(req.Prompt, req.ModelID, req.Glob)
-
-		for _, url := range researchActions.UrlsRecommended {
-			data, err := coreConfig.toolsConfig.browserToolConfig.FetchWebContext(ctx, url)
-			if err != nil {
-				pterm.Error.Printf("failed to load url: %v\n", err)
-				return "", err
-			}
-			t.SetResearchData(url, data)
-		}
-		pterm.Success.Println("research - #1 - loaded browser-based content if applicable")
-
-		for _, fileRequested := range researchActions.FilesRequested {
-			data, err := coreConfig.toolsConfig.fsToolConfig.GetFileContents(fileRequested)
-			if err != nil {
-				pterm.Warning.Printf("failed to load file contents from file requested (%s): %v\n", fileRequested, err)
-				continue
-			}
-			pterm.Info.Printfln("research - #2 - loaded file: %s", fileRequested)
-			t.SetFileMapData(fileRequested, data)
+		if err := researchLoop(ctx, coreConfig, req, t); err != nil {
+			return "", err
 		}
-		pterm.Success.Println("research - #2 - loaded requested files")
-
 		return "", nil
 	}
 }
 
-func generateLogSummary[T LogSummarizable](coreConfig *CoreConfig, llmRequest *LLMRequest, ctx context.Context, t T) commonDataDAGFunction {
+func generateLogSummary[T LogSummarizable](coreConfig *CoreConfig, ctx context.Context, t T) commonDataDAGFunction {
 	return func(inputs map[string]string) (string, error) {
-		logCtx := coreConfig.logger.GetLogContext()
-
-		logSummary, usage, err := coreConfig.generateBedrockTextResponse(
-			ctx,
-			fmt.Sprintf(LogSummary, llmRequest.Prompt, logCtx),
-			coreConfig.brainsConfig.GetConfig().Model,
-		)
+		logSummary, err := coreConfig.summarizeLogs(ctx, coreConfig.brainsConfig.GetConfig().Model)
 		if err != nil {
 			return "", err
 		}
 
-		coreConfig.awsImpl.PrintCost(usage, coreConfig.brainsConfig.GetConfig().Model)
-		coreConfig.awsImpl.PrintContext(usage, coreConfig.brainsConfig.GetConfig().Model)
-
 		t.SetLogSummaryContext(logSummary)
 
 		pterm.Success.Printfln("log summary successfully constructed")
@@ -145,6 +128,18 @@ func generateRepoMap[T RepoMappable](
 	}
 }
 
+// addGlobFiles adds the contents of the files matching glob to the files of vars
+func (c *CoreConfig) addGlobFiles(vars *prompts.Vars, glob string) error {
+	addedContext, err := c.enrichWithGlob(glob)
+	if err != nil {
+		return err
+	}
+	if addedContext != "" {
+		vars.Files += "----- files added as context: \n" + addedContext + "\n\n\n" + "------------"
+	}
+	return nil
+}
+
 func (c *CoreConfig) enrichWithGlob(glob string) (string, error) {
 	addedContext := ""
 	if glob != "" {
@@ -154,101 +149,67 @@ func (c *CoreConfig) enrichWithGlob(glob string) (string, error) {
 			pterm.Error.Printfln("failed to read glob pattern for context: %v", err)
 			return "", err
 		}
+		// the context is a JSON map of the files to their contents
+		files := map[string]string{}
+		if err := json.Unmarshal([]byte(addedContext), &files); err == nil {
+			for path, contents := range files {
+				c.recordRead(path, contents)
+			}
+		}
 	}
 	return addedContext, nil
 }
 
-func (c *CoreConfig) addLogContextToPrompt(currentPrompt string) string {
+func (c *CoreConfig) addLogContextToPrompt(currentPrompt string) (string, error) {
 	if !c.brainsConfig.GetConfig().ContextConfig.SendLogs {
-		return currentPrompt
+		return currentPrompt, nil
 	}
 
 	if logCtx := c.logger.GetLogContext(); logCtx != "" {
-		currentPrompt += fmt.Sprintf("%s\n%s\n%s", logCtx, currentPrompt, GeneralResearchActivities)
+		activities, err := c.render(prompts.ResearchActivities, prompts.Vars{Prompt: currentPrompt, Logs: logCtx})
+		if err != nil {
+			return "", err
+		}
+		currentPrompt += activities
 	}
-	return currentPrompt
+	return currentPrompt, nil
 }
 
-func (c *CoreConfig) Research(prompt, modelID, glob string) *ResearchActions {
-	ctx := context.Background()
-
-	promptToSendBedrock := ""
-	addedContext, err := c.enrichWithGlob(glob)
-
-	if err != nil {
-		return nil
-	}
-	promptToSendBedrock = c.addLogContextToPrompt(addedContext)
-
-	req := aws.BedrockRequest{
-		Messages: []aws.BedrockMessage{
-			{
-				Role: "user",
-				Content: []aws.BedrockContent{
-					{
-						Type: "text",
-						Text: promptToSendBedrock,
-					},
-				},
-			},
-		},
-	}
-
-	respBody, err := c.awsImpl.CallAWSBedrockConverse(ctx, modelID, req, researcherToolConfig)
-	if err != nil {
-		pterm.Error.Printf("converse error: %v\n", err)
-		return nil
-	}
-	c.logger.LogMessage("[RESPONSE FOR RESEARCH] \n " + string(respBody) + "\n\n")
-
-	data, err := ExtractResponse(
-		respBody,
-		UnwrapFunc[ResearchModelResponse, ResearchModelResponseWithParameters](),
-	)
-	if err != nil {
-		pterm.Error.Printf("unable to ExtractResponse (research): %v\n", err)
-		return nil
+// render executes a prompt template, templates are loaded with their overrides on first use
+func (c *CoreConfig) render(name string, vars prompts.Vars) (string, error) {
+	if c.prompts == nil {
+		templates, err := prompts.Load(c.brainsConfig.GetConfig().PromptsPath())
+		if err != nil {
+			pterm.Error.Printfln("failed to load prompt templates: %v", err)
+			return "", err
+		}
+		c.prompts = templates
 	}
-
-	return &data.ResearchActions
+	return c.prompts.Render(name, vars)
 }
 
 func (c *CoreConfig) ValidateBedrockConfiguration(modelID string) bool {
 	ctx := context.Background()
-	_, usage, err := c.generateBedrockTextResponse(ctx, HealthCheck, modelID)
+	prompt, err := c.render(prompts.HealthCheck, prompts.Vars{})
+	if err != nil {
+		return false
+	}
+	_, usage, err := c.generateBedrockTextResponse(ctx, brainsConfig.EventHealthCheck, prompt, modelID)
 	if err != nil {
 		return false
 	}
 
-	c.awsImpl.PrintCost(usage, modelID)
-	c.awsImpl.PrintContext(usage, modelID)
+	c.llmImpl.PrintCost(usage, modelID)
+	c.llmImpl.PrintContext(usage, modelID)
 
 	return true
 }
 
-func (c *CoreConfig) generateBedrockTextResponse(ctx context.Context, request, modelID string) (response string, usage map[string]any, err error) {
-	simpleReq := aws.BedrockRequest{
-		Messages: []aws.BedrockMessage{
-			{
-				Role: "user",
-				Content: []aws.BedrockContent{
-					{
-						Type: "text",
-						Text: request,
-					},
-				},
-			},
-		},
-	}
-	c.logger.LogMessage("[REQUEST] \n health‑check prompt")
-	respBody, err := c.awsImpl.CallAWSBedrock(ctx, modelID, simpleReq)
+// generateBedrockTextResponse sends request on its own, the answer is logged as an event of eventType
+func (c *CoreConfig) generateBedrockTextResponse(ctx context.Context, eventType brainsConfig.EventType, request, modelID string) (response string, usage map[string]any, err error) {
+	data, err := c.redactedLLM().Chat(ctx, modelID, llm.NewUserRequest(request))
 	if err != nil {
-		pterm.Error.Printf("invokeModel error: %v\n", err)
-		return "", nil, err
-	}
-	var data aws.ChatResponse
-	if err := json.Unmarshal(respBody, &data); err != nil {
-		pterm.Error.Printf("json Unmarshal error (when parsing Bedrock Body): %v\n", err)
+		pterm.Error.Printf("chat error: %v\n", err)
 		return "", nil, err
 	}
 
@@ -257,7 +218,50 @@ func (c *CoreConfig) generateBedrockTextResponse(ctx context.Context, request, m
 		return "", nil, nil
 	}
 
-	c.awsImpl.PrintBedrockMessage(data.Choices[0].Message.Content)
-	c.logger.LogMessage("[RESPONSE] \n " + data.Choices[0].Message.Content)
+	c.printReasoning(data.Choices[0].Message.Reasoning)
+	c.llmImpl.PrintMessage(data.Choices[0].Message.Content)
+	c.logResponse(eventType, modelID, data.Choices[0].Message.Content, data.Usage)
 	return data.Choices[0].Message.Content, data.Usage, nil
 }
+
+// logResponse records an answer of the model with its token usage and cost
+func (c *CoreConfig) logResponse(eventType brainsConfig.EventType, modelID, response string, usage map[string]any) {
+	promptTokens, completionTokens := llm.UsageTokens(usage)
+	cost, _ := c.llmImpl.Cost(usage, modelID)
+	c.logger.LogEvent(brainsConfig.Event{
+		Type:         eventType,
+		Model:        modelID,
+		Response:     response,
+		InputTokens:  promptTokens,
+		OutputTokens: completionTokens,
+		Cost:         cost,
+	})
+}
+
+// printReasoning shows the model's reasoning as configured by reasoning_display, providers already log it
+func (c *CoreConfig) printReasoning(reasoning string) {
+	llm.PrintReasoning(reasoning, c.brainsConfig.GetConfig().ReasoningDisplay)
+}
+
+// truncateHead keeps at most the first n bytes of s, cut before a rune so the text stays valid UTF-8
+func truncateHead(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateTail keeps at most the last n bytes of s, cut before a rune so the text stays valid UTF-8
+func truncateTail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return s[start:]
+}
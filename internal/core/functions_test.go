@@ -3,13 +3,18 @@ package core_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	"net/http"
-	"net/http/httptest"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
+	"atomicgo.dev/keyboard"
+	awsSDK "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -21,15 +26,18 @@ import (
 )
 
 func setupCore(t *testing.T) (core.CoreImpl, *mockBrains.MockInvoker) {
+	return setupCoreWithConfig(t, &brainsConfig.BrainsConfig{})
+}
+
+func setupCoreWithConfig(t *testing.T, brainsCfg *brainsConfig.BrainsConfig) (core.CoreImpl, *mockBrains.MockInvoker) {
 	t.Helper()
 
 	awsCfg := &aws.AWSConfig{}
 	invoker := &mockBrains.MockInvoker{}
 	awsCfg.SetInvoker(invoker)
 	awsCfg.SetLogger(&mockBrains.TestLogger{})
-	brainsCfg := brainsConfig.BrainsConfig{}
 
-	c := core.NewCoreConfig(awsCfg, &brainsCfg)
+	c := core.NewCoreConfig(awsCfg, brainsCfg)
 	c.SetLogger(&mockBrains.TestLogger{})
 
 	return c, invoker
@@ -49,21 +57,18 @@ func captureStdout(fn func()) string {
 	return string(out)
 }
 
-func setupServer() *httptest.Server {
-	html := `<!DOCTYPE html>
-<html>
-<head><title>Test Page</title></head>
-<body>
-    <p>Hello World from Test</p>
-</body>
-</html>`
-
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		_, _ = w.Write([]byte(html))
-	}))
-
-	return srv
+// hasToolResult matches a Converse request whose last message answers a tool call
+func hasToolResult(input *bedrockruntime.ConverseInput) bool {
+	if len(input.Messages) == 0 {
+		return false
+	}
+	last := input.Messages[len(input.Messages)-1]
+	for _, block := range last.Content {
+		if _, ok := block.(*bedrockruntimeTypes.ContentBlockMemberToolResult); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func TestValidateBedrockConfiguration_Success(t *testing.T) {
@@ -104,9 +109,6 @@ func TestValidateBedrockConfiguration_Failure(t *testing.T) {
 }
 
 func TestAskFlow_Success(t *testing.T) {
-	srv := setupServer()
-	defer srv.Close()
-
 	c, inv := setupCore(t)
 
 	inv.
@@ -116,19 +118,30 @@ func TestAskFlow_Success(t *testing.T) {
 				Value: bedrockruntimeTypes.Message{
 					Role: "assistant",
 					Content: []bedrockruntimeTypes.ContentBlock{
-						&bedrockruntimeTypes.ContentBlockMemberText{Value: `{
-                "name": "assistant", 
-                "parameters": {
-                  "markdown_summary": "mock",
-                  "research_actions": { 
-                    "urls_recommended": ["` + srv.URL + `"], 
-                    "files_requested": []
-                  } 
-                }
-              }`},
+						&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+							ToolUseId: awsSDK.String("tooluse_1"),
+							Name:      awsSDK.String("read_file"),
+							Input:     document.NewLazyDocument(map[string]any{"path": "constants.go"}),
+						}},
 					},
 				},
 			},
+			StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		}, nil).
+		Once()
+
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(hasToolResult)).
+		Return(&bedrockruntime.ConverseOutput{
+			Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+				Value: bedrockruntimeTypes.Message{
+					Role: "assistant",
+					Content: []bedrockruntimeTypes.ContentBlock{
+						&bedrockruntimeTypes.ContentBlockMemberText{Value: "constants.go holds the prompts"},
+					},
+				},
+			},
+			StopReason: bedrockruntimeTypes.StopReasonEndTurn,
 		}, nil).
 		Once()
 
@@ -147,6 +160,8 @@ func TestAskFlow_Success(t *testing.T) {
 		}, nil).
 		Once()
 
+	logger := &mockBrains.TestLogger{}
+	c.SetLogger(logger)
 	output := captureStdout(func() {
 		err := c.AskFlow(context.Background(), &core.LLMRequest{
 			Prompt:  "prompt",
@@ -156,13 +171,18 @@ func TestAskFlow_Success(t *testing.T) {
 	})
 
 	assert.Contains(t, output, "mock response")
+	types := []brainsConfig.EventType{}
+	for _, e := range logger.Events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []brainsConfig.EventType{brainsConfig.EventTool, brainsConfig.EventResearch, brainsConfig.EventRequest, brainsConfig.EventResponse}, types)
+	assert.Equal(t, "read_file", logger.Events[0].Tool)
+	assert.Equal(t, "constants.go holds the prompts", logger.Events[1].Response)
+	assert.Equal(t, brainsConfig.Event{Type: brainsConfig.EventResponse, Model: "model", Response: "mock response"}, logger.Last())
 	inv.AssertExpectations(t)
 }
 
 func TestCodeFlow_Success(t *testing.T) {
-	srv := setupServer()
-	defer srv.Close()
-
 	c, inv := setupCore(t)
 
 	inv.
@@ -172,19 +192,30 @@ func TestCodeFlow_Success(t *testing.T) {
 				Value: bedrockruntimeTypes.Message{
 					Role: "assistant",
 					Content: []bedrockruntimeTypes.ContentBlock{
-						&bedrockruntimeTypes.ContentBlockMemberText{Value: `{
-                "name": "assistant", 
-                "parameters": {
-                  "markdown_summary": "mock",
-                  "research_actions": { 
-                    "urls_recommended": ["` + srv.URL + `"], 
-                    "files_requested": []
-                  } 
-                }
-              }`},
+						&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+							ToolUseId: awsSDK.String("tooluse_1"),
+							Name:      awsSDK.String("read_file"),
+							Input:     document.NewLazyDocument(map[string]any{"path": "constants.go"}),
+						}},
 					},
 				},
 			},
+			StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		}, nil).
+		Once()
+
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(hasToolResult)).
+		Return(&bedrockruntime.ConverseOutput{
+			Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+				Value: bedrockruntimeTypes.Message{
+					Role: "assistant",
+					Content: []bedrockruntimeTypes.ContentBlock{
+						&bedrockruntimeTypes.ContentBlockMemberText{Value: "constants.go holds the prompts"},
+					},
+				},
+			},
+			StopReason: bedrockruntimeTypes.StopReasonEndTurn,
 		}, nil).
 		Once()
 
@@ -222,13 +253,453 @@ func TestCodeFlow_Success(t *testing.T) {
 	inv.AssertExpectations(t)
 }
 
-func TestCore_AWSConfig_GetterSetter(t *testing.T) {
+func TestAskFlow_ResearchStepBudget(t *testing.T) {
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{
+		Research: brainsConfig.ResearchConfig{MaxSteps: 1},
+	})
+
+	inv.
+		On("ConverseModel", mock.Anything, mock.Anything).
+		Return(&bedrockruntime.ConverseOutput{
+			Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+				Value: bedrockruntimeTypes.Message{
+					Role: "assistant",
+					Content: []bedrockruntimeTypes.ContentBlock{
+						&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+							ToolUseId: awsSDK.String("tooluse_1"),
+							Name:      awsSDK.String("glob"),
+							Input:     document.NewLazyDocument(map[string]any{"pattern": "*.go"}),
+						}},
+					},
+				},
+			},
+			StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		}, nil).
+		Once()
+
+	inv.
+		On("InvokeModel", mock.Anything, mock.Anything).
+		Return(&bedrockruntime.InvokeModelOutput{
+			Body: []byte(`{"choices": [{"message": {"role": "assistant", "content": "mock response"}}], "usage": {}}`),
+		}, nil).
+		Once()
+
+	output := captureStdout(func() {
+		err := c.AskFlow(context.Background(), &core.LLMRequest{
+			Prompt:  "prompt",
+			ModelID: "model",
+		})
+		assert.NoError(t, err)
+	})
+
+	assert.Contains(t, output, "mock response")
+	inv.AssertNumberOfCalls(t, "ConverseModel", 1)
+	inv.AssertExpectations(t)
+}
+
+func TestAskFlow_ResearchPersonaTools(t *testing.T) {
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{
+		Research: brainsConfig.ResearchConfig{MaxSteps: 2},
+	})
+
+	offersOnlyReadFile := func(input *bedrockruntime.ConverseInput) bool {
+		return input.ToolConfig != nil && len(input.ToolConfig.Tools) == 1 &&
+			awsSDK.ToString(input.ToolConfig.Tools[0].(*bedrockruntimeTypes.ToolMemberToolSpec).Value.Name) == "read_file"
+	}
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(input *bedrockruntime.ConverseInput) bool {
+			return offersOnlyReadFile(input) && !hasToolResult(input)
+		})).
+		Return(&bedrockruntime.ConverseOutput{
+			Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+				Value: bedrockruntimeTypes.Message{
+					Role: "assistant",
+					Content: []bedrockruntimeTypes.ContentBlock{
+						&bedrockruntimeTypes.ContentBlockMemberToolUse{Value: bedrockruntimeTypes.ToolUseBlock{
+							ToolUseId: awsSDK.String("tooluse_1"),
+							Name:      awsSDK.String("glob"),
+							Input:     document.NewLazyDocument(map[string]any{"pattern": "*.go"}),
+						}},
+					},
+				},
+			},
+			StopReason: bedrockruntimeTypes.StopReasonToolUse,
+		}, nil).
+		Once()
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(input *bedrockruntime.ConverseInput) bool {
+			if !offersOnlyReadFile(input) || !hasToolResult(input) {
+				return false
+			}
+			last := input.Messages[len(input.Messages)-1]
+			result := last.Content[0].(*bedrockruntimeTypes.ContentBlockMemberToolResult).Value
+			text := result.Content[0].(*bedrockruntimeTypes.ToolResultContentBlockMemberText).Value
+			return result.Status == bedrockruntimeTypes.ToolResultStatusError && text == "tool glob is not available to this persona"
+		})).
+		Return(coderOutput("nothing else to look at"), nil).
+		Once()
+	inv.
+		On("InvokeModel", mock.Anything, mock.Anything).
+		Return(&bedrockruntime.InvokeModelOutput{
+			Body: []byte(`{"choices": [{"message": {"role": "assistant", "content": "mock response"}}], "usage": {}}`),
+		}, nil).
+		Once()
+
+	_ = captureStdout(func() {
+		err := c.AskFlow(context.Background(), &core.LLMRequest{
+			Prompt:  "prompt",
+			ModelID: "model",
+			Tools:   []string{"read_file"},
+		})
+		assert.NoError(t, err)
+	})
+	inv.AssertExpectations(t)
+}
+
+func coderOutput(body string) *bedrockruntime.ConverseOutput {
+	return &bedrockruntime.ConverseOutput{
+		Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+			Value: bedrockruntimeTypes.Message{
+				Role:    "assistant",
+				Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: body}},
+			},
+		},
+		StopReason: bedrockruntimeTypes.StopReasonEndTurn,
+	}
+}
+
+func TestCodeFlow_CorrectsInvalidResponse(t *testing.T) {
+	c, inv := setupCore(t)
+
+	// research ends straight away
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 })).
+		Return(coderOutput("nothing to research"), nil).
+		Once()
+
+	// the first answer edits a file that does not exist and misses a required field
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.Messages) == 1 })).
+		Return(coderOutput(`{
+			"markdown_summary": "first try",
+			"code_updates": [{"path": "missing.go", "old_code": "a", "new_code": "b"}],
+			"add_code_files": []
+		}`), nil).
+		Once()
+
+	var correction string
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+			if len(in.Messages) != 3 {
+				return false
+			}
+			correction = in.Messages[2].Content[0].(*bedrockruntimeTypes.ContentBlockMemberText).Value
+			return true
+		})).
+		Return(coderOutput(`{
+			"markdown_summary": "mock corrected response",
+			"code_updates": [],
+			"add_code_files": [],
+			"remove_code_files": []
+		}`), nil).
+		Once()
+
+	output := captureStdout(func() {
+		err := c.CodeFlow(context.Background(), &core.LLMRequest{
+			Prompt:  "prompt",
+			ModelID: "model",
+		})
+		assert.NoError(t, err)
+	})
+
+	assert.Contains(t, output, "mock corrected response")
+	assert.Contains(t, correction, "$.remove_code_files: required property is missing")
+	inv.AssertExpectations(t)
+
+	// once the schema is satisfied, semantic problems are reported too
+	c, inv = setupCore(t)
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 })).
+		Return(coderOutput("nothing to research"), nil).
+		Once()
+	inv.
+		On("ConverseModel", mock.Anything, mock.Anything).
+		Return(coderOutput(`{
+			"markdown_summary": "never valid",
+			"code_updates": [{"path": "constants.go", "old_code": "not in the file", "new_code": "b"}],
+			"add_code_files": [{"path": "../escape.go", "content": "x"}],
+			"remove_code_files": []
+		}`), nil)
+
+	_ = captureStdout(func() {
+		err := c.CodeFlow(context.Background(), &core.LLMRequest{
+			Prompt:  "prompt",
+			ModelID: "model",
+		})
+		assert.Error(t, err)
+	})
+	inv.AssertExpectations(t)
+	_, err := os.Stat("../escape.go")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCodeFlow_ReportsEditConflicts(t *testing.T) {
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile("app.go", []byte("a()\nb()\na()\n"), 0o600))
+	assert.NoError(t, os.WriteFile("other.go", []byte("c()\n"), 0o600))
+
+	c, inv := setupCore(t)
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 })).
+		Return(coderOutput("nothing to research"), nil).
+		Once()
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.Messages) == 1 })).
+		Return(coderOutput(`{
+			"markdown_summary": "first try",
+			"code_updates": [
+				{"path": "app.go", "old_code": "a()", "new_code": "d()"},
+				{"path": "other.go", "old_code": "c()", "new_code": "e()"}
+			],
+			"replace_files": [{"path": "other.go", "content": "f()\n"}],
+			"add_code_files": [],
+			"remove_code_files": []
+		}`), nil).
+		Once()
+	var correction string
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+			if len(in.Messages) != 3 {
+				return false
+			}
+			correction = in.Messages[2].Content[0].(*bedrockruntimeTypes.ContentBlockMemberText).Value
+			return true
+		})).
+		Return(coderOutput(`{
+			"markdown_summary": "mock corrected response",
+			"code_updates": [],
+			"add_code_files": [],
+			"remove_code_files": []
+		}`), nil).
+		Once()
+
+	_ = captureStdout(func() {
+		assert.NoError(t, c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"}))
+	})
+	assert.Contains(t, correction, "$.replace_files[0].path: other.go is also edited by code_updates")
+	assert.Contains(t, correction, "$.code_updates[0].old_code: old_code matches 2 places, include surrounding lines to make it unique (app.go)")
+	data, err := os.ReadFile("app.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "a()\nb()\na()\n", string(data))
+	inv.AssertExpectations(t)
+}
+
+func TestCodeFlow_RefusesDirtyFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile("app.go", []byte("a()\n"), 0o600))
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "user.name=brains", "-c", "user.email=brains@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{Git: brainsConfig.GitConfig{DirtyFiles: brainsConfig.GitDirtyRefuse}})
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 })).
+		Return(coderOutput("nothing to research"), nil).
+		Once()
+	inv.
+		On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) == 0 })).
+		Return(coderOutput(`{
+			"markdown_summary": "rename a",
+			"code_updates": [{"path": "app.go", "old_code": "a()", "new_code": "b()"}],
+			"add_code_files": [],
+			"remove_code_files": []
+		}`), nil).
+		Once()
+
+	_ = captureStdout(func() {
+		err := c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "rename a", ModelID: "model"})
+		assert.ErrorContains(t, err, "unable to execute edits")
+	})
+	data, err := os.ReadFile("app.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "a()\n", string(data))
+}
+
+// pressKeys answers the prompts of a flow in order, each key press waits for a prompt to read it. The listener of
+// an answered prompt takes a moment to stop and would swallow a key sent right away.
+func pressKeys(keys ...rune) {
+	go func() {
+		for _, key := range keys {
+			_ = keyboard.SimulateKeyPress(key)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+}
+
+func TestCodeFlow_PostEditRepair(t *testing.T) {
+	edit := func(from, to string) *bedrockruntime.ConverseOutput {
+		return coderOutput(fmt.Sprintf(`{
+			"markdown_summary": "mock code response",
+			"code_updates": [{"path": "app.go", "old_code": "%s", "new_code": "%s"}],
+			"add_code_files": [],
+			"remove_code_files": []
+		}`, from, to))
+	}
+	isResearch := func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 }
+	orig, _ := os.Getwd()
+	defer func() { _ = os.Chdir(orig) }()
+	inTempDir := func() {
+		assert.NoError(t, os.Chdir(t.TempDir()))
+		assert.NoError(t, os.WriteFile("app.go", []byte("a()\n"), 0o600))
+	}
+
+	// commands passing on the first edits need no repair
+	inTempDir()
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{PostEditCommands: []string{"exit 0"}})
+	inv.On("ConverseModel", mock.Anything, mock.MatchedBy(isResearch)).Return(coderOutput("nothing to research"), nil).Once()
+	inv.On("ConverseModel", mock.Anything, mock.Anything).Return(edit("a()", "b()"), nil).Once()
+	pressKeys('y')
+	output := mockBrains.CaptureAllOutput(func() {
+		assert.NoError(t, c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"}))
+	})
+	assert.Contains(t, output, "post_edit_commands passed after 0 repair iteration(s)")
+	inv.AssertExpectations(t)
+
+	// a failing command is sent back until max_repair_iterations runs out
+	inTempDir()
+	c, inv = setupCoreWithConfig(t, &brainsConfig.BrainsConfig{
+		PostEditCommands:    []string{"exit 0", "echo broken build >&2; exit 1", "echo never run"},
+		MaxRepairIterations: 1,
+	})
+	inv.On("ConverseModel", mock.Anything, mock.MatchedBy(isResearch)).Return(coderOutput("nothing to research"), nil).Once()
+	var repairPrompt string
+	inv.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool {
+		text := in.Messages[0].Content[0].(*bedrockruntimeTypes.ContentBlockMemberText).Value
+		if !strings.Contains(text, "this command failed") {
+			return false
+		}
+		repairPrompt = text
+		return true
+	})).Return(edit("b()", "c()"), nil).Once()
+	inv.On("ConverseModel", mock.Anything, mock.Anything).Return(edit("a()", "b()"), nil).Once()
+	pressKeys('y', 'y')
+	output = mockBrains.CaptureAllOutput(func() {
+		err := c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"})
+		assert.ErrorContains(t, err, "post_edit_commands still fail after 1 repair iteration(s)")
+	})
+	assert.Contains(t, repairPrompt, "$ echo broken build >&2; exit 1\nbroken build\n\nexit status 1")
+	assert.Contains(t, output, "repair 1")
+	assert.NotContains(t, output, "never run")
+	data, err := os.ReadFile("app.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "c()\n", string(data))
+	inv.AssertExpectations(t)
+
+	// max_repair_iterations: 0 runs the commands without asking for repairs
+	inTempDir()
+	c, inv = setupCoreWithConfig(t, &brainsConfig.BrainsConfig{PostEditCommands: []string{"exit 1"}})
+	inv.On("ConverseModel", mock.Anything, mock.MatchedBy(isResearch)).Return(coderOutput("nothing to research"), nil).Once()
+	inv.On("ConverseModel", mock.Anything, mock.Anything).Return(edit("a()", "b()"), nil).Once()
+	pressKeys('y')
+	_ = mockBrains.CaptureAllOutput(func() {
+		err := c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"})
+		assert.ErrorContains(t, err, "post_edit_commands still fail after 0 repair iteration(s)")
+	})
+	inv.AssertExpectations(t)
+}
+
+func TestCodeFlow_SkipsRepairWhenNothingIsApplied(t *testing.T) {
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile("app.go", []byte("a()\n"), 0o600))
+
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{PostEditCommands: []string{"touch ran.txt; exit 1"}})
+	inv.On("ConverseModel", mock.Anything, mock.MatchedBy(func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 })).
+		Return(coderOutput("nothing to research"), nil).
+		Once()
+	inv.On("ConverseModel", mock.Anything, mock.Anything).Return(coderOutput(`{
+		"markdown_summary": "rename a",
+		"code_updates": [{"path": "app.go", "old_code": "a()", "new_code": "b()"}],
+		"add_code_files": [],
+		"remove_code_files": []
+	}`), nil).Once()
+	// the only change is rejected
+	pressKeys('n')
+	output := mockBrains.CaptureAllOutput(func() {
+		assert.NoError(t, c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "rename a", ModelID: "model"}))
+	})
+	assert.Contains(t, output, "no changes were applied, skipping post_edit_commands")
+	assert.NoFileExists(t, "ran.txt")
+	inv.AssertExpectations(t)
+}
+
+func TestCodeFlow_SkipsRepairWhenHooksAreSkipped(t *testing.T) {
+	rename := coderOutput(`{
+		"markdown_summary": "rename a",
+		"code_updates": [{"path": "app.go", "old_code": "a()", "new_code": "b()"}],
+		"add_code_files": [],
+		"remove_code_files": []
+	}`)
+	isResearch := func(in *bedrockruntime.ConverseInput) bool { return len(in.System) > 0 }
+	orig, _ := os.Getwd()
+	defer func() { _ = os.Chdir(orig) }()
+
+	// hooks disabled with --no-hooks
+	disabledDir := t.TempDir()
+	disabled := &brainsConfig.BrainsConfig{
+		PostEditCommands: []string{"touch ran.txt; exit 1"},
+		Hooks:            brainsConfig.HooksConfig{Disabled: true},
+	}
+	// post_edit_commands set by the repository and never approved
+	untrustedDir := t.TempDir()
+	assert.NoError(t, os.Chdir(untrustedDir))
+	t.Setenv("HOME", t.TempDir())
+	assert.NoError(t, os.WriteFile(".brains.yml", []byte("post_edit_commands: [touch ran.txt; exit 1]\n"), 0o600))
+	untrusted, err := brainsConfig.LoadConfig()
+	assert.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		dir string
+		cfg *brainsConfig.BrainsConfig
+	}{"disabled": {disabledDir, disabled}, "untrusted": {untrustedDir, untrusted.GetConfig()}} {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, os.Chdir(tc.dir))
+			assert.NoError(t, os.WriteFile("app.go", []byte("a()\n"), 0o600))
+			c, inv := setupCoreWithConfig(t, tc.cfg)
+			inv.On("ConverseModel", mock.Anything, mock.MatchedBy(isResearch)).Return(coderOutput("nothing to research"), nil).Once()
+			inv.On("ConverseModel", mock.Anything, mock.Anything).Return(rename, nil).Once()
+			pressKeys('y')
+			_ = mockBrains.CaptureAllOutput(func() {
+				assert.NoError(t, c.CodeFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"}))
+			})
+			data, err := os.ReadFile("app.go")
+			assert.NoError(t, err)
+			assert.Equal(t, "b()\n", string(data))
+			assert.NoFileExists(t, "ran.txt")
+			inv.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCore_LLM_GetterSetter(t *testing.T) {
 	c, _ := setupCore(t)
 
 	newAWS := &aws.AWSConfig{}
-	c.SetAWSConfig(newAWS)
+	c.SetLLM(newAWS)
 
-	got := c.GetAWSConfig()
+	got := c.GetLLM()
 	assert.Equal(t, newAWS, got)
 }
 
@@ -239,3 +710,100 @@ func TestCore_SetLogger(t *testing.T) {
 	// No panic, should assign cleanly
 	c.SetLogger(logger)
 }
+
+func TestCountTokens(t *testing.T) {
+	c, inv := setupCore(t)
+
+	assert.NoError(t, c.CountTokens("*.go", "openai.gpt-oss-120b-1:0"))
+	assert.ErrorContains(t, c.CountTokens("*.nothing", "openai.gpt-oss-120b-1:0"), "no files matched")
+	// counting is offline
+	inv.AssertNotCalled(t, "InvokeModel", mock.Anything, mock.Anything)
+}
+
+// invokeResponse is an InvokeModel answer of a gpt-oss model
+func invokeResponse(content string) *bedrockruntime.InvokeModelOutput {
+	body, _ := json.Marshal(map[string]any{
+		"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": content}}},
+		"usage":   map[string]any{},
+	})
+	return &bedrockruntime.InvokeModelOutput{Body: body}
+}
+
+func invokeBodyContains(text string) any {
+	return mock.MatchedBy(func(input *bedrockruntime.InvokeModelInput) bool {
+		return strings.Contains(string(input.Body), text)
+	})
+}
+
+func TestAskFlow_SummarizesOnlyNewLogEntries(t *testing.T) {
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+
+	c, inv := setupCoreWithConfig(t, &brainsConfig.BrainsConfig{
+		Model: "model",
+		ContextConfig: brainsConfig.ContextConfig{
+			SummarizeLogs:      true,
+			SummaryChunkTokens: 30,
+		},
+	})
+	logger := &mockBrains.TestLogger{Entries: []string{
+		"[2025-01-01T00:00:00Z request]\nrename the config loader and update every caller of it\n\n",
+		"[2025-01-01T00:01:00Z response]\nrenamed the loader in config.go and fixed the callers in main.go\n\n",
+		"[2025-01-01T00:02:00Z post_edit]\ngo test ./... failed with an undefined loader in main_test.go\n\n",
+	}}
+	c.SetLogger(logger)
+
+	inv.
+		On("ConverseModel", mock.Anything, mock.Anything).
+		Return(&bedrockruntime.ConverseOutput{
+			Output: &bedrockruntimeTypes.ConverseOutputMemberMessage{
+				Value: bedrockruntimeTypes.Message{
+					Role:    "assistant",
+					Content: []bedrockruntimeTypes.ContentBlock{&bedrockruntimeTypes.ContentBlockMemberText{Value: "nothing to research"}},
+				},
+			},
+			StopReason: bedrockruntimeTypes.StopReasonEndTurn,
+		}, nil)
+	chunks, merges := 0, 0
+	inv.
+		On("InvokeModel", mock.Anything, invokeBodyContains("this part of the activity log")).
+		Run(func(mock.Arguments) { chunks++ }).
+		Return(invokeResponse("chunk summary"), nil)
+	inv.
+		On("InvokeModel", mock.Anything, invokeBodyContains("Merge these summaries")).
+		Run(func(mock.Arguments) { merges++ }).
+		Return(invokeResponse("merged summary"), nil)
+	var askBodies []string
+	inv.
+		On("InvokeModel", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			askBodies = append(askBodies, string(args.Get(1).(*bedrockruntime.InvokeModelInput).Body))
+		}).
+		Return(invokeResponse("mock response"), nil)
+
+	ask := func() {
+		_ = captureStdout(func() {
+			assert.NoError(t, c.AskFlow(context.Background(), &core.LLMRequest{Prompt: "prompt", ModelID: "model"}))
+		})
+	}
+
+	// each entry is a chunk of its own, their summaries are merged once
+	ask()
+	assert.Equal(t, 3, chunks)
+	assert.Equal(t, 1, merges)
+	assert.Contains(t, askBodies[0], "merged summary")
+	assert.FileExists(t, core.LogSummaryCachePath)
+
+	// without new entries the summary is reused
+	ask()
+	assert.Equal(t, 3, chunks)
+	assert.Equal(t, 1, merges)
+	assert.Contains(t, askBodies[1], "merged summary")
+
+	// a new entry is summarized alone and merged into the previous summary
+	logger.Entries = append(logger.Entries, "[2025-01-01T00:03:00Z response]\nmain_test.go now uses the renamed loader\n\n")
+	ask()
+	assert.Equal(t, 4, chunks)
+	assert.Equal(t, 2, merges)
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/tools/git"
+)
+
+// checkDirtyFiles applies git.dirty_files to the files about to be edited that have uncommitted changes, files
+// edited earlier in the run are expected to be. Dirty files are kept out of the commits of git.auto_commit, they
+// would sweep the changes of the user into them. It returns false when the edits must not be applied.
+func (c *CoreConfig) checkDirtyFiles(changes []plannedChange) bool {
+	gitCfg := c.brainsConfig.GetConfig().Git
+	if (gitCfg.DirtyFiles == brainsConfig.GitDirtyIgnore && !gitCfg.AutoCommit) || len(changes) == 0 || !c.toolsConfig.gitToolConfig.IsRepo() {
+		return true
+	}
+	var paths []string
+	for _, change := range changes {
+		if !c.editedPaths[change.Path] {
+			paths = append(paths, change.Path)
+		}
+	}
+	dirty, err := c.toolsConfig.gitToolConfig.DirtyFiles(paths)
+	if err != nil {
+		pterm.Warning.Printfln("unable to check for uncommitted changes: %v", err)
+		return true
+	}
+	if len(dirty) == 0 {
+		return true
+	}
+	switch {
+	case gitCfg.DirtyFiles == brainsConfig.GitDirtyRefuse:
+		pterm.Error.Printfln("not editing files with uncommitted changes, commit or stash them first: %s", strings.Join(dirty, ", "))
+		return false
+	case gitCfg.AutoCommit:
+		pterm.Warning.Printfln("editing files with uncommitted changes, they are left out of the commit: %s", strings.Join(dirty, ", "))
+	case gitCfg.DirtyFiles != brainsConfig.GitDirtyIgnore:
+		pterm.Warning.Printfln("editing files with uncommitted changes, \"brains undo\" restores them: %s", strings.Join(dirty, ", "))
+	}
+	for _, path := range dirty {
+		c.dirtyPaths[path] = true
+	}
+	return true
+}
+
+// commitEdits commits the applied changes when git.auto_commit is set, on a brains/<slug> branch created on the
+// first commit of the run when git.branch is new. A failed commit leaves the edits in the work tree.
+func (c *CoreConfig) commitEdits(prompt, summary string, paths []string) {
+	cfg := c.brainsConfig.GetConfig()
+	if !cfg.Git.AutoCommit || len(paths) == 0 {
+		return
+	}
+	if !c.toolsConfig.gitToolConfig.IsRepo() {
+		pterm.Warning.Println("git.auto_commit is set but this is not a git repository, the edits were not committed")
+		return
+	}
+	var clean, dirty []string
+	for _, path := range paths {
+		if c.dirtyPaths[path] {
+			dirty = append(dirty, path)
+		} else {
+			clean = append(clean, path)
+		}
+	}
+	if len(dirty) > 0 {
+		pterm.Warning.Printfln("not committing files that had uncommitted changes before the edits: %s", strings.Join(dirty, ", "))
+	}
+	if paths = clean; len(paths) == 0 {
+		return
+	}
+	if cfg.Git.Branch == brainsConfig.GitBranchNew && c.gitBranch == "" {
+		branch, err := c.toolsConfig.gitToolConfig.CreateBranch(git.BranchName(prompt))
+		if err != nil {
+			pterm.Warning.Printfln("unable to create a branch for the edits, they were not committed: %v", err)
+			return
+		}
+		c.gitBranch = branch
+		pterm.Info.Printfln("switched to branch %s", branch)
+	}
+	hash, err := c.toolsConfig.gitToolConfig.Commit(commitMessage(prompt, summary, cfg.RunID()), paths)
+	if err != nil {
+		pterm.Warning.Printfln("unable to commit the edits: %v", err)
+		return
+	}
+	pterm.Success.Printfln("committed the edits as %s", hash)
+}
+
+// commitMessage has the first line of prompt as its subject and the summary of the response as its body
+func commitMessage(prompt, summary, runID string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if runes := []rune(subject); len(runes) > maxCommitSubjectLength {
+		subject = strings.TrimSpace(string(runes[:maxCommitSubjectLength-3])) + "..."
+	}
+	if subject == "" {
+		subject = "Apply brains edits"
+	}
+	message := subject
+	if summary = strings.TrimSpace(summary); summary != "" {
+		message += "\n\n" + summary
+	}
+	return message + fmt.Sprintf("\n\nbrains run %s", runID)
+}
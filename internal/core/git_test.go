@@ -0,0 +1,68 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+	"github.com/madhuravius/brains/internal/tools/file_system"
+	"github.com/madhuravius/brains/internal/tools/git"
+)
+
+func gitOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	return string(out)
+}
+
+func TestAutoCommitLeavesOutDirtyFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(orig) }()
+	assert.NoError(t, os.WriteFile("dirty.go", []byte("a()\n"), 0o600))
+	assert.NoError(t, os.WriteFile("clean.go", []byte("b()\n"), 0o600))
+	t.Setenv("GIT_AUTHOR_NAME", "brains")
+	t.Setenv("GIT_AUTHOR_EMAIL", "brains@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "brains")
+	t.Setenv("GIT_COMMITTER_EMAIL", "brains@example.com")
+	gitOutput(t, "init", "--quiet")
+	gitOutput(t, "add", ".")
+	gitOutput(t, "commit", "--quiet", "-m", "initial")
+	// the user's own change, it must not end up in a commit of brains
+	assert.NoError(t, os.WriteFile("dirty.go", []byte("a()\nmine()\n"), 0o600))
+
+	c := &CoreConfig{
+		brainsConfig: &brainsConfig.BrainsConfig{Git: brainsConfig.GitConfig{
+			DirtyFiles: brainsConfig.GitDirtyWarn,
+			AutoCommit: true,
+			Branch:     brainsConfig.GitBranchCurrent,
+		}},
+		toolsConfig: &toolsConfig{gitToolConfig: git.NewGitConfig("")},
+		editedPaths: map[string]bool{},
+		dirtyPaths:  map[string]bool{},
+	}
+	changes := []plannedChange{
+		{Change: file_system.Change{Op: file_system.OpUpdate, Path: "dirty.go", Before: "a()\nmine()\n", After: "c()\nmine()\n"}},
+		{Change: file_system.Change{Op: file_system.OpUpdate, Path: "clean.go", Before: "b()\n", After: "d()\n"}},
+	}
+
+	output := mockBrains.CaptureAllOutput(func() {
+		assert.True(t, c.checkDirtyFiles(changes))
+		for _, change := range changes {
+			assert.NoError(t, os.WriteFile(change.Path, []byte(change.After), 0o600))
+		}
+		c.commitEdits("edit both files", "summary", []string{"dirty.go", "clean.go"})
+	})
+	assert.Contains(t, output, "editing files with uncommitted changes, they are left out of the commit: dirty.go")
+	assert.Contains(t, output, "not committing files that had uncommitted changes before the edits: dirty.go")
+	assert.Equal(t, "clean.go\n", gitOutput(t, "show", "--name-only", "--format=", "HEAD"))
+	assert.Equal(t, " M dirty.go\n", gitOutput(t, "status", "--porcelain"))
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/prompts"
+	"github.com/madhuravius/brains/internal/tools/tokenizer"
+)
+
+// summarizeLogs returns the rolling summary of the log of the current session. Only the entries logged since the
+// last summary are summarized, in chunks of at most context_config.summary_chunk_tokens, and merged into it. A
+// summary is reused as is until new entries are logged.
+func (c *CoreConfig) summarizeLogs(ctx context.Context, modelID string) (string, error) {
+	entries := c.logger.GetLogEntries()
+	if len(entries) == 0 {
+		return "", nil
+	}
+	budget := c.brainsConfig.GetConfig().ContextConfig.SummaryChunkTokens
+	if budget <= 0 {
+		budget = brainsConfig.DefaultConfig.ContextConfig.SummaryChunkTokens
+	}
+	t := tokenizer.ForModel(modelID)
+	cache := loadLogSummaryCache()
+	now := time.Now().UTC()
+
+	chain := entryChain(modelID, entries)
+	start, summary := 0, ""
+	for i := len(entries) - 1; i >= 0; i-- {
+		if rolling, ok := cache.Rolling[chain[i]]; ok {
+			start, summary = i+1, rolling.Summary
+			rolling.Used = now
+			break
+		}
+	}
+	if start == len(entries) {
+		pterm.Info.Printfln("no new log entries, reusing the log summary")
+		saveLogSummaryCache(cache)
+		return summary, nil
+	}
+	pterm.Info.Printfln("summarizing %d new log entries", len(entries)-start)
+
+	var parts []string
+	if summary != "" {
+		parts = append(parts, summary)
+	}
+	for _, chunk := range packByTokens(t, entries[start:], budget, 1) {
+		chunkSummary, err := c.cachedSummary(ctx, cache, prompts.LogSummary, strings.Join(chunk, ""), modelID)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, chunkSummary)
+	}
+	// every merge holds at least two summaries, so each round has fewer of them until one is left
+	for len(parts) > 1 {
+		var merged []string
+		for _, group := range packByTokens(t, parts, budget, 2) {
+			if len(group) == 1 {
+				merged = append(merged, group[0])
+				continue
+			}
+			groupSummary, err := c.cachedSummary(ctx, cache, prompts.LogSummaryReduce, strings.Join(group, "\n\n"), modelID)
+			if err != nil {
+				return "", err
+			}
+			merged = append(merged, groupSummary)
+		}
+		parts = merged
+	}
+
+	cache.Rolling[chain[len(entries)-1]] = &cachedSummary{Summary: parts[0], Entries: len(entries), Used: now}
+	saveLogSummaryCache(cache)
+	return parts[0], nil
+}
+
+// cachedSummary renders the template with logs and asks the model for it, unless the same prompt was summarized
+// before with modelID
+func (c *CoreConfig) cachedSummary(ctx context.Context, cache *logSummaryCache, name, logs, modelID string) (string, error) {
+	prompt, err := c.render(name, prompts.Vars{Logs: logs})
+	if err != nil {
+		return "", err
+	}
+	key := hashText(modelID, prompt)
+	if cached, ok := cache.Chunks[key]; ok {
+		cached.Used = time.Now().UTC()
+		return cached.Summary, nil
+	}
+
+	summary, usage, err := c.generateBedrockTextResponse(ctx, brainsConfig.EventLogSummary, prompt, modelID)
+	if err != nil {
+		return "", err
+	}
+	c.llmImpl.PrintCost(usage, modelID)
+	c.llmImpl.PrintContext(usage, modelID)
+	cache.Chunks[key] = &cachedSummary{Summary: summary, Used: time.Now().UTC()}
+	return summary, nil
+}
+
+// entryChain hashes the log entries one after the other, chain[i] identifies the first i+1 entries
+func entryChain(modelID string, entries []string) []string {
+	chain := make([]string, len(entries))
+	previous := hashText(modelID)
+	for i, entry := range entries {
+		previous = hashText(previous, entry)
+		chain[i] = previous
+	}
+	return chain
+}
+
+func hashText(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// packByTokens groups consecutive texts while a group stays within budget tokens, a group holds at least minTexts
+// texts when enough are left. Texts over budget on their own are truncated.
+func packByTokens(t tokenizer.Tokenizer, texts []string, budget, minTexts int) [][]string {
+	var groups [][]string
+	var group []string
+	tokens := 0
+	for _, text := range texts {
+		text = truncateTokens(t, text, budget)
+		n := t.Count(text)
+		if len(group) >= minTexts && tokens+n > budget {
+			groups = append(groups, group)
+			group, tokens = nil, 0
+		}
+		group = append(group, text)
+		tokens += n
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// truncateTokens keeps about the first budget tokens of text
+func truncateTokens(t tokenizer.Tokenizer, text string, budget int) string {
+	count := t.Count(text)
+	if count <= budget {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:len(runes)*budget/count]) + "\n[truncated]\n\n"
+}
+
+// loadLogSummaryCache reads LogSummaryCachePath, a missing or unreadable cache starts empty
+func loadLogSummaryCache() *logSummaryCache {
+	cache := &logSummaryCache{}
+	if data, err := os.ReadFile(LogSummaryCachePath); err == nil {
+		_ = json.Unmarshal(data, cache)
+	}
+	if cache.Chunks == nil {
+		cache.Chunks = map[string]*cachedSummary{}
+	}
+	if cache.Rolling == nil {
+		cache.Rolling = map[string]*cachedSummary{}
+	}
+	return cache
+}
+
+// saveLogSummaryCache writes the cache without its least recently used summaries, failing to write it only
+// costs summarizing again
+func saveLogSummaryCache(cache *logSummaryCache) {
+	pruneSummaries(cache.Chunks)
+	pruneSummaries(cache.Rolling)
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(LogSummaryCachePath), 0o750); err != nil {
+		pterm.Warning.Printfln("unable to cache the log summary: %v", err)
+		return
+	}
+	if err := os.WriteFile(LogSummaryCachePath, data, 0o600); err != nil {
+		pterm.Warning.Printfln("unable to cache the log summary: %v", err)
+	}
+}
+
+func pruneSummaries(summaries map[string]*cachedSummary) {
+	if len(summaries) <= maxCachedSummaries {
+		return
+	}
+	keys := make([]string, 0, len(summaries))
+	for key := range summaries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return summaries[keys[i]].Used.Before(summaries[keys[j]].Used) })
+	for _, key := range keys[:len(keys)-maxCachedSummaries] {
+		delete(summaries, key)
+	}
+}
@@ -2,33 +2,48 @@ package core
 
 import (
 	"context"
+	"time"
 
-	awsConfig "github.com/madhuravius/brains/internal/aws"
 	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/prompts"
 	"github.com/madhuravius/brains/internal/tools/browser"
 	"github.com/madhuravius/brains/internal/tools/file_system"
+	"github.com/madhuravius/brains/internal/tools/git"
 )
 
 type CoreImpl interface {
 	AskFlow(ctx context.Context, llmRequest *LLMRequest) error
 	CodeFlow(ctx context.Context, llmRequest *LLMRequest) error
+	CountTokens(glob, modelID string) error
 	ValidateBedrockConfiguration(modelID string) bool
 
 	SetLogger(l brainsConfig.SimpleLogger)
-	GetAWSConfig() awsConfig.AWSImpl
-	SetAWSConfig(a awsConfig.AWSImpl)
+	GetLLM() llm.LLMImpl
+	SetLLM(l llm.LLMImpl)
 }
 
 type toolsConfig struct {
 	browserToolConfig browser.BrowserImpl
 	fsToolConfig      file_system.FileSystemImpl
+	gitToolConfig     git.GitImpl
 }
 
 type CoreConfig struct {
-	awsImpl      awsConfig.AWSImpl
+	llmImpl      llm.LLMImpl
 	brainsConfig brainsConfig.BrainsConfigImpl
 	logger       brainsConfig.SimpleLogger
 	toolsConfig  *toolsConfig
+	// prompts are loaded on first use, see render
+	prompts *prompts.Templates
+	// readHashes hash the contents of the files as they were read into the context, edits are planned against them
+	readHashes map[string]string
+	// editedPaths are the files applied during the run, they are expected to have uncommitted changes
+	editedPaths map[string]bool
+	// dirtyPaths are the files that had uncommitted changes before they were edited, they are never auto committed
+	dirtyPaths map[string]bool
+	// gitBranch is the branch created for the run when git.branch is new
+	gitBranch string
 }
 
 type LLMRequest struct {
@@ -36,10 +51,13 @@ type LLMRequest struct {
 	ModelID             string
 	PersonaInstructions string
 	Prompt              string
+	// Tools limits the research tools offered to the model, all of them are offered when empty
+	Tools []string
 }
 type Researchable interface {
 	SetFileMapData(filePath, filePathData string)
 	SetResearchData(url, data string)
+	SetResearchSummary(summary string)
 }
 type FileListable interface {
 	SetFileListContext(string)
@@ -59,6 +77,7 @@ type CommonData struct {
 	FileListContext   string
 	RepoMapContext    string
 	LogSummaryContext string
+	ResearchSummary   string
 }
 type commonDataDAGFunction func(inputs map[string]string) (string, error)
 
@@ -70,11 +89,21 @@ type askDataDAGFunction func(inputs map[string]string) (string, error)
 type CodeData struct {
 	*CommonData
 	CodeModelResponse *CodeModelResponse
+	// Applied is the number of changes applied by the last round of edits
+	Applied int
+	// Iterations are the rounds of edits and post_edit_commands, the first one holds the initial edits
+	Iterations []RepairIteration
+}
+
+// RepairIteration is a round of edits followed by the post_edit_commands run on them
+type RepairIteration struct {
+	Edits   []string
+	Results []brainsConfig.CommandResult
 }
 type codeDataDAGFunction func(inputs map[string]string) (string, error)
 
-type InitialContextSettable interface {
-	generateInitialContextRun() string
+type PromptVarsSettable interface {
+	promptVars(req *LLMRequest) prompts.Vars
 }
 
 type Hydratable interface {
@@ -85,12 +114,26 @@ type HasParameters[T any] interface {
 	GetParameters() T
 }
 
+// CodeUpdate replaces the anchor OldCode of a file with NewCode, see file_system.ApplyEdit
 type CodeUpdate struct {
 	Path    string `json:"path"`
 	OldCode string `json:"old_code"`
 	NewCode string `json:"new_code"`
 }
 
+// ReplaceFile replaces the whole contents of an existing file
+type ReplaceFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// plannedChange is an operation of a code response ready to be reviewed and applied
+type plannedChange struct {
+	file_system.Change
+	// Notes list the edits whose anchor was not matched exactly
+	Notes []string
+}
+
 type AddCodeFile struct {
 	Path    string `json:"path"`
 	Content string `json:"content"`
@@ -103,6 +146,7 @@ type RemoveCodeFile struct {
 type CodeModelResponse struct {
 	MarkdownSummary string           `json:"markdown_summary"`
 	CodeUpdates     []CodeUpdate     `json:"code_updates"`
+	ReplaceFiles    []ReplaceFile    `json:"replace_files,omitempty"`
 	AddCodeFiles    []AddCodeFile    `json:"add_code_files,omitempty"`
 	RemoveCodeFiles []RemoveCodeFile `json:"remove_code_files,omitempty"`
 }
@@ -112,6 +156,20 @@ type CodeModelResponseWithParameters struct {
 	Parameters CodeModelResponse `json:"parameters"`
 }
 
+// logSummaryCache is the content of LogSummaryCachePath. Chunks are keyed by the hash of the prompt that
+// summarized them, Rolling by the chain hash of the log entries their summary covers.
+type logSummaryCache struct {
+	Chunks  map[string]*cachedSummary `json:"chunks"`
+	Rolling map[string]*cachedSummary `json:"rolling"`
+}
+
+type cachedSummary struct {
+	Summary string `json:"summary"`
+	// Entries is the number of log entries a rolling summary covers
+	Entries int       `json:"entries,omitempty"`
+	Used    time.Time `json:"used"`
+}
+
 type ResearchActions struct {
 	UrlsRecommended []string `json:"urls_recommended"`
 	FilesRequested  []string `json:"files_requested"`
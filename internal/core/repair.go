@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/prompts"
+)
+
+func (c *CodeData) generatePostEditRepairFunction(coreConfig *CoreConfig, req *LLMRequest) codeDataDAGFunction {
+	return func(inputs map[string]string) (string, error) {
+		return "", coreConfig.repairLoop(c, req)
+	}
+}
+
+// repairLoop runs post_edit_commands on the applied edits, a failing command is sent back to the model for
+// another round of edits until the commands pass or max_repair_iterations runs out, 0 only runs the commands.
+// Nothing runs when no changes were applied.
+func (c *CoreConfig) repairLoop(data *CodeData, req *LLMRequest) error {
+	if data.Applied == 0 {
+		pterm.Info.Println("no changes were applied, skipping post_edit_commands")
+		return nil
+	}
+	cfg := c.brainsConfig.GetConfig()
+	maxIterations := cfg.MaxRepairIterations
+	defer func() { printRepairReport(data.Iterations) }()
+
+	response := data.CodeModelResponse
+	for repair := 0; ; repair++ {
+		c.refreshFileMapData(data, response)
+		results := cfg.PostEditCommandsHook()
+		if len(results) == 0 {
+			// the commands were skipped, there is nothing to repair
+			return nil
+		}
+		data.Iterations = append(data.Iterations, RepairIteration{Edits: editSummary(response), Results: results})
+
+		failed := results[len(results)-1]
+		if failed.Err == nil {
+			pterm.Success.Printfln("post_edit_commands passed after %d repair iteration(s)", repair)
+			return nil
+		}
+		output := failed.Output
+		if len(output) > maxCommandOutputChars {
+			tail := truncateTail(output, maxCommandOutputChars)
+			output = fmt.Sprintf("... truncated %d characters\n%s", len(output)-len(tail), tail)
+		}
+		commandOutput := fmt.Sprintf("$ %s\n%s\n%v", failed.Command, output, failed.Err)
+		c.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventPostEdit, Message: commandOutput})
+		if repair >= maxIterations {
+			return fmt.Errorf("post_edit_commands still fail after %d repair iteration(s)", maxIterations)
+		}
+
+		pterm.Info.Printfln("repair iteration %d/%d, sending the output of %s back", repair+1, maxIterations, failed.Command)
+		repairPrompt, err := c.render(prompts.Repair, prompts.Vars{Prompt: req.Prompt, CommandOutput: commandOutput})
+		if err != nil {
+			return err
+		}
+		vars := data.promptVars(req)
+		vars.Prompt = repairPrompt
+		if response = c.DetermineCodeChanges(vars, req.ModelID, req.Glob); response == nil {
+			return fmt.Errorf("unable to determine repair changes")
+		}
+		applied, ok := c.ExecuteEditCode(req.Prompt, response)
+		if !ok {
+			return fmt.Errorf("unable to execute repair edits")
+		}
+		if applied == 0 {
+			return fmt.Errorf("no repair edits were applied, post_edit_commands still fail")
+		}
+		data.CodeModelResponse = response
+		data.Applied = applied
+	}
+}
+
+// refreshFileMapData replaces the contents read during research with the edited files so the next round sees them
+func (c *CoreConfig) refreshFileMapData(data *CodeData, response *CodeModelResponse) {
+	var paths []string
+	for _, update := range response.CodeUpdates {
+		paths = append(paths, update.Path)
+	}
+	for _, replace := range response.ReplaceFiles {
+		paths = append(paths, replace.Path)
+	}
+	for _, add := range response.AddCodeFiles {
+		paths = append(paths, add.Path)
+	}
+	for _, path := range paths {
+		if contents, err := c.toolsConfig.fsToolConfig.GetFileContents(path); err == nil && contents != "" {
+			data.SetFileMapData(path, contents)
+			c.recordRead(path, contents)
+		}
+	}
+	for _, rem := range response.RemoveCodeFiles {
+		delete(data.FileMapData, rem.Path)
+	}
+}
+
+func editSummary(response *CodeModelResponse) []string {
+	var edits []string
+	for _, update := range response.CodeUpdates {
+		edits = append(edits, "update "+update.Path)
+	}
+	for _, replace := range response.ReplaceFiles {
+		edits = append(edits, "replace "+replace.Path)
+	}
+	for _, add := range response.AddCodeFiles {
+		edits = append(edits, "add "+add.Path)
+	}
+	for _, rem := range response.RemoveCodeFiles {
+		edits = append(edits, "remove "+rem.Path)
+	}
+	return edits
+}
+
+// printRepairReport shows the edits of each iteration and the result of the commands run on them
+func printRepairReport(iterations []RepairIteration) {
+	tableData := pterm.TableData{{"Iteration", "Edits", "Command", "Result"}}
+	for idx, iteration := range iterations {
+		name := "initial"
+		if idx > 0 {
+			name = fmt.Sprintf("repair %d", idx)
+		}
+		edits := strings.Join(iteration.Edits, "\n")
+		if edits == "" {
+			edits = "none"
+		}
+		for resultIdx, result := range iteration.Results {
+			status := "passed"
+			if result.Err != nil {
+				status = "failed: " + result.Err.Error()
+			}
+			if resultIdx > 0 {
+				name, edits = "", ""
+			}
+			tableData = append(tableData, []string{name, edits, result.Command, status})
+		}
+	}
+	_ = pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+}
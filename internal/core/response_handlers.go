@@ -13,6 +13,7 @@ func UnwrapFunc[T any, TP interface{ GetParameters() T }]() func(TP) T {
 func (r CodeModelResponse) IsHydrated() bool {
 	return r.MarkdownSummary != "" ||
 		len(r.CodeUpdates) > 0 ||
+		len(r.ReplaceFiles) > 0 ||
 		len(r.AddCodeFiles) > 0 ||
 		len(r.RemoveCodeFiles) > 0
 }
@@ -103,6 +104,29 @@ func ExtractResponse[T Hydratable, TP any](
 	return nil, fmt.Errorf("unrecognized or empty JSON structure")
 }
 
+// ExtractSchemaPayload decodes a structured output into the generic form checked against a tool schema,
+// unwrapping the same array and {"name", "parameters"} shapes accepted by ExtractResponse
+func ExtractSchemaPayload(respBody []byte) (any, error) {
+	var payload any
+	if err := json.Unmarshal(respBody, &payload); err != nil {
+		recovered, extErr := ExtractAnyJSON[any](string(respBody))
+		if extErr != nil {
+			return nil, fmt.Errorf("invalid JSON and no recoverable fragment: %w", err)
+		}
+		payload = *recovered
+	}
+	if arr, ok := payload.([]any); ok && len(arr) > 0 {
+		payload = arr[0]
+	}
+	if obj, ok := payload.(map[string]any); ok {
+		_, hasName := obj["name"].(string)
+		if parameters, ok := obj["parameters"].(map[string]any); ok && hasName {
+			payload = parameters
+		}
+	}
+	return payload, nil
+}
+
 func ExtractAnyJSON[T any](raw string) (*T, error) {
 	raw = strings.TrimSpace(raw)
 
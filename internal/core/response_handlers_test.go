@@ -238,3 +238,20 @@ func TestExtractAnyJSON_MultipleCandidates(t *testing.T) {
 		t.Errorf("expected first valid JSON to be extracted, got %d", out.Val)
 	}
 }
+
+func TestExtractSchemaPayload(t *testing.T) {
+	direct, err := core.ExtractSchemaPayload([]byte(`{"markdown_summary": "a"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"markdown_summary": "a"}, direct)
+
+	wrapped, err := core.ExtractSchemaPayload([]byte(`[{"name": "coder", "parameters": {"markdown_summary": "b"}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"markdown_summary": "b"}, wrapped)
+
+	recovered, err := core.ExtractSchemaPayload([]byte(`here you go {"markdown_summary": "c"} done`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"markdown_summary": "c"}, recovered)
+
+	_, err = core.ExtractSchemaPayload([]byte(`no json here`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	"github.com/madhuravius/brains/internal/llm"
+	"github.com/madhuravius/brains/internal/tools/tokenizer"
+)
+
+// CountTokens estimates offline how many tokens each file matching glob adds to the context of modelID
+func (c *CoreConfig) CountTokens(glob, modelID string) error {
+	files, err := c.toolsConfig.fsToolConfig.Glob(glob)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files matched pattern %s", glob)
+	}
+
+	t := tokenizer.ForModel(modelID)
+	var counts []tokenizer.FileCount
+	total := 0
+	for _, path := range files {
+		tokens, err := tokenizer.CountFile(t, path)
+		if err != nil {
+			pterm.Warning.Printfln("skipping %s: %v", path, err)
+			continue
+		}
+		counts = append(counts, tokenizer.FileCount{Path: path, Tokens: tokens})
+		total += tokens
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].Tokens > counts[j].Tokens })
+
+	tableData := pterm.TableData{{"File", "Tokens"}}
+	for _, count := range counts {
+		tableData = append(tableData, []string{count.Path, fmt.Sprintf("%d", count.Tokens)})
+	}
+	tableData = append(tableData, []string{fmt.Sprintf("Total (%d files)", len(counts)), fmt.Sprintf("%d", total)})
+	if err := pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render(); err != nil {
+		return err
+	}
+	pterm.Info.Printfln("counted with the %s tokenizer for %s, %.1f%% of the %d token limit",
+		t.Name(), modelID, float64(total)*100/float64(llm.TokenLimit), llm.TokenLimit)
+	return nil
+}
+
+// printEstimatedContext reports the offline token estimate of a request before it is sent
+func (c *CoreConfig) printEstimatedContext(modelID string, texts ...string) {
+	tokens := tokenizer.ForModel(modelID).Count(strings.Join(texts, "\n"))
+	c.llmImpl.PrintContext(llm.EstimatedUsage(tokens), modelID)
+}
+
+func messagesText(messages []llm.Message) []string {
+	var texts []string
+	for _, m := range messages {
+		texts = append(texts, m.Content)
+	}
+	return texts
+}
+
+func turnsText(turns []llm.Turn) []string {
+	var texts []string
+	for _, turn := range turns {
+		texts = append(texts, turn.Text)
+		for _, call := range turn.ToolCalls {
+			texts = append(texts, fmt.Sprintf("%s %v", call.Name, call.Input))
+		}
+		for _, result := range turn.ToolResults {
+			texts = append(texts, result.Content)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// parseCodeResponse decodes a coder response and lists every schema and semantic problem found, the
+// problems are sent back to the model so it can correct its answer
+func (c *CoreConfig) parseCodeResponse(respBody []byte) (*CodeModelResponse, []string) {
+	payload, err := ExtractSchemaPayload(respBody)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	if problems := coderToolSpec.Validate(payload); len(problems) > 0 {
+		return nil, problems
+	}
+
+	data, err := ExtractResponse(
+		respBody,
+		UnwrapFunc[CodeModelResponse, CodeModelResponseWithParameters](),
+	)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	if problems := c.validateCodeChanges(data); len(problems) > 0 {
+		return nil, problems
+	}
+	return data, nil
+}
+
+// validateCodeChanges checks the edits can be applied to the working tree as described, anchors of code_updates
+// that are missing or ambiguous are problems
+func (c *CoreConfig) validateCodeChanges(data *CodeModelResponse) []string {
+	_, problems := c.planChanges(data)
+	return problems
+}
+
+func checkEditPath(field, path string) string {
+	if path == "" {
+		return field + ".path: must not be empty"
+	}
+	if !filepath.IsLocal(filepath.Clean(path)) {
+		return fmt.Sprintf("%s.path: %s is outside of the repository", field, path)
+	}
+	return ""
+}
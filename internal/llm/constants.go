@@ -0,0 +1,13 @@
+package llm
+
+// token limit is still a fixed safety bound (128 000)
+const TokenLimit = 128000
+
+// budgets up to these sizes map to the low and medium reasoning_effort, anything larger is high
+const (
+	lowReasoningBudget    = 4096
+	mediumReasoningBudget = 16384
+)
+
+// collapsedReasoningChars is how much of the reasoning is previewed when collapsed
+const collapsedReasoningChars = 100
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/muesli/termenv"
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+)
+
+// reasoningTagPattern matches the reasoning gpt-oss on Bedrock inlines ahead of its answer
+var reasoningTagPattern = regexp.MustCompile(`(?s)^\s*<reasoning>(.*?)</reasoning>\s*`)
+
+// NewUserRequest builds a single-message request, which is how every flow in core talks to a model
+func NewUserRequest(prompt string) ChatRequest {
+	return ChatRequest{Messages: []Message{{Role: "user", Content: prompt}}}
+}
+
+// UsageTokens reads the prompt and completion token counts from a usage map
+func UsageTokens(usage map[string]any) (promptTokens, completionTokens int) {
+	return usageValue(usage, "prompt_tokens"), usageValue(usage, "completion_tokens")
+}
+
+// EstimatedUsage reports an offline token estimate of a prompt that has not been sent yet
+func EstimatedUsage(promptTokens int) map[string]any {
+	return map[string]any{"prompt_tokens": promptTokens, "estimated": true}
+}
+
+// PrintContextUsage reports how much of the context window a request used, or is estimated to use
+func PrintContextUsage(usage map[string]any, modelID string) {
+	promptTokens, completionTokens := UsageTokens(usage)
+	total := promptTokens + completionTokens
+	if estimated, _ := usage["estimated"].(bool); estimated {
+		pterm.Info.Printf("estimated context for this request (%s): %d tokens (limit %d)\n", modelID, total, TokenLimit)
+		if total > TokenLimit {
+			pterm.Warning.Printfln("the request is likely over the context limit, narrow the glob or default_context")
+		}
+		return
+	}
+	pterm.Info.Printf("current context used (%s): %d tokens (limit %d)\n", modelID, total, TokenLimit)
+}
+
+// CacheTokens reads the prompt cache read and write token counts, these are not part of prompt_tokens
+func CacheTokens(usage map[string]any) (cacheReadTokens, cacheWriteTokens int) {
+	return usageValue(usage, "cache_read_tokens"), usageValue(usage, "cache_write_tokens")
+}
+
+// ReasoningTokens reads the reasoning token count, either flat or nested in completion_tokens_details as
+// OpenAI reports it. Reasoning tokens are billed as (and already included in) completion tokens.
+func ReasoningTokens(usage map[string]any) int {
+	if n := usageValue(usage, "reasoning_tokens"); n > 0 {
+		return n
+	}
+	details, _ := usage["completion_tokens_details"].(map[string]any)
+	return usageValue(details, "reasoning_tokens")
+}
+
+// TokenSummary describes the token counts of a usage map for cost and context reporting
+func TokenSummary(usage map[string]any) string {
+	promptTokens, completionTokens := UsageTokens(usage)
+	summary := fmt.Sprintf("prompt %d, completion %d", promptTokens, completionTokens)
+	if reasoningTokens := ReasoningTokens(usage); reasoningTokens > 0 {
+		summary += fmt.Sprintf(" including %d reasoning", reasoningTokens)
+	}
+	if cacheReadTokens, cacheWriteTokens := CacheTokens(usage); cacheReadTokens+cacheWriteTokens > 0 {
+		summary += fmt.Sprintf(", cache read %d, cache write %d", cacheReadTokens, cacheWriteTokens)
+	}
+	return summary
+}
+
+// ReasoningEffort maps a reasoning_budget onto the reasoning_effort levels of OpenAI style models
+func ReasoningEffort(budget int) string {
+	switch {
+	case budget <= lowReasoningBudget:
+		return "low"
+	case budget <= mediumReasoningBudget:
+		return "medium"
+	}
+	return "high"
+}
+
+// SplitReasoning separates inline <reasoning> tags from the answer that follows them
+func SplitReasoning(content string) (reasoning, answer string) {
+	match := reasoningTagPattern.FindStringSubmatchIndex(content)
+	if match == nil {
+		return "", content
+	}
+	return strings.TrimSpace(content[match[2]:match[3]]), content[match[1]:]
+}
+
+// ReasoningText joins the readable text of reasoning blocks, redacted blocks have none
+func ReasoningText(blocks []ReasoningBlock) string {
+	var parts []string
+	for _, block := range blocks {
+		if block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// PrintReasoning shows reasoning according to the reasoning_display setting, nothing is shown when hidden
+func PrintReasoning(reasoning, display string) {
+	reasoning = strings.TrimSpace(reasoning)
+	if reasoning == "" {
+		return
+	}
+	switch display {
+	case brainsConfig.ReasoningDisplayCollapsed:
+		preview := strings.Join(strings.Fields(reasoning), " ")
+		if runes := []rune(preview); len(runes) > collapsedReasoningChars {
+			preview = string(runes[:collapsedReasoningChars]) + "…"
+		}
+		pterm.FgGray.Printfln("▸ reasoning (%d words, see brains logs): %s", len(strings.Fields(reasoning)), preview)
+	case brainsConfig.ReasoningDisplayDimmed:
+		pterm.FgGray.Println("▾ reasoning")
+		pterm.FgGray.Println(reasoning)
+		fmt.Println()
+	}
+}
+
+func usageValue(usage map[string]any, key string) int {
+	switch n := usage[key].(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+func PrintMarkdown(content string) {
+	r, _ := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+		glamour.WithWordWrap(120),
+		glamour.WithColorProfile(termenv.ANSI256),
+	)
+	result, _ := r.Render(content)
+	fmt.Println(result)
+}
@@ -0,0 +1,72 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/llm"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+)
+
+func TestNewUserRequest(t *testing.T) {
+	req := llm.NewUserRequest("hello")
+	assert.Equal(t, []llm.Message{{Role: "user", Content: "hello"}}, req.Messages)
+}
+
+func TestUsageTokens(t *testing.T) {
+	promptTokens, completionTokens := llm.UsageTokens(map[string]any{
+		"prompt_tokens":     float64(12),
+		"completion_tokens": 3,
+	})
+	assert.Equal(t, 12, promptTokens)
+	assert.Equal(t, 3, completionTokens)
+
+	promptTokens, completionTokens = llm.UsageTokens(nil)
+	assert.Zero(t, promptTokens)
+	assert.Zero(t, completionTokens)
+}
+
+func TestReasoningTokens(t *testing.T) {
+	assert.Equal(t, 7, llm.ReasoningTokens(map[string]any{"reasoning_tokens": 7}))
+	assert.Equal(t, 5, llm.ReasoningTokens(map[string]any{
+		"completion_tokens_details": map[string]any{"reasoning_tokens": float64(5)},
+	}))
+	assert.Zero(t, llm.ReasoningTokens(nil))
+
+	assert.Equal(t, "prompt 3, completion 9 including 5 reasoning", llm.TokenSummary(map[string]any{
+		"prompt_tokens":     3,
+		"completion_tokens": 9,
+		"reasoning_tokens":  5,
+	}))
+	assert.Equal(t, "prompt 3, completion 9", llm.TokenSummary(map[string]any{"prompt_tokens": 3, "completion_tokens": 9}))
+}
+
+func TestSplitReasoning(t *testing.T) {
+	reasoning, answer := llm.SplitReasoning("<reasoning>\nthink first\n</reasoning>\n\nthe answer")
+	assert.Equal(t, "think first", reasoning)
+	assert.Equal(t, "the answer", answer)
+
+	reasoning, answer = llm.SplitReasoning("no tags <reasoning>here</reasoning>")
+	assert.Empty(t, reasoning)
+	assert.Equal(t, "no tags <reasoning>here</reasoning>", answer)
+}
+
+func TestReasoningEffort(t *testing.T) {
+	assert.Equal(t, "low", llm.ReasoningEffort(1024))
+	assert.Equal(t, "medium", llm.ReasoningEffort(8000))
+	assert.Equal(t, "high", llm.ReasoningEffort(32000))
+}
+
+func TestPrintContextUsage(t *testing.T) {
+	out := mockBrains.CaptureAllOutput(func() {
+		llm.PrintContextUsage(llm.EstimatedUsage(llm.TokenLimit+1), "model-id")
+	})
+	assert.Contains(t, out, "estimated context for this request (model-id)")
+	assert.Contains(t, out, "over the context limit")
+
+	out = mockBrains.CaptureAllOutput(func() {
+		llm.PrintContextUsage(map[string]any{"prompt_tokens": 3, "completion_tokens": 2}, "model-id")
+	})
+	assert.Contains(t, out, "current context used (model-id): 5 tokens")
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+)
+
+// LLMImpl is the provider-neutral surface that core depends on, every backend
+// (Bedrock, OpenAI-compatible servers) implements it.
+type LLMImpl interface {
+	Chat(ctx context.Context, modelID string, req ChatRequest) (*ChatResponse, error)
+	ChatWithTools(ctx context.Context, modelID string, req ToolRequest) (*ToolResponse, error)
+	StructuredOutput(ctx context.Context, modelID string, req ChatRequest, tool ToolSpec) ([]byte, error)
+	Cost(usage map[string]any, modelID string) (float64, bool)
+	PrintContext(usage map[string]any, modelID string)
+	PrintCost(usage map[string]any, modelID string)
+	PrintMessage(content string)
+	PrintPricing(modelID string) error
+	SetAndValidateCredentials() bool
+	SetLogger(l brainsConfig.SimpleLogger)
+	SetReasoningBudget(budget int)
+	SetTemperature(temperature *float64)
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatRequest struct {
+	Messages []Message
+}
+
+// ToolSpec describes a single tool the model is forced to call, its input
+// schema is the shape of the structured output returned to the caller.
+type ToolSpec struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+}
+
+type ResponseMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Reasoning is the model's thinking ahead of the answer, empty for models that do not reason
+	Reasoning string `json:"reasoning_content,omitempty"`
+}
+
+type ResponseChoice struct {
+	Message ResponseMessage `json:"message"`
+}
+
+// ChatResponse follows the OpenAI chat completions shape, usage keeps the
+// prompt_tokens and completion_tokens keys used for cost reporting.
+type ChatResponse struct {
+	Choices []ResponseChoice `json:"choices"`
+	Usage   map[string]any
+}
+
+// ToolCall is the model asking for one of the tools offered in a ToolRequest to be run
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input map[string]any
+}
+
+// ToolResult answers the ToolCall with the same ID
+type ToolResult struct {
+	ToolCallID string
+	Content    string
+	IsError    bool
+}
+
+// ReasoningBlock is a piece of the model's thinking, Signature and Redacted are opaque and only
+// kept so the block can be sent back unchanged on the next turn (required by Claude during tool use).
+type ReasoningBlock struct {
+	Text      string
+	Signature string
+	Redacted  []byte
+}
+
+// Turn is one message of a tool-use conversation, assistant turns may carry
+// tool calls and the user turn that follows carries their results.
+type Turn struct {
+	Role        string
+	Text        string
+	Reasoning   []ReasoningBlock
+	ToolCalls   []ToolCall
+	ToolResults []ToolResult
+}
+
+// ToolRequest offers tools the model may call any number of times, unlike
+// StructuredOutput the model is free to answer in text instead.
+type ToolRequest struct {
+	System string
+	Turns  []Turn
+	Tools  []ToolSpec
+}
+
+type ToolResponse struct {
+	Turn Turn
+	// Done is set when the model ended its turn without asking for a tool
+	Done  bool
+	Usage map[string]any
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/madhuravius/brains/internal/redact"
+)
+
+// redactingLLM redacts everything sent to the wrapped LLMImpl and restores the placeholders the model answers
+// with, the secrets never leave the machine but edits and answers still hold the real values. Tool calls are not
+// restored, a tool like fetch_url would otherwise send the secrets wherever the model asks.
+type redactingLLM struct {
+	LLMImpl
+	redactor *redact.Redactor
+}
+
+// Redacting wraps l with r, l is returned as is when r is nil (redaction disabled)
+func Redacting(l LLMImpl, r *redact.Redactor) LLMImpl {
+	if r == nil {
+		return l
+	}
+	return &redactingLLM{LLMImpl: l, redactor: r}
+}
+
+func (l *redactingLLM) Chat(ctx context.Context, modelID string, req ChatRequest) (*ChatResponse, error) {
+	resp, err := l.LLMImpl.Chat(ctx, modelID, l.redactChat(req))
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	for i := range resp.Choices {
+		resp.Choices[i].Message.Content = l.redactor.Restore(resp.Choices[i].Message.Content)
+	}
+	return resp, nil
+}
+
+func (l *redactingLLM) ChatWithTools(ctx context.Context, modelID string, req ToolRequest) (*ToolResponse, error) {
+	redacted := ToolRequest{System: l.redactor.Redact(req.System), Tools: req.Tools}
+	for _, turn := range req.Turns {
+		turn.Text = l.redactor.Redact(turn.Text)
+		turn.ToolCalls = l.mapToolCalls(turn.ToolCalls, l.redactor.Redact)
+		results := make([]ToolResult, len(turn.ToolResults))
+		for i, result := range turn.ToolResults {
+			result.Content = l.redactor.Redact(result.Content)
+			results[i] = result
+		}
+		turn.ToolResults = results
+		redacted.Turns = append(redacted.Turns, turn)
+	}
+	resp, err := l.LLMImpl.ChatWithTools(ctx, modelID, redacted)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	resp.Turn.Text = l.redactor.Restore(resp.Turn.Text)
+	return resp, nil
+}
+
+func (l *redactingLLM) StructuredOutput(ctx context.Context, modelID string, req ChatRequest, tool ToolSpec) ([]byte, error) {
+	data, err := l.LLMImpl.StructuredOutput(ctx, modelID, l.redactChat(req), tool)
+	if err != nil {
+		return data, err
+	}
+	return l.redactor.RestoreJSON(data), nil
+}
+
+func (l *redactingLLM) redactChat(req ChatRequest) ChatRequest {
+	messages := make([]Message, len(req.Messages))
+	for i, message := range req.Messages {
+		message.Content = l.redactor.Redact(message.Content)
+		messages[i] = message
+	}
+	return ChatRequest{Messages: messages}
+}
+
+// mapToolCalls applies fn to the string inputs of calls, the calls are copied
+func (l *redactingLLM) mapToolCalls(calls []ToolCall, fn func(string) string) []ToolCall {
+	if len(calls) == 0 {
+		return calls
+	}
+	mapped := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		input := make(map[string]any, len(call.Input))
+		for key, value := range call.Input {
+			if s, ok := value.(string); ok {
+				value = fn(s)
+			}
+			input[key] = value
+		}
+		call.Input = input
+		mapped[i] = call
+	}
+	return mapped
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks value (decoded with encoding/json) against the tool's input schema. Only the subset of
+// JSON Schema used by brains is supported: type, properties, required and items.
+func (t ToolSpec) Validate(value any) []string {
+	return validateSchema(t.InputSchema, value, "$")
+}
+
+func validateSchema(schema map[string]any, value any, path string) []string {
+	if schema == nil {
+		return nil
+	}
+	if expected, ok := schema["type"].(string); ok && !matchesType(expected, value) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, expected, jsonType(value))}
+	}
+
+	var problems []string
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range stringList(schema["required"]) {
+			if _, ok := v[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: required property is missing", path, key))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(properties))
+		for key := range properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propertySchema, _ := properties[key].(map[string]any)
+			if propertyValue, ok := v[key]; ok {
+				problems = append(problems, validateSchema(propertySchema, propertyValue, path+"."+key)...)
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for idx, item := range v {
+			problems = append(problems, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, idx))...)
+		}
+	}
+	return problems
+}
+
+func matchesType(expected string, value any) bool {
+	actual := jsonType(value)
+	if expected == "number" && actual == "integer" {
+		return true
+	}
+	return expected == actual
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// stringList reads a list of strings whether it was declared in Go ([]string) or decoded from JSON ([]any)
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
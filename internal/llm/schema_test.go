@@ -0,0 +1,52 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/llm"
+)
+
+var testSpec = llm.ToolSpec{
+	Name: "coder",
+	InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+			"count":   map[string]any{"type": "number"},
+			"updates": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path": map[string]any{"type": "string"},
+					},
+					"required": []string{"path"},
+				},
+			},
+		},
+		"required": []string{"summary", "updates"},
+	},
+}
+
+func decode(t *testing.T, raw string) any {
+	t.Helper()
+	var v any
+	assert.NoError(t, json.Unmarshal([]byte(raw), &v))
+	return v
+}
+
+func TestToolSpecValidate(t *testing.T) {
+	assert.Empty(t, testSpec.Validate(decode(t, `{"summary": "ok", "count": 2, "updates": [{"path": "a.go"}]}`)))
+
+	assert.Equal(t, []string{
+		"$.summary: required property is missing",
+		"$.count: expected number, got string",
+		"$.updates[1].path: required property is missing",
+		"$.updates[2].path: expected string, got integer",
+	}, testSpec.Validate(decode(t, `{"count": "2", "updates": [{"path": "a.go"}, {}, {"path": 3}]}`)))
+
+	assert.Equal(t, []string{"$: expected object, got array"}, testSpec.Validate(decode(t, `[]`)))
+}
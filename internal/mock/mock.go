@@ -4,12 +4,15 @@ import (
 	"context"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/pterm/pterm"
 	"github.com/stretchr/testify/mock"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
 )
 
 func CaptureAllOutput(fn func()) string {
@@ -46,11 +49,22 @@ func CaptureAllOutput(fn func()) string {
 }
 
 type TestLogger struct {
-	Data string
+	Events []brainsConfig.Event
+	// Entries are the previous log entries returned as context
+	Entries []string
 }
 
-func (l *TestLogger) LogMessage(data string) { l.Data = data }
-func (l *TestLogger) GetLogContext() string  { return "" }
+func (l *TestLogger) LogEvent(e brainsConfig.Event) { l.Events = append(l.Events, e) }
+func (l *TestLogger) GetLogContext() string         { return strings.Join(l.Entries, "") }
+func (l *TestLogger) GetLogEntries() []string       { return l.Entries }
+
+// Last is the latest event logged, the zero Event when none was
+func (l *TestLogger) Last() brainsConfig.Event {
+	if len(l.Events) == 0 {
+		return brainsConfig.Event{}
+	}
+	return l.Events[len(l.Events)-1]
+}
 
 type MockSTSClient struct {
 	Output *sts.GetCallerIdentityOutput
@@ -61,6 +75,18 @@ func (m *MockSTSClient) GetCallerIdentity(ctx context.Context, params *sts.GetCa
 	return m.Output, m.Err
 }
 
+// MockAssumeRoleClient records the AssumeRole requests it answers
+type MockAssumeRoleClient struct {
+	Output *sts.AssumeRoleOutput
+	Err    error
+	Inputs []*sts.AssumeRoleInput
+}
+
+func (m *MockAssumeRoleClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.Inputs = append(m.Inputs, params)
+	return m.Output, m.Err
+}
+
 type MockInvoker struct {
 	mock.Mock
 }
@@ -81,6 +107,22 @@ func (m *MockInvoker) ListFoundationModels(ctx context.Context, input *bedrock.L
 	return nil, args.Error(1)
 }
 
+func (m *MockInvoker) ListInferenceProfiles(ctx context.Context, input *bedrock.ListInferenceProfilesInput) (*bedrock.ListInferenceProfilesOutput, error) {
+	args := m.Called(ctx, input)
+	if out := args.Get(0); out != nil {
+		return out.(*bedrock.ListInferenceProfilesOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockInvoker) GetFoundationModelAvailability(ctx context.Context, input *bedrock.GetFoundationModelAvailabilityInput) (*bedrock.GetFoundationModelAvailabilityOutput, error) {
+	args := m.Called(ctx, input)
+	if out := args.Get(0); out != nil {
+		return out.(*bedrock.GetFoundationModelAvailabilityOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockInvoker) ConverseModel(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
 	args := m.Called(ctx, input)
 	if out := args.Get(0); out != nil {
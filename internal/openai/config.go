@@ -0,0 +1,46 @@
+package openai
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+)
+
+func NewOpenAIConfig(cfg brainsConfig.OpenAIConfig) llm.LLMImpl {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &OpenAIConfig{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		apiKeyEnv: cfg.APIKeyEnv,
+		client:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SetAndValidateCredentials reads the API key (if one is configured) and checks that the server answers
+func (o *OpenAIConfig) SetAndValidateCredentials() bool {
+	pterm.Info.Printfln("checking OpenAI-compatible server at %s", o.baseURL)
+	if o.apiKeyEnv != "" {
+		o.apiKey = os.Getenv(o.apiKeyEnv)
+		if o.apiKey == "" {
+			pterm.Error.Printfln("environment variable %s is empty", o.apiKeyEnv)
+			return false
+		}
+	}
+	if err := o.listModels(); err != nil {
+		pterm.Error.Printfln("server unavailable: %v", err)
+		return false
+	}
+	pterm.Info.Println("server available")
+	return true
+}
+
+func (o *OpenAIConfig) SetLogger(l brainsConfig.SimpleLogger) { o.logger = l }
+func (o *OpenAIConfig) SetReasoningBudget(budget int)         { o.reasoningBudget = budget }
+func (o *OpenAIConfig) SetTemperature(temperature *float64)   { o.temperature = temperature }
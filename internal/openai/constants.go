@@ -0,0 +1,14 @@
+package openai
+
+import "time"
+
+const (
+	// DefaultBaseURL matches the default llama.cpp server address
+	DefaultBaseURL = "http://localhost:8080/v1"
+
+	chatCompletionsPath = "/chat/completions"
+	modelsPath          = "/models"
+
+	// requestTimeout is generous as local models on CPU can take minutes to respond
+	requestTimeout = 10 * time.Minute
+)
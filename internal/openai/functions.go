@@ -0,0 +1,252 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pterm/pterm"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+)
+
+func (o *OpenAIConfig) Chat(ctx context.Context, modelID string, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := o.chatCompletion(ctx, chatCompletionRequest{
+		Model:    modelID,
+		Messages: chatMessages(req),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &llm.ChatResponse{Usage: resp.Usage}
+	for _, choice := range resp.Choices {
+		reasoning := messageReasoning(choice.Message)
+		o.logReasoning(modelID, reasoning)
+		data.Choices = append(data.Choices, llm.ResponseChoice{
+			Message: llm.ResponseMessage{
+				Role:      choice.Message.Role,
+				Content:   choice.Message.Content,
+				Reasoning: reasoning,
+			},
+		})
+	}
+	return data, nil
+}
+
+func (o *OpenAIConfig) StructuredOutput(ctx context.Context, modelID string, req llm.ChatRequest, spec llm.ToolSpec) ([]byte, error) {
+	resp, err := o.chatCompletion(ctx, chatCompletionRequest{
+		Model:    modelID,
+		Messages: chatMessages(req),
+		Tools: []tool{{
+			Type: "function",
+			Function: toolFunction{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Parameters:  spec.InputSchema,
+			},
+		}},
+		ToolChoice: &toolChoice{
+			Type:     "function",
+			Function: toolChoiceFunction{Name: spec.Name},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices found in the response")
+	}
+
+	message := resp.Choices[0].Message
+	o.logReasoning(modelID, messageReasoning(message))
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == spec.Name {
+			return []byte(call.Function.Arguments), nil
+		}
+	}
+
+	// fall back to text, this may fail
+	if message.Content != "" {
+		pterm.Warning.Println("model returned a text response instead of using the tool. Parsing may be brittle.")
+		return []byte(message.Content), nil
+	}
+
+	return nil, fmt.Errorf("no tool call or text found in the response")
+}
+
+// ChatWithTools runs a single chat completion with tools offered, tool_choice is left to the server default (auto)
+func (o *OpenAIConfig) ChatWithTools(ctx context.Context, modelID string, req llm.ToolRequest) (*llm.ToolResponse, error) {
+	var messages []chatMessage
+	if req.System != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: req.System})
+	}
+	for _, turn := range req.Turns {
+		message := chatMessage{Role: turn.Role, Content: turn.Text}
+		for _, call := range turn.ToolCalls {
+			arguments, err := json.Marshal(call.Input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal arguments of tool %s: %w", call.Name, err)
+			}
+			message.ToolCalls = append(message.ToolCalls, toolCall{
+				ID:       call.ID,
+				Type:     "function",
+				Function: toolCallFunction{Name: call.Name, Arguments: string(arguments)},
+			})
+		}
+		if turn.Text != "" || len(turn.ToolCalls) > 0 {
+			messages = append(messages, message)
+		}
+		// each result is its own message with the tool role
+		for _, result := range turn.ToolResults {
+			messages = append(messages, chatMessage{Role: "tool", Content: result.Content, ToolCallID: result.ToolCallID})
+		}
+	}
+
+	var tools []tool
+	for _, spec := range req.Tools {
+		tools = append(tools, tool{
+			Type: "function",
+			Function: toolFunction{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Parameters:  spec.InputSchema,
+			},
+		})
+	}
+
+	resp, err := o.chatCompletion(ctx, chatCompletionRequest{
+		Model:    modelID,
+		Messages: messages,
+		Tools:    tools,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices found in the response")
+	}
+
+	choice := resp.Choices[0]
+	turn := llm.Turn{Role: choice.Message.Role, Text: choice.Message.Content}
+	if reasoning := messageReasoning(choice.Message); reasoning != "" {
+		o.logReasoning(modelID, reasoning)
+		turn.Reasoning = []llm.ReasoningBlock{{Text: reasoning}}
+	}
+	for _, call := range choice.Message.ToolCalls {
+		input := map[string]any{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+				return nil, fmt.Errorf("decode arguments of tool %s: %w", call.Function.Name, err)
+			}
+		}
+		turn.ToolCalls = append(turn.ToolCalls, llm.ToolCall{ID: call.ID, Name: call.Function.Name, Input: input})
+	}
+	return &llm.ToolResponse{
+		Turn:  turn,
+		Done:  len(turn.ToolCalls) == 0,
+		Usage: resp.Usage,
+	}, nil
+}
+
+func (o *OpenAIConfig) chatCompletion(ctx context.Context, req chatCompletionRequest) (*chatCompletionResponse, error) {
+	if o.reasoningBudget > 0 {
+		req.ReasoningEffort = llm.ReasoningEffort(o.reasoningBudget)
+	}
+	req.Temperature = o.temperature
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat completion request: %w", err)
+	}
+	pterm.Info.Printfln("size of outbound request: %d", len(body))
+
+	spinner, _ := pterm.DefaultSpinner.Start("loading response from OpenAI-compatible server")
+	respBody, err := o.do(ctx, http.MethodPost, chatCompletionsPath, body)
+	if err != nil {
+		spinner.Fail()
+		return nil, err
+	}
+	spinner.Success()
+
+	var resp chatCompletionResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("json Unmarshal error (when parsing chat completion): %w", err)
+	}
+	return &resp, nil
+}
+
+func (o *OpenAIConfig) listModels() error {
+	_, err := o.do(context.Background(), http.MethodGet, modelsPath, nil)
+	return err
+}
+
+func (o *OpenAIConfig) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, bytes.TrimSpace(respBody))
+	}
+	return respBody, nil
+}
+
+func chatMessages(req llm.ChatRequest) []chatMessage {
+	var messages []chatMessage
+	for _, m := range req.Messages {
+		messages = append(messages, chatMessage{Role: m.Role, Content: m.Content})
+	}
+	return messages
+}
+
+func messageReasoning(message chatMessage) string {
+	if message.ReasoningContent != "" {
+		return message.ReasoningContent
+	}
+	return message.Reasoning
+}
+
+func (o *OpenAIConfig) logReasoning(modelID, reasoning string) {
+	if o.logger != nil && reasoning != "" {
+		o.logger.LogEvent(brainsConfig.Event{Type: brainsConfig.EventReasoning, Model: modelID, Response: reasoning})
+	}
+}
+
+func (o *OpenAIConfig) PrintMessage(content string) { llm.PrintMarkdown(content) }
+
+// Cost is unknown for OpenAI-compatible servers
+func (o *OpenAIConfig) Cost(usage map[string]any, modelID string) (float64, bool) {
+	return 0, false
+}
+
+func (o *OpenAIConfig) PrintCost(usage map[string]any, modelID string) {
+	pterm.Info.Printf("no pricing tracked for this request (%s) (%s)\n", modelID, llm.TokenSummary(usage))
+}
+
+func (o *OpenAIConfig) PrintContext(usage map[string]any, modelID string) {
+	llm.PrintContextUsage(usage, modelID)
+}
+
+func (o *OpenAIConfig) PrintPricing(modelID string) error {
+	pterm.Info.Printfln("pricing is not tracked for OpenAI-compatible servers, active model: %s", modelID)
+	return nil
+}
@@ -0,0 +1,205 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+	"github.com/madhuravius/brains/internal/llm"
+	mockBrains "github.com/madhuravius/brains/internal/mock"
+	"github.com/madhuravius/brains/internal/openai"
+)
+
+func setupServer(t *testing.T, handler func(t *testing.T, body map[string]any) string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"local-model"}]}`))
+		case "/v1/chat/completions":
+			assert.Equal(t, http.MethodPost, r.Method)
+			var body map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			_, _ = w.Write([]byte(handler(t, body)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChat(t *testing.T) {
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		assert.Equal(t, "local-model", body["model"])
+		assert.Equal(t, []any{map[string]any{"role": "user", "content": "hello"}}, body["messages"])
+		assert.NotContains(t, body, "tools")
+		return `{
+			"choices": [{"message": {"role": "assistant", "content": "hi there"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8}
+		}`
+	})
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1/"})
+
+	resp, err := o.Chat(context.Background(), "local-model", llm.NewUserRequest("hello"))
+	assert.NoError(t, err)
+	assert.Len(t, resp.Choices, 1)
+	assert.Equal(t, "hi there", resp.Choices[0].Message.Content)
+
+	promptTokens, completionTokens := llm.UsageTokens(resp.Usage)
+	assert.Equal(t, 5, promptTokens)
+	assert.Equal(t, 3, completionTokens)
+}
+
+func TestStructuredOutputUsesToolCall(t *testing.T) {
+	spec := llm.ToolSpec{
+		Name:        "coder",
+		Description: "Generate code changes",
+		InputSchema: map[string]any{"type": "object"},
+	}
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		assert.Equal(t, []any{map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        "coder",
+				"description": "Generate code changes",
+				"parameters":  map[string]any{"type": "object"},
+			},
+		}}, body["tools"])
+		assert.Equal(t, map[string]any{
+			"type":     "function",
+			"function": map[string]any{"name": "coder"},
+		}, body["tool_choice"])
+		return `{"choices": [{"message": {"role": "assistant", "content": "", "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "coder", "arguments": "{\"markdown_summary\":\"done\"}"}}
+		]}, "finish_reason": "tool_calls"}]}`
+	})
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+
+	out, err := o.StructuredOutput(context.Background(), "local-model", llm.NewUserRequest("change it"), spec)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"markdown_summary":"done"}`, string(out))
+}
+
+func TestStructuredOutputFallsBackToText(t *testing.T) {
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		return `{"choices": [{"message": {"role": "assistant", "content": "{\"markdown_summary\":\"text\"}"}}]}`
+	})
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+
+	out, err := o.StructuredOutput(context.Background(), "local-model", llm.NewUserRequest("change it"), llm.ToolSpec{Name: "coder"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"markdown_summary":"text"}`, string(out))
+}
+
+func TestChatServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+
+	_, err := o.Chat(context.Background(), "local-model", llm.NewUserRequest("hello"))
+	assert.ErrorContains(t, err, "503")
+	assert.ErrorContains(t, err, "model not loaded")
+}
+
+func TestSetAndValidateCredentials(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("BRAINS_TEST_OPENAI_KEY", "sk-test")
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1", APIKeyEnv: "BRAINS_TEST_OPENAI_KEY"})
+	assert.True(t, o.SetAndValidateCredentials())
+	assert.Equal(t, "Bearer sk-test", auth)
+
+	missing := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1", APIKeyEnv: "BRAINS_TEST_OPENAI_KEY_UNSET"})
+	assert.False(t, missing.SetAndValidateCredentials())
+
+	srv.Close()
+	unreachable := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+	assert.False(t, unreachable.SetAndValidateCredentials())
+}
+
+func TestPrintPricing(t *testing.T) {
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{})
+	assert.NoError(t, o.PrintPricing("local-model"))
+	o.PrintCost(map[string]any{"prompt_tokens": float64(1)}, "local-model")
+	o.PrintContext(map[string]any{"prompt_tokens": float64(1)}, "local-model")
+}
+
+func TestChatWithTools(t *testing.T) {
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		assert.NotContains(t, body, "tool_choice")
+		assert.Equal(t, []any{
+			map[string]any{"role": "system", "content": "system prompt"},
+			map[string]any{"role": "user", "content": "what module is this?"},
+			map[string]any{"role": "assistant", "content": "", "tool_calls": []any{map[string]any{
+				"id": "call_1", "type": "function",
+				"function": map[string]any{"name": "read_file", "arguments": `{"path":"go.mod"}`},
+			}}},
+			map[string]any{"role": "tool", "content": "module example", "tool_call_id": "call_1"},
+		}, body["messages"])
+		return `{"choices": [{"message": {"role": "assistant", "content": "it is example"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 4}}`
+	})
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+
+	resp, err := o.ChatWithTools(context.Background(), "local-model", llm.ToolRequest{
+		System: "system prompt",
+		Turns: []llm.Turn{
+			{Role: "user", Text: "what module is this?"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "read_file", Input: map[string]any{"path": "go.mod"}}}},
+			{Role: "user", ToolResults: []llm.ToolResult{{ToolCallID: "call_1", Content: "module example"}}},
+		},
+		Tools: []llm.ToolSpec{{Name: "read_file", InputSchema: map[string]any{"type": "object"}}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.Done)
+	assert.Equal(t, "it is example", resp.Turn.Text)
+}
+
+func TestChatReasoning(t *testing.T) {
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		assert.Equal(t, "medium", body["reasoning_effort"])
+		return `{
+			"choices": [{"message": {"role": "assistant", "content": "hi there", "reasoning_content": "a greeting"}}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 30, "completion_tokens_details": {"reasoning_tokens": 27}}
+		}`
+	})
+	logger := &mockBrains.TestLogger{}
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+	o.SetLogger(logger)
+	o.SetReasoningBudget(8000)
+
+	resp, err := o.Chat(context.Background(), "local-model", llm.NewUserRequest("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hi there", resp.Choices[0].Message.Content)
+	assert.Equal(t, "a greeting", resp.Choices[0].Message.Reasoning)
+	assert.Equal(t, 27, llm.ReasoningTokens(resp.Usage))
+	assert.Equal(t, brainsConfig.Event{Type: brainsConfig.EventReasoning, Model: "local-model", Response: "a greeting"}, logger.Last())
+}
+
+func TestChatTemperature(t *testing.T) {
+	srv := setupServer(t, func(t *testing.T, body map[string]any) string {
+		assert.Equal(t, 0.7, body["temperature"])
+		return `{"choices": [{"message": {"role": "assistant", "content": "hi"}}], "usage": {}}`
+	})
+	temperature := 0.7
+	o := openai.NewOpenAIConfig(brainsConfig.OpenAIConfig{BaseURL: srv.URL + "/v1"})
+	o.SetLogger(&mockBrains.TestLogger{})
+	o.SetTemperature(&temperature)
+
+	_, err := o.Chat(context.Background(), "local-model", llm.NewUserRequest("hello"))
+	assert.NoError(t, err)
+}
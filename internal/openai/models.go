@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"net/http"
+
+	brainsConfig "github.com/madhuravius/brains/internal/config"
+)
+
+type OpenAIConfig struct {
+	baseURL   string
+	apiKeyEnv string
+	apiKey    string
+	client    *http.Client
+	logger    brainsConfig.SimpleLogger
+	// reasoningBudget is sent as reasoning_effort, the server default is used when zero
+	reasoningBudget int
+	// temperature comes from the persona, the server default is used when nil
+	temperature *float64
+}
+
+type chatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// servers disagree on the name of the reasoning field, llama.cpp and vLLM use reasoning_content
+	// while Ollama and OpenRouter use reasoning
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+}
+
+type toolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type tool struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolChoiceFunction struct {
+	Name string `json:"name"`
+}
+
+type toolChoice struct {
+	Type     string             `json:"type"`
+	Function toolChoiceFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type toolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	Function toolCallFunction `json:"function"`
+}
+
+type chatCompletionRequest struct {
+	Model      string        `json:"model"`
+	Messages   []chatMessage `json:"messages"`
+	Tools      []tool        `json:"tools,omitempty"`
+	ToolChoice *toolChoice   `json:"tool_choice,omitempty"`
+	// ReasoningEffort is low, medium or high
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+}
+
+type chatCompletionChoice struct {
+	Message      chatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type chatCompletionResponse struct {
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   map[string]any         `json:"usage"`
+}
@@ -0,0 +1,34 @@
+package prompts
+
+import "embed"
+
+//go:embed data/*.tmpl
+var defaultTemplates embed.FS
+
+// Ext is the extension of template files, overrides are named after the template (ex: coder.tmpl)
+const Ext = ".tmpl"
+
+// SourceEmbedded is the source of templates that are not overridden
+const SourceEmbedded = "embedded"
+
+// template names, each has a default in data/
+const (
+	Ask                = "ask"
+	Coder              = "coder"
+	HealthCheck        = "health_check"
+	LogSummary         = "log_summary"
+	LogSummaryReduce   = "log_summary_reduce"
+	Repair             = "repair"
+	ResearchActivities = "research_activities"
+)
+
+// specs describe every template and the variables an override must still reference
+var specs = []spec{
+	{name: Ask, description: "answers the prompt of brains ask with the gathered context", required: []string{"Prompt", "Files"}},
+	{name: Coder, description: "asks for the code changes of brains code", required: []string{"Prompt", "Files"}},
+	{name: HealthCheck, description: "checks that the model answers, used by brains health"},
+	{name: LogSummary, description: "summarizes a chunk of the previous logs when summarize_logs is set", required: []string{"Logs"}},
+	{name: LogSummaryReduce, description: "merges the summaries of the chunks of the previous logs", required: []string{"Logs"}},
+	{name: Repair, description: "becomes the prompt of coder when post_edit_commands fail", required: []string{"Prompt", "CommandOutput"}},
+	{name: ResearchActivities, description: "appended with the logs when send_logs is set", required: []string{"Prompt", "Logs"}},
+}
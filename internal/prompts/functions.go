@@ -0,0 +1,214 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pterm/pterm"
+)
+
+// Load parses the embedded templates and the overrides found in dir, an override that fails to parse, has no
+// default to replace or misses a required variable is an error
+func Load(dir string) (*Templates, error) {
+	t := &Templates{templates: map[string]*Template{}}
+	var errs []error
+	for _, s := range specs {
+		source, text, err := readTemplate(dir, s.name)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := parseTemplate(s, source, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t.templates[s.name] = tmpl
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+Ext))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), Ext)
+		if !slices.ContainsFunc(specs, func(s spec) bool { return s.name == name }) {
+			errs = append(errs, fmt.Errorf("%s: unknown template %s, expected one of %s", path, name, strings.Join(Names(), ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return t, nil
+}
+
+// Names lists the template names in order
+func Names() []string {
+	names := make([]string, 0, len(specs))
+	for _, s := range specs {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// List returns every template in the order of Names
+func (t *Templates) List() []*Template {
+	templates := make([]*Template, 0, len(specs))
+	for _, name := range Names() {
+		templates = append(templates, t.templates[name])
+	}
+	return templates
+}
+
+// Get returns the template called name
+func (t *Templates) Get(name string) (*Template, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %s, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return tmpl, nil
+}
+
+// Print lists every template with its source and required variables
+func (t *Templates) Print() error {
+	tableData := pterm.TableData{{"Name", "Source", "Required", "Description"}}
+	for _, tmpl := range t.List() {
+		tableData = append(tableData, []string{tmpl.Name, tmpl.Source, strings.Join(tmpl.Required, ", "), tmpl.Description})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
+}
+
+// Print shows where the template comes from followed by its text
+func (t *Template) Print() {
+	pterm.Info.Printfln("template %s from %s", t.Name, t.Source)
+	fmt.Print(t.Text)
+}
+
+// Render executes the template called name with vars
+func (t *Templates) Render(name string, vars Vars) (string, error) {
+	tmpl, err := t.Get(name)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.tmpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("render %s: %w", tmpl.Source, err)
+	}
+	return out.String(), nil
+}
+
+// Eject copies the default of the template called name into dir so that it can be edited, returning its path
+func Eject(dir, name string) (string, error) {
+	if !slices.Contains(Names(), name) {
+		return "", fmt.Errorf("unknown template %s, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	path := filepath.Join(dir, name+Ext)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	text, err := defaultTemplates.ReadFile("data/" + name + Ext)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, text, 0o600)
+}
+
+// readTemplate returns the override of name in dir, or the embedded default when there is none
+func readTemplate(dir, name string) (source, text string, err error) {
+	path := filepath.Join(dir, name+Ext)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return path, string(data), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", "", err
+	}
+	data, err = defaultTemplates.ReadFile("data/" + name + Ext)
+	if err != nil {
+		return "", "", err
+	}
+	return SourceEmbedded, string(data), nil
+}
+
+func parseTemplate(s spec, source, text string) (*Template, error) {
+	tmpl, err := template.New(s.name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	referenced := map[string]bool{}
+	if tmpl.Tree != nil {
+		fields(tmpl.Tree.Root, referenced)
+	}
+	for name := range referenced {
+		if _, ok := reflect.TypeOf(Vars{}).FieldByName(name); !ok {
+			return nil, fmt.Errorf("%s: template %s references unknown variable {{.%s}}", source, s.name, name)
+		}
+	}
+	var missing []string
+	for _, name := range s.required {
+		if !referenced[name] {
+			missing = append(missing, "{{."+name+"}}")
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: template %s must reference %s", source, s.name, strings.Join(missing, ", "))
+	}
+	return &Template{
+		Name:        s.name,
+		Description: s.description,
+		Required:    s.required,
+		Source:      source,
+		Text:        text,
+		tmpl:        tmpl,
+	}, nil
+}
+
+// fields collects the top level variables referenced anywhere in node, ex: Prompt for {{.Prompt}}
+func fields(node parse.Node, referenced map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			fields(child, referenced)
+		}
+	case *parse.ActionNode:
+		fields(n.Pipe, referenced)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			fields(cmd, referenced)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			fields(arg, referenced)
+		}
+	case *parse.FieldNode:
+		referenced[n.Ident[0]] = true
+	case *parse.IfNode:
+		fields(n.Pipe, referenced)
+		fields(n.List, referenced)
+		fields(n.ElseList, referenced)
+	case *parse.RangeNode:
+		fields(n.Pipe, referenced)
+		fields(n.List, referenced)
+		fields(n.ElseList, referenced)
+	case *parse.WithNode:
+		fields(n.Pipe, referenced)
+		fields(n.List, referenced)
+		fields(n.ElseList, referenced)
+	}
+}
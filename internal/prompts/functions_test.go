@@ -0,0 +1,96 @@
+package prompts_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/prompts"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	templates, err := prompts.Load(t.TempDir())
+	assert.NoError(t, err)
+
+	var names []string
+	for _, tmpl := range templates.List() {
+		assert.Equal(t, prompts.SourceEmbedded, tmpl.Source)
+		names = append(names, tmpl.Name)
+	}
+	assert.Equal(t, prompts.Names(), names)
+
+	out, err := templates.Render(prompts.LogSummary, prompts.Vars{Logs: "panic: boom"})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Logs:\npanic: boom\n")
+
+	out, err = templates.Render(prompts.LogSummaryReduce, prompts.Vars{Logs: "first part\n\nsecond part"})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "oldest first:\nfirst part\n\nsecond part\n")
+
+	out, err = templates.Render(prompts.Ask, prompts.Vars{Prompt: "what is this?", Persona: "Human: be brief\n\n", Files: "main.go"})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "Human: be brief\n\nmain.go")
+	assert.NotContains(t, out, "mapping of the current repository")
+	assert.Contains(t, out, "\n\nwhat is this?\n")
+
+	_, err = templates.Render("missing", prompts.Vars{})
+	assert.ErrorContains(t, err, "unknown template missing")
+}
+
+func TestLoadOverride(t *testing.T) {
+	dir := t.TempDir()
+	path, err := prompts.Eject(dir, prompts.Coder)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "coder.tmpl"), path)
+	_, err = prompts.Eject(dir, prompts.Coder)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = prompts.Eject(dir, "coders")
+	assert.ErrorContains(t, err, "unknown template coders")
+
+	assert.NoError(t, os.WriteFile(path, []byte("{{.Persona}}Edit {{.Files}} for: {{.Prompt}}"), 0o600))
+	templates, err := prompts.Load(dir)
+	assert.NoError(t, err)
+	tmpl, err := templates.Get(prompts.Coder)
+	assert.NoError(t, err)
+	assert.Equal(t, path, tmpl.Source)
+
+	out, err := templates.Render(prompts.Coder, prompts.Vars{Prompt: "add tests", Files: "a.go"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Edit a.go for: add tests", out)
+}
+
+func TestLoadInvalidOverrides(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file, contents, err string
+	}{
+		"missing variable": {
+			file:     "ask.tmpl",
+			contents: "{{if .Persona}}{{.Persona}}{{end}}{{.Prompt}}",
+			err:      "template ask must reference {{.Files}}",
+		},
+		"unknown variable": {
+			file:     "log_summary.tmpl",
+			contents: "{{.Prompt}} {{range .Logs}}{{.}}{{end}} {{.Log}}",
+			err:      "template log_summary references unknown variable {{.Log}}",
+		},
+		"parse error": {
+			file:     "health_check.tmpl",
+			contents: "{{.Prompt",
+			err:      "health_check.tmpl: template: health_check:1: unclosed action",
+		},
+		"unknown template": {
+			file:     "asks.tmpl",
+			contents: "{{.Prompt}}",
+			err:      "unknown template asks, expected one of ask, coder, health_check, log_summary, log_summary_reduce, repair, research_activities",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, tc.file), []byte(tc.contents), 0o600))
+			_, err := prompts.Load(dir)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
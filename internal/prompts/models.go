@@ -0,0 +1,49 @@
+package prompts
+
+import "text/template"
+
+// Vars are the variables available to every template, empty when a step did not gather them
+type Vars struct {
+	// Prompt is the prompt given to brains
+	Prompt string
+	// Persona holds the persona instructions
+	Persona string
+	// RepoMap lists the files and symbols of the repository when send_all_tags is set
+	RepoMap string
+	// FileList is the file tree when send_file_list is set
+	FileList string
+	// Files holds the contents of the files added with --add or read during research
+	Files string
+	// Research is the research summary and the fetched urls
+	Research string
+	// LogSummary is the summary of previous logs when summarize_logs is set
+	LogSummary string
+	// Logs are the raw previous logs, a chunk of them in log_summary and the summaries to merge in log_summary_reduce
+	Logs string
+	// CommandOutput is the command and output of the post edit command that failed
+	CommandOutput string
+}
+
+// Template is a prompt template, embedded or overridden by a file
+type Template struct {
+	Name        string
+	Description string
+	// Required are the variables the template has to reference
+	Required []string
+	// Source is the override file, SourceEmbedded for the default
+	Source string
+	Text   string
+
+	tmpl *template.Template
+}
+
+// Templates are every prompt template with overrides applied
+type Templates struct {
+	templates map[string]*Template
+}
+
+type spec struct {
+	name        string
+	description string
+	required    []string
+}
@@ -0,0 +1,66 @@
+package redact
+
+import "regexp"
+
+// PlaceholderPrefix starts every placeholder (ex: [REDACTED:aws_access_key_id:3f9a1c20])
+const PlaceholderPrefix = "[REDACTED:"
+
+// placeholderHashSize is the number of hex characters of the keyed hash in a placeholder
+const placeholderHashSize = 8
+
+// keySize is the size of the keys made by NewKey
+const keySize = 32
+
+// KindCustom is the kind of secrets matched by configured patterns
+const KindCustom = "custom"
+
+// KindEnv is the kind of values read from .env files
+const KindEnv = "env_value"
+
+// KindEntropy is the kind of long random looking tokens
+const KindEntropy = "high_entropy"
+
+// DefaultDenyFiles are never read into the context, gitignore syntax
+var DefaultDenyFiles = []string{
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.keystore",
+	"*.jks",
+	"id_rsa*",
+	"id_dsa*",
+	"id_ecdsa*",
+	"id_ed25519*",
+	".aws/credentials",
+	".netrc",
+	".npmrc",
+	".pypirc",
+	"*.tfstate",
+	"*.tfstate.backup",
+}
+
+// EnvFiles are the files at the repository root whose values are redacted wherever they show up
+var EnvFiles = []string{".env", ".env.*", "*.env"}
+
+// minEnvValue skips short values of .env files (ex: true, 8080) that would redact common words
+const minEnvValue = 6
+
+// tokens of 32 characters or more (entropyToken) with letters, digits and at least minEntropy bits per character
+// are considered random, hex strings never reach it so commit hashes and checksums are kept
+const minEntropy = 4.3
+
+var builtinRules = []rule{
+	{kind: "private_key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----`)},
+	{kind: "aws_access_key_id", re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|AGPA|AIDA|AIPA|ANPA|ANVA|AROA|APKA)[A-Z0-9]{16}\b`)},
+	{kind: "aws_secret_access_key", re: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})(?:[^A-Za-z0-9/+=]|$)`)},
+	{kind: "aws_session_token", re: regexp.MustCompile(`(?i)aws_?session_?token["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{100,})`)},
+	{kind: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{kind: "github_token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{kind: "slack_token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+}
+
+var (
+	entropyToken = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+	envLine      = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*=\s*(.*?)\s*$`)
+)
@@ -0,0 +1,266 @@
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// New builds a Redactor with the builtin rules and patterns, a pattern with a capture group only redacts the
+// group. Patterns that fail to compile are skipped and returned as an error. Placeholders are derived from the
+// secrets with key so they stay the same across runs, a nil key picks a random one for this run only.
+func New(key []byte, patterns []string) (*Redactor, error) {
+	if key == nil {
+		key = NewKey()
+	}
+	r := &Redactor{
+		rules:        append([]rule{}, builtinRules...),
+		literals:     map[string]bool{},
+		key:          key,
+		placeholders: map[string]string{},
+		secrets:      map[string]string{},
+	}
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := CompilePattern(pattern)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.rules = append(r.rules, rule{kind: KindCustom, re: re})
+	}
+	return r, errors.Join(errs...)
+}
+
+// NewKey returns a random key for New
+func NewKey() []byte {
+	key := make([]byte, keySize)
+	_, _ = rand.Read(key)
+	return key
+}
+
+// CompilePattern compiles a configured pattern, used by config validation
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// LoadEnvFiles adds the values of the EnvFiles found in dir, so they are redacted from every file and log
+func (r *Redactor) LoadEnvFiles(dir string) error {
+	for _, pattern := range EnvFiles {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if err := r.loadEnvFile(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Redactor) loadEnvFile(path string) error {
+	f, err := os.Open(path) // #nosec G304 -- env files of the repository are read to redact their values
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		match := envLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		value := strings.Trim(match[2], `"'`)
+		if len(value) >= minEnvValue {
+			r.literals[value] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// Redact replaces the secrets of text with placeholders, a nil Redactor returns text unchanged
+func (r *Redactor) Redact(text string) string {
+	redacted, _ := r.Find(text)
+	return redacted
+}
+
+// Find redacts text and reports every secret it replaced
+func (r *Redactor) Find(text string) (string, []Finding) {
+	if r == nil || text == "" {
+		return text, nil
+	}
+	spans := r.spans(text)
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b strings.Builder
+	findings := make([]Finding, 0, len(spans))
+	last := 0
+	for _, s := range spans {
+		secret := text[s.start:s.end]
+		placeholder := r.placeholder(secret, s.kind)
+		b.WriteString(text[last:s.start])
+		b.WriteString(placeholder)
+		last = s.end
+		findings = append(findings, Finding{
+			Kind:        s.kind,
+			Line:        strings.Count(text[:s.start], "\n") + 1,
+			Preview:     preview(secret),
+			Placeholder: placeholder,
+		})
+	}
+	b.WriteString(text[last:])
+	return b.String(), findings
+}
+
+// Restore puts back the secrets behind the placeholders of text, so edits the model makes around a redacted
+// value apply to the real file. Only the secrets redacted during this run are known, other placeholders are kept.
+func (r *Redactor) Restore(text string) string {
+	if r == nil || !strings.Contains(text, PlaceholderPrefix) {
+		return text
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for placeholder, secret := range r.secrets {
+		text = strings.ReplaceAll(text, placeholder, secret)
+	}
+	return text
+}
+
+// placeholder is the stable placeholder of secret, a keyed hash of it so the placeholder doesn't reveal the
+// secret. A prefix of the hash is used, longer ones only if it is taken by another secret. r.mu must be held.
+func (r *Redactor) placeholder(secret, kind string) string {
+	if placeholder, ok := r.placeholders[secret]; ok {
+		return placeholder
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(secret))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	var placeholder string
+	for n := placeholderHashSize; n <= len(sum); n *= 2 {
+		placeholder = fmt.Sprintf("%s%s:%s]", PlaceholderPrefix, kind, sum[:n])
+		if _, taken := r.secrets[placeholder]; !taken {
+			break
+		}
+	}
+	r.placeholders[secret] = placeholder
+	r.secrets[placeholder] = secret
+	return placeholder
+}
+
+// spans finds the secrets of text ordered by position, overlapping matches keep the one starting first
+func (r *Redactor) spans(text string) []span {
+	var found []span
+	for _, rl := range r.rules {
+		for _, loc := range rl.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) > 2 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start < end {
+				found = append(found, span{start: start, end: end, kind: rl.kind})
+			}
+		}
+	}
+	for value := range r.literals {
+		for offset := 0; ; {
+			idx := strings.Index(text[offset:], value)
+			if idx < 0 {
+				break
+			}
+			found = append(found, span{start: offset + idx, end: offset + idx + len(value), kind: KindEnv})
+			offset += idx + len(value)
+		}
+	}
+	for _, loc := range entropyToken.FindAllStringIndex(text, -1) {
+		if looksRandom(text[loc[0]:loc[1]]) {
+			found = append(found, span{start: loc[0], end: loc[1], kind: KindEntropy})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].start == found[j].start {
+			return found[i].end > found[j].end
+		}
+		return found[i].start < found[j].start
+	})
+	spans := found[:0]
+	end := 0
+	for _, s := range found {
+		if s.start < end {
+			continue
+		}
+		spans = append(spans, s)
+		end = s.end
+	}
+	return spans
+}
+
+// looksRandom reports whether token mixes letters and digits with a high Shannon entropy
+func looksRandom(token string) bool {
+	var letters, digits bool
+	counts := map[rune]int{}
+	for _, c := range token {
+		letters = letters || unicode.IsLetter(c)
+		digits = digits || unicode.IsDigit(c)
+		counts[c]++
+	}
+	if !letters || !digits {
+		return false
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(token))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
+
+// preview keeps the first characters of long secrets to recognize them, short ones are fully masked
+func preview(secret string) string {
+	if len(secret) < 12 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// RestoreJSON restores the placeholders inside the strings of a JSON document, secrets are escaped so the
+// document stays valid
+func (r *Redactor) RestoreJSON(data []byte) []byte {
+	if r == nil || !bytes.Contains(data, []byte(PlaceholderPrefix)) {
+		return data
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for placeholder, secret := range r.secrets {
+		escaped, err := json.Marshal(secret)
+		if err != nil {
+			continue
+		}
+		data = bytes.ReplaceAll(data, []byte(placeholder), escaped[1:len(escaped)-1])
+	}
+	return data
+}
@@ -0,0 +1,42 @@
+package redact
+
+import (
+	"regexp"
+	"sync"
+)
+
+// Redactor replaces secrets with placeholders before text leaves the machine, the same secret always gets the
+// same placeholder so Restore can put it back in the answers of the model and logs of earlier runs still match
+type Redactor struct {
+	rules []rule
+	// literals are the values read from .env files
+	literals map[string]bool
+	// key derives the placeholders from the secrets
+	key []byte
+
+	mu           sync.Mutex
+	placeholders map[string]string
+	secrets      map[string]string
+}
+
+// Finding is a secret found by Find
+type Finding struct {
+	Kind string
+	// Line is the 1-based line of the text the secret starts on
+	Line int
+	// Preview shows the first characters of the secret only
+	Preview     string
+	Placeholder string
+}
+
+// rule finds a kind of secret, the secret is the capture group when the pattern has one
+type rule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// span is a secret at text[start:end]
+type span struct {
+	start, end int
+	kind       string
+}
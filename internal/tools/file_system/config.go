@@ -10,5 +10,21 @@ func NewFileSystemConfig() (FileSystemImpl, error) {
 
 	return &FileSystemConfig{
 		commonTools: tools.NewCommonToolsConfig(ignorePatterns),
+		denied:      tools.NewCommonToolsConfig(nil),
 	}, nil
 }
+
+// SetDenyPatterns replaces the files that must never be read into the context, gitignore syntax
+func (f *FileSystemConfig) SetDenyPatterns(patterns []string) {
+	f.denied.SetIgnorePatterns(patterns)
+}
+
+// IsIgnored reports whether path is ignored by .gitignore, its contents are never read into the context
+func (f *FileSystemConfig) IsIgnored(path string) bool {
+	return f.commonTools.IsIgnored(path)
+}
+
+// IsDenied reports whether path is on the deny list
+func (f *FileSystemConfig) IsDenied(path string) bool {
+	return f.denied.IsIgnored(path)
+}
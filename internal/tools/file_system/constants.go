@@ -0,0 +1,46 @@
+package file_system
+
+import "time"
+
+// anchor matches of ApplyEdit, from the strictest to the loosest
+const (
+	MatchExact      Match = "exact"
+	MatchWhitespace Match = "whitespace"
+	MatchFuzzy      Match = "fuzzy"
+)
+
+// minFuzzySimilarity is the share of characters a fuzzy match has in common with old_code, ignoring whitespace
+const minFuzzySimilarity = 0.9
+
+// maxFuzzyLines bounds the anchors matched fuzzily, each window of the file is compared with the anchor
+const maxFuzzyLines = 200
+
+// maxFuzzyWork bounds the characters diffed to match an anchor fuzzily, the anchor is reported as not found past it
+const maxFuzzyWork = 500_000
+
+// maxFuzzyDuration bounds the time spent matching an anchor fuzzily, the anchor is reported as not found past it
+const maxFuzzyDuration = time.Second
+
+// fuzzyDiffTimeout bounds each diff of a window with the anchor
+const fuzzyDiffTimeout = 20 * time.Millisecond
+
+// asciiSize is the number of ASCII characters counted to skip windows too different from an anchor
+const asciiSize = 128
+
+// kinds of Change
+const (
+	OpUpdate Op = "update"
+	OpAdd    Op = "add"
+	OpRemove Op = "remove"
+)
+
+// UndoDir holds a Snapshot per run that changed files, in <run-id>/manifest.json with the original files next to it
+const UndoDir = "./.brains/undo"
+
+const (
+	snapshotManifest = "manifest.json"
+	snapshotFiles    = "files"
+)
+
+// newFileMode is the mode of the files created by edits
+const newFileMode = 0o644
@@ -1,28 +1,14 @@
 package file_system
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
-	"github.com/charmbracelet/glamour"
-	"github.com/muesli/termenv"
 	"github.com/pterm/pterm"
-	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 func (f *FileSystemConfig) CreateFile(filePath, fileContents string) error {
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain("", fileContents, false)
-	diffText := dmp.DiffPrettyText(diffs)
-
-	r, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(100),
-		glamour.WithColorProfile(termenv.ANSI256),
-	)
-	renderedDiff, _ := r.Render(fmt.Sprintf("diff\n%s\n", diffText))
-	fmt.Println(renderedDiff)
+	printDiff("", fileContents)
 
 	// Ensure the target directory exists.
 	if dir := filepath.Dir(filePath); dir != "." {
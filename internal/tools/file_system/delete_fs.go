@@ -1,13 +1,9 @@
 package file_system
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/charmbracelet/glamour"
-	"github.com/muesli/termenv"
 	"github.com/pterm/pterm"
-	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 func (f *FileSystemConfig) DeleteFile(filePath string) error {
@@ -15,17 +11,7 @@ func (f *FileSystemConfig) DeleteFile(filePath string) error {
 	if readErr != nil {
 		pterm.Error.Printfln("Failed to read %s for diff: %v", filePath, readErr)
 	} else {
-		dmp := diffmatchpatch.New()
-		diffs := dmp.DiffMain(string(oldContentBytes), "", false)
-		diffText := dmp.DiffPrettyText(diffs)
-
-		r, _ := glamour.NewTermRenderer(
-			glamour.WithAutoStyle(),
-			glamour.WithWordWrap(100),
-			glamour.WithColorProfile(termenv.ANSI256),
-		)
-		renderedDiff, _ := r.Render(fmt.Sprintf("diff\n%s\n", diffText))
-		fmt.Println(renderedDiff)
+		printDiff(string(oldContentBytes), "")
 	}
 
 	if err := os.Remove(filePath); err != nil {
@@ -1,16 +1,67 @@
 package file_system
 
-import "github.com/madhuravius/brains/internal/tools"
+import (
+	"io/fs"
+	"time"
+
+	"github.com/madhuravius/brains/internal/tools"
+)
 
 type FileSystemConfig struct {
 	commonTools tools.CommonToolsImpl
+	// denied holds the deny list of redaction, these files are never read into the context
+	denied tools.CommonToolsImpl
 }
 
 type FileSystemImpl interface {
+	ApplyChanges(runID string, changes []Change) error
 	CreateFile(filePath, fileContents string) error
 	DeleteFile(filePath string) error
 	GetFileContents(path string) (string, error)
 	GetFileTree(root string) (string, error)
+	Glob(pattern string) ([]string, error)
+	IsDenied(path string) bool
+	IsIgnored(path string) bool
+	ReviewChange(change Change) bool
 	SetContextFromGlob(pattern string) (string, error)
+	SetDenyPatterns(patterns []string)
+	Snapshots() ([]Snapshot, error)
+	Undo(runID string, force bool) (*Snapshot, error)
 	UpdateFile(filePath, oldContent, newContent string, interactive bool) (bool, error)
 }
+
+// Match is how ApplyEdit found the anchor of an edit in a file
+type Match string
+
+// EditConflict is an edit whose anchor is missing or ambiguous, it is reported instead of being written
+type EditConflict struct {
+	Reason string
+}
+
+// Op is the kind of a Change
+type Op string
+
+// Change is an operation of a transaction applied by ApplyChanges. Before is the contents the change was planned
+// against, empty for OpAdd, and After the contents it writes, empty for OpRemove.
+type Change struct {
+	Op     Op
+	Path   string
+	Before string
+	After  string
+}
+
+// Snapshot records the files changed by a run in UndoDir, with what they held before the run
+type Snapshot struct {
+	RunID string         `json:"run_id"`
+	Time  time.Time      `json:"time"`
+	Files []SnapshotFile `json:"files"`
+}
+
+// SnapshotFile is a file changed by a run. Existed is false for the files the run created, AfterHash is the
+// sha256 of what the run left in the file, empty when it removed it.
+type SnapshotFile struct {
+	Path      string      `json:"path"`
+	Existed   bool        `json:"existed"`
+	Mode      fs.FileMode `json:"mode,omitempty"`
+	AfterHash string      `json:"after_hash,omitempty"`
+}
@@ -13,6 +13,10 @@ func (f *FileSystemConfig) GetFileContents(path string) (string, error) {
 	if f.commonTools.IsIgnored(path) {
 		return "", nil
 	}
+	if f.IsDenied(path) {
+		pterm.Warning.Printfln("%s is on the redaction deny list, it is not added to the context", path)
+		return "", nil
+	}
 
 	info, err := os.Stat(path)
 	if err != nil {
@@ -63,3 +67,23 @@ func (f *FileSystemConfig) SetContextFromGlob(pattern string) (string, error) {
 
 	return string(contentData), nil
 }
+
+// Glob lists the files matching pattern, skipping directories and ignored paths
+func (f *FileSystemConfig) Glob(pattern string) ([]string, error) {
+	matches, err := doublestar.Glob(os.DirFS("."), pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand glob: %w", err)
+	}
+
+	files := []string{}
+	for _, fpath := range matches {
+		if f.commonTools.IsIgnored(fpath) {
+			continue
+		}
+		if info, err := os.Stat(fpath); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, fpath)
+	}
+	return files, nil
+}
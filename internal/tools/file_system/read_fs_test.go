@@ -51,3 +51,45 @@ func TestSetContextFromGlobFileReadError(t *testing.T) {
 	_, err := f.SetContextFromGlob(filepath.Join(dirPath, "*"))
 	assert.Error(t, err)
 }
+
+func TestGlobSkipsDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package a"), 0o600)
+	_ = os.Mkdir(filepath.Join(tmpDir, "pkg.go"), 0o700)
+	_ = os.WriteFile(filepath.Join(tmpDir, "pkg.go", "b.go"), []byte("package b"), 0o600)
+
+	f, _ := file_system.NewFileSystemConfig()
+
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+
+	files, err := f.Glob("**/*.go")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.go", "pkg.go/b.go"}, files)
+
+	_, err = f.Glob("[")
+	assert.Error(t, err)
+}
+
+func TestSetContextFromGlobSkipsDeniedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, "certs"), 0o750)
+	_ = os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("content A"), 0o600)
+	_ = os.WriteFile(filepath.Join(tmpDir, "certs", "server.pem"), []byte("private"), 0o600)
+
+	origWD, _ := os.Getwd()
+	defer func() { _ = os.Chdir(origWD) }()
+	_ = os.Chdir(tmpDir)
+
+	f, _ := file_system.NewFileSystemConfig()
+	f.SetDenyPatterns([]string{"*.pem"})
+	assert.True(t, f.IsDenied("certs/server.pem"))
+	assert.False(t, f.IsDenied("a.txt"))
+
+	ctx, err := f.SetContextFromGlob("**/*")
+	assert.NoError(t, err)
+	var result map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(ctx), &result))
+	assert.Equal(t, map[string]string{"a.txt": "content A"}, result)
+}
@@ -0,0 +1,41 @@
+package file_system
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/muesli/termenv"
+	"github.com/pterm/pterm"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ReviewChange shows the diff of change and asks whether to apply it, nothing is written
+func (f *FileSystemConfig) ReviewChange(change Change) bool {
+	printDiff(change.Before, change.After)
+	question := fmt.Sprintf("Apply changes to %s?", change.Path)
+	switch change.Op {
+	case OpAdd:
+		question = fmt.Sprintf("Create file %s?", change.Path)
+	case OpRemove:
+		question = fmt.Sprintf("Delete file %s?", change.Path)
+	}
+	ok, _ := pterm.DefaultInteractiveConfirm.WithDefaultText(question).Show()
+	if !ok {
+		pterm.Warning.Printfln("Skipped: %s", change.Path)
+	}
+	return ok
+}
+
+func printDiff(oldContent, newContent string) {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(oldContent, newContent, false)
+	diffText := dmp.DiffPrettyText(diffs)
+
+	r, _ := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+		glamour.WithWordWrap(100),
+		glamour.WithColorProfile(termenv.ANSI256),
+	)
+	renderedDiff, _ := r.Render(fmt.Sprintf("diff\n%s\n", diffText))
+	fmt.Println(renderedDiff)
+}
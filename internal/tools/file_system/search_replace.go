@@ -0,0 +1,257 @@
+package file_system
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func (e *EditConflict) Error() string { return e.Reason }
+
+// ApplyEdit replaces the anchor oldCode with newCode in contents. The anchor is looked up as is first, then line
+// by line ignoring whitespace and then fuzzily, it has to match a single place of contents or an EditConflict is
+// returned.
+func ApplyEdit(contents, oldCode, newCode string) (string, Match, error) {
+	if strings.TrimSpace(oldCode) == "" {
+		return "", "", &EditConflict{Reason: "old_code is empty, use replace_files to replace a whole file"}
+	}
+	switch n := strings.Count(contents, oldCode); {
+	case n == 1:
+		return strings.Replace(contents, oldCode, newCode, 1), MatchExact, nil
+	case n > 1:
+		return "", "", &EditConflict{Reason: fmt.Sprintf("old_code matches %d places, include surrounding lines to make it unique", n)}
+	}
+
+	lines := strings.SplitAfter(contents, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	anchor := trimBlankLines(strings.Split(oldCode, "\n"))
+	match, starts := MatchWhitespace, whitespaceMatches(lines, anchor)
+	if len(starts) == 0 {
+		match, starts = MatchFuzzy, fuzzyMatches(lines, anchor)
+	}
+	switch {
+	case len(starts) == 0:
+		return "", "", &EditConflict{Reason: "old_code was not found, it must be copied from the current file"}
+	case len(starts) > 1:
+		return "", "", &EditConflict{Reason: fmt.Sprintf("old_code matches %d places ignoring whitespace, include surrounding lines to make it unique", len(starts))}
+	}
+	return replaceLines(lines, starts[0], anchor, newCode), match, nil
+}
+
+// whitespaceMatches are the lines where the anchor starts when whitespace is ignored
+func whitespaceMatches(lines, anchor []string) []int {
+	var starts []int
+	for start := 0; start+len(anchor) <= len(lines); start++ {
+		matched := true
+		for i, line := range anchor {
+			if normalizeSpace(lines[start+i]) != normalizeSpace(line) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// fuzzyMatches compares the anchor with every window of as many lines, the closest window comes first followed by
+// the windows not overlapping it that are close enough to make the match ambiguous. Windows whose characters can't
+// be close enough are skipped, the others are compared line by line, which finds typos cheaply, and only diffed
+// as a whole when no window matched that way. Diffing stops at maxFuzzyWork characters or after maxFuzzyDuration
+// and the anchor is then not found.
+func fuzzyMatches(lines, anchor []string) []int {
+	if len(anchor) > maxFuzzyLines || len(anchor) > len(lines) {
+		return nil
+	}
+	dmp := diffmatchpatch.New()
+	dmp.DiffTimeout = fuzzyDiffTimeout
+	want := normalizeLines(anchor)
+	wantLines := strings.Split(want, "\n")
+	wantCounts := asciiCounts(want)
+	wantLineCounts := make([][asciiSize]int, len(wantLines))
+	for i, line := range wantLines {
+		wantLineCounts[i] = asciiCounts(line)
+	}
+
+	normalized := make([]string, len(lines))
+	lineCounts := make([][asciiSize]int, len(lines))
+	for i, line := range lines {
+		normalized[i] = normalizeSpace(line)
+		lineCounts[i] = asciiCounts(normalized[i])
+	}
+	var windowCounts [asciiSize]int
+	for i := range anchor {
+		addCounts(&windowCounts, &lineCounts[i], 1)
+	}
+
+	var candidates []int
+	scores := map[int]float64{}
+	for start := 0; start+len(anchor) <= len(lines); start++ {
+		if start > 0 {
+			addCounts(&windowCounts, &lineCounts[start-1], -1)
+			addCounts(&windowCounts, &lineCounts[start+len(anchor)-1], 1)
+		}
+		length := windowLength(normalized[start : start+len(anchor)])
+		longest := max(length, len(want))
+		if longest == 0 || float64(min(length, len(want)))/float64(longest) < minFuzzySimilarity {
+			continue
+		}
+		// an edit changes the counts of at most two characters, half their difference bounds the distance
+		maxDistance := int((1 - minFuzzySimilarity) * float64(longest))
+		if countsDistance(&windowCounts, &wantCounts)/2 > maxDistance {
+			continue
+		}
+		candidates = append(candidates, start)
+		if distance := alignedDistance(dmp, normalized[start:start+len(anchor)], lineCounts[start:], wantLines, wantLineCounts, maxDistance); distance <= maxDistance {
+			scores[start] = 1 - float64(distance)/float64(longest)
+		}
+	}
+
+	if len(scores) == 0 {
+		// lines were added or removed in the anchor, only a diff of the whole window lines them up
+		deadline := time.Now().Add(maxFuzzyDuration)
+		work := 0
+		for _, start := range candidates {
+			window := strings.Join(normalized[start:start+len(anchor)], "\n")
+			if work += len(window); work > maxFuzzyWork || time.Now().After(deadline) {
+				return nil
+			}
+			longest := max(len(window), len(want))
+			if score := 1 - float64(dmp.DiffLevenshtein(dmp.DiffMain(window, want, true)))/float64(longest); score >= minFuzzySimilarity {
+				scores[start] = score
+			}
+		}
+	}
+
+	best, bestScore := -1, 0.0
+	for start, score := range scores {
+		if score > bestScore || (score == bestScore && start < best) {
+			best, bestScore = start, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	starts := []int{best}
+	for start := range scores {
+		if start <= best-len(anchor) || start >= best+len(anchor) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// alignedDistance is the distance of each line of window to the line of the anchor at the same place, summed up
+// until it is over maxDistance. The counts of the lines skip the diffs of lines too far apart.
+func alignedDistance(dmp *diffmatchpatch.DiffMatchPatch, window []string, counts [][asciiSize]int, anchor []string, anchorCounts [][asciiSize]int, maxDistance int) int {
+	bound := 0
+	for i := range window {
+		if bound += countsDistance(&counts[i], &anchorCounts[i]) / 2; bound > maxDistance {
+			return bound
+		}
+	}
+	distance := 0
+	for i, line := range window {
+		if line == anchor[i] {
+			continue
+		}
+		if distance += dmp.DiffLevenshtein(dmp.DiffMain(line, anchor[i], false)); distance > maxDistance {
+			return distance
+		}
+	}
+	return distance
+}
+
+// windowLength is the length of lines joined with newlines
+func windowLength(lines []string) int {
+	length := len(lines) - 1
+	for _, line := range lines {
+		length += len(line)
+	}
+	return length
+}
+
+// asciiCounts counts the ASCII characters of s, other characters are left out so the counts still bound the
+// edit distance
+func asciiCounts(s string) [asciiSize]int {
+	var counts [asciiSize]int
+	for i := 0; i < len(s); i++ {
+		if s[i] < asciiSize {
+			counts[s[i]]++
+		}
+	}
+	return counts
+}
+
+func addCounts(counts, other *[asciiSize]int, sign int) {
+	for i := range counts {
+		counts[i] += sign * other[i]
+	}
+}
+
+func countsDistance(a, b *[asciiSize]int) int {
+	distance := 0
+	for i := range a {
+		distance += max(a[i]-b[i], b[i]-a[i])
+	}
+	return distance
+}
+
+// replaceLines puts newCode in place of the len(anchor) lines at start, reindented when the file indents the
+// anchor differently
+func replaceLines(lines []string, start int, anchor []string, newCode string) string {
+	window := lines[start : start+len(anchor)]
+	replacement := strings.Join(trimBlankLines(strings.Split(newCode, "\n")), "\n")
+	fileIndent, anchorIndent := firstIndent(window), firstIndent(anchor)
+	if replacement != "" && fileIndent != anchorIndent {
+		reindented := strings.Split(replacement, "\n")
+		for i, line := range reindented {
+			if strings.TrimSpace(line) != "" && strings.HasPrefix(line, anchorIndent) {
+				reindented[i] = fileIndent + strings.TrimPrefix(line, anchorIndent)
+			}
+		}
+		replacement = strings.Join(reindented, "\n")
+	}
+	if replacement != "" && strings.HasSuffix(window[len(window)-1], "\n") {
+		replacement += "\n"
+	}
+	return strings.Join(lines[:start], "") + replacement + strings.Join(lines[start+len(anchor):], "")
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// firstIndent is the indentation of the first line that is not blank
+func firstIndent(lines []string) string {
+	for _, line := range lines {
+		if trimmed := strings.TrimLeft(line, " \t"); strings.TrimSpace(trimmed) != "" {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
+
+func normalizeSpace(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func normalizeLines(lines []string) string {
+	normalized := make([]string, len(lines))
+	for i, line := range lines {
+		normalized[i] = normalizeSpace(line)
+	}
+	return strings.Join(normalized, "\n")
+}
@@ -0,0 +1,141 @@
+package file_system_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+const sample = `package main
+
+func main() {
+	if ready {
+		start()
+	}
+	stop()
+}
+`
+
+func TestApplyEdit(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents, oldCode, newCode string
+		want                       string
+		match                      file_system.Match
+		conflict                   string
+	}{
+		"exact": {
+			contents: sample,
+			oldCode:  "\tstop()\n",
+			newCode:  "\tstop(true)\n",
+			want:     "package main\n\nfunc main() {\n\tif ready {\n\t\tstart()\n\t}\n\tstop(true)\n}\n",
+			match:    file_system.MatchExact,
+		},
+		"whitespace is ignored and the replacement reindented": {
+			contents: sample,
+			oldCode:  "if ready {\n    start()\n}",
+			newCode:  "if ready {\n    start()\n    log()\n}",
+			want:     "package main\n\nfunc main() {\n\tif ready {\n\t    start()\n\t    log()\n\t}\n\tstop()\n}\n",
+			match:    file_system.MatchWhitespace,
+		},
+		"fuzzy": {
+			contents: sample,
+			oldCode:  "\tif ready {\n\t\tstartt()\n\t}\n",
+			newCode:  "\tif ready {\n\t\tgo start()\n\t}\n",
+			want:     "package main\n\nfunc main() {\n\tif ready {\n\t\tgo start()\n\t}\n\tstop()\n}\n",
+			match:    file_system.MatchFuzzy,
+		},
+		"deletion removes the lines": {
+			contents: sample,
+			oldCode:  "  stop()",
+			newCode:  "",
+			want:     "package main\n\nfunc main() {\n\tif ready {\n\t\tstart()\n\t}\n}\n",
+			match:    file_system.MatchWhitespace,
+		},
+		"ambiguous exact anchor": {
+			contents: "a()\nb()\na()\n",
+			oldCode:  "a()",
+			newCode:  "c()",
+			conflict: "old_code matches 2 places, include surrounding lines to make it unique",
+		},
+		"ambiguous whitespace anchor": {
+			contents: "\ta()\nb()\n  a()\n",
+			oldCode:  "a() ",
+			newCode:  "c()",
+			conflict: "old_code matches 2 places ignoring whitespace, include surrounding lines to make it unique",
+		},
+		"missing anchor": {
+			contents: sample,
+			oldCode:  "func other() {}",
+			newCode:  "func other() { return }",
+			conflict: "old_code was not found, it must be copied from the current file",
+		},
+		"empty anchor": {
+			contents: sample,
+			oldCode:  "\n",
+			newCode:  "package other\n",
+			conflict: "old_code is empty, use replace_files to replace a whole file",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, match, err := file_system.ApplyEdit(tc.contents, tc.oldCode, tc.newCode)
+			if tc.conflict != "" {
+				var conflict *file_system.EditConflict
+				assert.ErrorAs(t, err, &conflict)
+				assert.EqualError(t, err, tc.conflict)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.match, match)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestApplyEditMissingAnchorInLargeFile(t *testing.T) {
+	for _, size := range []struct {
+		file, anchor int
+		reversed     bool
+	}{{500, 20, false}, {3000, 120, false}, {3000, 120, true}} {
+		var file, anchor strings.Builder
+		lines := make([]string, size.file)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("\tvalue%d := compute(%d, %d)\n", i, i*7, i%13)
+			file.WriteString(lines[i])
+		}
+		for i := range size.anchor {
+			if size.reversed {
+				// the same characters as a window of the file, only a diff tells them apart
+				anchor.WriteString(lines[size.file/2+size.anchor-1-i])
+			} else {
+				// lines of the same shape as the file that are nowhere in it
+				fmt.Fprintf(&anchor, "\tresult%d := compute(%d, %d)\n", i*3, i*11, i%5)
+			}
+		}
+
+		started := time.Now()
+		_, _, err := file_system.ApplyEdit(file.String(), anchor.String(), "")
+		assert.EqualError(t, err, "old_code was not found, it must be copied from the current file")
+		assert.Less(t, time.Since(started), 5*time.Second, "%d line anchor in a %d line file", size.anchor, size.file)
+	}
+}
+
+func TestApplyEditFuzzyAnchorInLargeFile(t *testing.T) {
+	var file strings.Builder
+	lines := make([]string, 3000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("\tvalue%d := compute(%d, %d)\n", i, i*7, i%13)
+		file.WriteString(lines[i])
+	}
+	anchor := strings.Join(lines[1500:1620], "")
+	anchor = strings.Replace(anchor, "compute(10500", "compte(10500", 1)
+
+	got, match, err := file_system.ApplyEdit(file.String(), anchor, "\treplaced()\n")
+	assert.NoError(t, err)
+	assert.Equal(t, file_system.MatchFuzzy, match)
+	assert.Equal(t, strings.Join(lines[:1500], "")+"\treplaced()\n"+strings.Join(lines[1620:], ""), got)
+}
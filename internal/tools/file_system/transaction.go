@@ -0,0 +1,305 @@
+package file_system
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// original is a file as it was before a transaction
+type original struct {
+	existed  bool
+	contents []byte
+	mode     fs.FileMode
+}
+
+// ApplyChanges applies every change or none of them. Each file has to hold what the change was planned against,
+// its original is kept in the snapshot of runID for Undo, and a failing write restores the files already written.
+func (f *FileSystemConfig) ApplyChanges(runID string, changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	originals, err := readOriginals(changes)
+	if err != nil {
+		return fmt.Errorf("nothing was applied: %w", err)
+	}
+	restoreManifest, err := snapshot(runID, changes, originals)
+	if err != nil {
+		return fmt.Errorf("unable to snapshot the files, nothing was applied: %w", err)
+	}
+
+	for i, change := range changes {
+		if err := applyChange(change, originals[i]); err != nil {
+			errs := []error{fmt.Errorf("unable to %s %s, the changes were rolled back: %w", change.Op, change.Path, err)}
+			errs = append(errs, rollback(changes[:i], originals[:i]), restoreManifest())
+			return errors.Join(errs...)
+		}
+	}
+	return nil
+}
+
+// readOriginals reads the files of changes, failing when one of them changed on disk since it was read
+func readOriginals(changes []Change) ([]original, error) {
+	originals := make([]original, len(changes))
+	seen := map[string]bool{}
+	var errs []error
+	for i, change := range changes {
+		if seen[change.Path] {
+			errs = append(errs, fmt.Errorf("%s has more than one change", change.Path))
+			continue
+		}
+		seen[change.Path] = true
+
+		data, err := os.ReadFile(change.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if change.Op != OpAdd {
+				errs = append(errs, fmt.Errorf("%s was removed since it was read", change.Path))
+			}
+		case err != nil:
+			errs = append(errs, err)
+		case change.Op == OpAdd:
+			errs = append(errs, fmt.Errorf("%s was created since the edits were planned", change.Path))
+		case string(data) != change.Before:
+			errs = append(errs, fmt.Errorf("%s changed on disk since it was read", change.Path))
+		default:
+			info, err := os.Stat(change.Path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			originals[i] = original{existed: true, contents: data, mode: info.Mode().Perm()}
+		}
+	}
+	return originals, errors.Join(errs...)
+}
+
+func applyChange(change Change, orig original) error {
+	switch change.Op {
+	case OpRemove:
+		return os.Remove(change.Path)
+	case OpAdd:
+		if err := os.MkdirAll(filepath.Dir(change.Path), 0o750); err != nil {
+			return err
+		}
+		return writeAtomic(change.Path, []byte(change.After), newFileMode)
+	}
+	return writeAtomic(change.Path, []byte(change.After), orig.mode)
+}
+
+// rollback puts back the originals of the changes already applied, latest first
+func rollback(changes []Change, originals []original) error {
+	var errs []error
+	for i := len(changes) - 1; i >= 0; i-- {
+		if err := restoreFile(changes[i].Path, originals[i]); err != nil {
+			errs = append(errs, fmt.Errorf("unable to roll back %s: %w", changes[i].Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func restoreFile(path string, orig original) error {
+	if !orig.existed {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return writeAtomic(path, orig.contents, orig.mode)
+}
+
+// writeAtomic replaces path through a temporary file in the same directory, so it never holds partial contents
+func writeAtomic(path string, data []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".brains-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// snapshot records the originals of changes in the snapshot of runID. A run edits files several times when its
+// edits are repaired, the first original of a file is kept so Undo goes back to before the run. The returned
+// function puts the previous manifest back when the changes could not be applied.
+func snapshot(runID string, changes []Change, originals []original) (func() error, error) {
+	dir := filepath.Join(UndoDir, runID)
+	manifestPath := filepath.Join(dir, snapshotManifest)
+	previous, err := os.ReadFile(manifestPath) // #nosec G304 -- run IDs come from the event log or brains itself
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	s := &Snapshot{RunID: runID, Time: time.Now().UTC()}
+	if previous != nil {
+		if err := json.Unmarshal(previous, s); err != nil {
+			return nil, fmt.Errorf("invalid snapshot %s: %w", manifestPath, err)
+		}
+	}
+
+	for i, change := range changes {
+		afterHash := ""
+		if change.Op != OpRemove {
+			afterHash = hashContents([]byte(change.After))
+		}
+		idx := slices.IndexFunc(s.Files, func(file SnapshotFile) bool { return file.Path == change.Path })
+		if idx >= 0 {
+			s.Files[idx].AfterHash = afterHash
+			continue
+		}
+		s.Files = append(s.Files, SnapshotFile{
+			Path:      change.Path,
+			Existed:   originals[i].existed,
+			Mode:      originals[i].mode,
+			AfterHash: afterHash,
+		})
+		if !originals[i].existed {
+			continue
+		}
+		copyPath := filepath.Join(dir, snapshotFiles, change.Path)
+		if err := os.MkdirAll(filepath.Dir(copyPath), 0o750); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(copyPath, originals[i].contents, 0o600); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(manifestPath, data, 0o600); err != nil {
+		return nil, err
+	}
+	return func() error {
+		if previous == nil {
+			return os.RemoveAll(dir)
+		}
+		return os.WriteFile(manifestPath, previous, 0o600)
+	}, nil
+}
+
+// Snapshots lists the runs that can be undone, oldest first
+func (f *FileSystemConfig) Snapshots() ([]Snapshot, error) {
+	entries, err := os.ReadDir(UndoDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		s, err := loadSnapshot(entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, *s)
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return strings.Compare(a.RunID, b.RunID) })
+	return snapshots, nil
+}
+
+// Undo restores the files changed by runID, the latest run when runID is empty, and removes its snapshot. Files
+// changed again since the run are not restored unless force is set.
+func (f *FileSystemConfig) Undo(runID string, force bool) (*Snapshot, error) {
+	if runID == "" {
+		snapshots, err := f.Snapshots()
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("no edits to undo in %s", UndoDir)
+		}
+		runID = snapshots[len(snapshots)-1].RunID
+	}
+	s, err := loadSnapshot(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		var changed []string
+		for _, file := range s.Files {
+			current := ""
+			if data, err := os.ReadFile(file.Path); err == nil {
+				current = hashContents(data)
+			}
+			if current != file.AfterHash {
+				changed = append(changed, file.Path)
+			}
+		}
+		if len(changed) > 0 {
+			return nil, fmt.Errorf("%s changed since run %s edited them, use --force to restore them anyway", strings.Join(changed, ", "), runID)
+		}
+	}
+
+	dir := filepath.Join(UndoDir, runID)
+	var errs []error
+	for _, file := range s.Files {
+		orig := original{existed: file.Existed, mode: file.Mode}
+		if file.Existed {
+			if orig.contents, err = os.ReadFile(filepath.Join(dir, snapshotFiles, file.Path)); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := restoreFile(file.Path, orig); err != nil {
+			errs = append(errs, fmt.Errorf("unable to restore %s: %w", file.Path, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, os.RemoveAll(dir)
+}
+
+func loadSnapshot(runID string) (*Snapshot, error) {
+	if !filepath.IsLocal(runID) || strings.ContainsRune(runID, filepath.Separator) {
+		return nil, fmt.Errorf("invalid run ID %q", runID)
+	}
+	data, err := os.ReadFile(filepath.Join(UndoDir, runID, snapshotManifest)) // #nosec G304 -- checked to be a directory of UndoDir
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no snapshot for run %s, see brains undo --list", runID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot of run %s: %w", runID, err)
+	}
+	return s, nil
+}
+
+func hashContents(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package file_system_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/tools/file_system"
+)
+
+func setupTransaction(t *testing.T) file_system.FileSystemImpl {
+	t.Helper()
+	orig, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	assert.NoError(t, os.WriteFile("edit.txt", []byte("before\n"), 0o600))
+	assert.NoError(t, os.WriteFile("remove.txt", []byte("gone\n"), 0o644))
+	fs, err := file_system.NewFileSystemConfig()
+	assert.NoError(t, err)
+	return fs
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestApplyChangesAndUndo(t *testing.T) {
+	fs := setupTransaction(t)
+
+	err := fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "before\n", After: "after\n"},
+		{Op: file_system.OpAdd, Path: "nested/new.txt", After: "new\n"},
+		{Op: file_system.OpRemove, Path: "remove.txt", Before: "gone\n"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", readFile(t, "edit.txt"))
+	assert.Equal(t, "new\n", readFile(t, "nested/new.txt"))
+	assert.NoFileExists(t, "remove.txt")
+	info, err := os.Stat("edit.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "the mode of edited files is kept")
+
+	snapshots, err := fs.Snapshots()
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "run-1", snapshots[0].RunID)
+	assert.Len(t, snapshots[0].Files, 3)
+
+	s, err := fs.Undo("", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "run-1", s.RunID)
+	assert.Equal(t, "before\n", readFile(t, "edit.txt"))
+	assert.Equal(t, "gone\n", readFile(t, "remove.txt"))
+	assert.NoFileExists(t, "nested/new.txt")
+
+	snapshots, err = fs.Snapshots()
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+	_, err = fs.Undo("", false)
+	assert.ErrorContains(t, err, "no edits to undo")
+}
+
+func TestApplyChangesRejectsFilesChangedOnDisk(t *testing.T) {
+	fs := setupTransaction(t)
+
+	err := fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpAdd, Path: "new.txt", After: "new\n"},
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "stale\n", After: "after\n"},
+	})
+	assert.ErrorContains(t, err, "nothing was applied: edit.txt changed on disk since it was read")
+	assert.NoFileExists(t, "new.txt")
+	assert.Equal(t, "before\n", readFile(t, "edit.txt"))
+	assert.NoDirExists(t, filepath.Join(file_system.UndoDir, "run-1"))
+}
+
+func TestApplyChangesRollsBackOnFailure(t *testing.T) {
+	fs := setupTransaction(t)
+	// the parent of the new file is a dangling link, so it can't be created and the second change fails
+	assert.NoError(t, os.Symlink("missing", "blocked"))
+
+	err := fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "before\n", After: "after\n"},
+		{Op: file_system.OpAdd, Path: "blocked/new.txt", After: "new\n"},
+	})
+	assert.ErrorContains(t, err, "the changes were rolled back")
+	assert.Equal(t, "before\n", readFile(t, "edit.txt"))
+	assert.NoDirExists(t, filepath.Join(file_system.UndoDir, "run-1"))
+}
+
+func TestUndoKeepsTheFirstOriginalOfARun(t *testing.T) {
+	fs := setupTransaction(t)
+
+	assert.NoError(t, fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "before\n", After: "first\n"},
+	}))
+	assert.NoError(t, fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "first\n", After: "second\n"},
+	}))
+
+	s, err := fs.Undo("run-1", false)
+	assert.NoError(t, err)
+	assert.Len(t, s.Files, 1)
+	assert.Equal(t, "before\n", readFile(t, "edit.txt"))
+}
+
+func TestUndoRefusesFilesChangedSinceTheRun(t *testing.T) {
+	fs := setupTransaction(t)
+
+	assert.NoError(t, fs.ApplyChanges("run-1", []file_system.Change{
+		{Op: file_system.OpUpdate, Path: "edit.txt", Before: "before\n", After: "after\n"},
+	}))
+	assert.NoError(t, os.WriteFile("edit.txt", []byte("by hand\n"), 0o600))
+
+	_, err := fs.Undo("run-1", false)
+	assert.EqualError(t, err, "edit.txt changed since run run-1 edited them, use --force to restore them anyway")
+	assert.Equal(t, "by hand\n", readFile(t, "edit.txt"))
+
+	_, err = fs.Undo("run-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "before\n", readFile(t, "edit.txt"))
+
+	_, err = fs.Undo("run-1", false)
+	assert.EqualError(t, err, "no snapshot for run run-1, see brains undo --list")
+	_, err = fs.Undo("../run-1", false)
+	assert.EqualError(t, err, `invalid run ID "../run-1"`)
+}
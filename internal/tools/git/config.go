@@ -0,0 +1,6 @@
+package git
+
+// NewGitConfig runs git in dir, the current directory when empty
+func NewGitConfig(dir string) GitImpl {
+	return &GitConfig{dir: dir}
+}
@@ -0,0 +1,7 @@
+package git
+
+// BranchPrefix starts the name of the branches created for edits, see BranchName
+const BranchPrefix = "brains/"
+
+// maxSlugLength bounds the part of a branch name made from the prompt
+const maxSlugLength = 40
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// run runs git with args and returns its output without the trailing newline, the error holds what git printed on stderr
+func (g *GitConfig) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...) // #nosec G204 -- the arguments are built by brains, paths follow "--"
+	cmd.Dir = g.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// IsRepo reports whether git is installed and the directory is in a work tree
+func (g *GitConfig) IsRepo() bool {
+	out, err := g.run("rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// DirtyFiles returns the paths with changes that are not committed, staged or not, including untracked files
+func (g *GitConfig) DirtyFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	// porcelain paths are relative to the repository root, the prefix is where the directory is in it
+	prefix, err := g.run("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	out, err := g.run(append([]string{"status", "--porcelain=v1", "-z", "--untracked-files=all", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			// renames and copies are followed by their source
+			i++
+		}
+		if rel, err := filepath.Rel(filepath.FromSlash(prefix+"."), filepath.FromSlash(entry[3:])); err == nil {
+			changed[rel] = true
+		}
+	}
+	var dirty []string
+	for _, path := range paths {
+		if changed[filepath.Clean(path)] {
+			dirty = append(dirty, path)
+		}
+	}
+	return dirty, nil
+}
+
+// CurrentBranch is the checked out branch, empty when HEAD is detached
+func (g *GitConfig) CurrentBranch() (string, error) {
+	return g.run("branch", "--show-current")
+}
+
+// CreateBranch creates and checks out name, or name-2, name-3... when it is taken, the changes of the work tree
+// are kept. The name of the new branch is returned.
+func (g *GitConfig) CreateBranch(name string) (string, error) {
+	branch := name
+	for n := 2; ; n++ {
+		if _, err := g.run("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+			break
+		}
+		branch = fmt.Sprintf("%s-%d", name, n)
+	}
+	if _, err := g.run("switch", "-c", branch); err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+// Commit commits paths, including removed ones, with message and returns the short hash of the commit. Other
+// changes of the index are left out of it.
+func (g *GitConfig) Commit(message string, paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", errors.New("no paths to commit")
+	}
+	if _, err := g.run(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	if _, err := g.run(append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return g.run("rev-parse", "--short", "HEAD")
+}
+
+// BranchName is BranchPrefix followed by a slug of prompt, ex: brains/add-retries-to-the-client
+func BranchName(prompt string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(prompt), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "edits"
+	}
+	return BranchPrefix + slug
+}
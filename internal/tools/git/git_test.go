@@ -0,0 +1,117 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/tools/git"
+)
+
+// setupRepo creates a repository with a committed file in a temporary directory
+func setupRepo(t *testing.T) (string, git.GitImpl) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main"},
+		{"config", "user.name", "brains"},
+		{"config", "user.email", "brains@example.com"},
+	} {
+		gitCmd(t, dir, args...)
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	gitCmd(t, dir, "add", "main.go")
+	gitCmd(t, dir, "commit", "--quiet", "-m", "initial")
+	return dir, git.NewGitConfig(dir)
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+	return string(out)
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+}
+
+func TestIsRepo(t *testing.T) {
+	_, g := setupRepo(t)
+	assert.True(t, g.IsRepo())
+	assert.False(t, git.NewGitConfig(t.TempDir()).IsRepo())
+}
+
+func TestDirtyFiles(t *testing.T) {
+	dir, g := setupRepo(t)
+	writeFile(t, filepath.Join(dir, "clean.go"), "package main\n")
+	gitCmd(t, dir, "add", "clean.go")
+	gitCmd(t, dir, "commit", "--quiet", "-m", "clean")
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "sub", "untracked.go"), "package sub\n")
+	writeFile(t, filepath.Join(dir, "staged.go"), "package main\n")
+	gitCmd(t, dir, "add", "staged.go")
+
+	dirty, err := g.DirtyFiles([]string{"main.go", "clean.go", "sub/untracked.go", "./staged.go", "new.go"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "sub/untracked.go", "./staged.go"}, dirty)
+
+	// paths are relative to the directory git runs in, not to the repository root
+	dirty, err = git.NewGitConfig(filepath.Join(dir, "sub")).DirtyFiles([]string{"untracked.go", "../clean.go"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"untracked.go"}, dirty)
+}
+
+func TestCommitOnANewBranch(t *testing.T) {
+	dir, g := setupRepo(t)
+	writeFile(t, filepath.Join(dir, "other.go"), "package main\n")
+	gitCmd(t, dir, "add", "other.go")
+
+	branch, err := g.CreateBranch(git.BranchName("Add a main function!"))
+	assert.NoError(t, err)
+	assert.Equal(t, "brains/add-a-main-function", branch)
+	current, err := g.CurrentBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, branch, current)
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "added.go"), "package main\n")
+	hash, err := g.Commit("Add a main function\n\nsummary", []string{"main.go", "added.go"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hash)
+
+	assert.Equal(t, "added.go\nmain.go\n", gitCmd(t, dir, "show", "--name-only", "--format=", "HEAD"))
+	assert.Equal(t, "Add a main function\n\nsummary\n\n", gitCmd(t, dir, "log", "-1", "--format=%B"))
+	// the staged file was not part of the edits and stays staged
+	assert.Equal(t, "A  other.go\n", gitCmd(t, dir, "status", "--porcelain"))
+
+	// a taken name gets a suffix
+	branch, err = g.CreateBranch(git.BranchName("add a main function"))
+	assert.NoError(t, err)
+	assert.Equal(t, "brains/add-a-main-function-2", branch)
+}
+
+func TestCommitRemovedFile(t *testing.T) {
+	dir, g := setupRepo(t)
+	assert.NoError(t, os.Remove(filepath.Join(dir, "main.go")))
+
+	_, err := g.Commit("Remove main.go", []string{"main.go"})
+	assert.NoError(t, err)
+	assert.Empty(t, gitCmd(t, dir, "status", "--porcelain"))
+}
+
+func TestBranchName(t *testing.T) {
+	assert.Equal(t, "brains/edits", git.BranchName("!!!"))
+	assert.Equal(t, "brains/fix-the-login-redirect-when-the-session", git.BranchName("Fix the login redirect when the session expires on mobile"))
+}
@@ -0,0 +1,13 @@
+package git
+
+type GitConfig struct {
+	dir string
+}
+
+type GitImpl interface {
+	Commit(message string, paths []string) (string, error)
+	CreateBranch(name string) (string, error)
+	CurrentBranch() (string, error)
+	DirtyFiles(paths []string) ([]string, error)
+	IsRepo() bool
+}
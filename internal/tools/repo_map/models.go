@@ -14,6 +14,7 @@ type RepoMapImpl interface {
 	GetFileCount() int
 	GetFiles() []*FileMap
 	ParseFile(ctx context.Context, path, lang string) (*FileMap, error)
+	Search(query string, limit int) []string
 	ToPrompt() string
 }
 
@@ -23,22 +23,7 @@ func (r *RepoMapConfig) ToPrompt() string {
 	for _, f := range r.Files {
 		sb.WriteString(fmt.Sprintf("### File: %s\n", f.Path))
 		for _, sym := range f.Symbols {
-			if len(sym.Params) > 0 {
-				var parts []string
-				for _, p := range sym.Params {
-					switch {
-					case p.Type != "" && p.Name != "":
-						parts = append(parts, fmt.Sprintf("%s: %s", p.Name, p.Type))
-					case p.Name != "":
-						parts = append(parts, p.Name)
-					case p.Type != "":
-						parts = append(parts, p.Type)
-					}
-				}
-				sb.WriteString(fmt.Sprintf("- %s %s(%s)\n", sym.Type, sym.Name, strings.Join(parts, ", ")))
-			} else {
-				sb.WriteString(fmt.Sprintf("- %s %s\n", sym.Type, sym.Name))
-			}
+			sb.WriteString(fmt.Sprintf("- %s\n", symbolSignature(sym)))
 			if sym.Doc != "" {
 				doc := normalizeDocForPrompt(sym.Doc)
 				if doc != "" {
@@ -51,6 +36,47 @@ func (r *RepoMapConfig) ToPrompt() string {
 	return sb.String()
 }
 
+// Search returns the files and symbols whose path or name contains query (case insensitive), at most limit lines
+func (r *RepoMapConfig) Search(query string, limit int) []string {
+	query = strings.ToLower(query)
+	var results []string
+	for _, f := range r.Files {
+		if len(results) >= limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(f.Path), query) {
+			results = append(results, fmt.Sprintf("%s (%s, %d symbols)", f.Path, f.Language, len(f.Symbols)))
+		}
+		for _, sym := range f.Symbols {
+			if len(results) >= limit {
+				break
+			}
+			if strings.Contains(strings.ToLower(sym.Name), query) {
+				results = append(results, fmt.Sprintf("%s:%d-%d %s", f.Path, sym.Start, sym.End, symbolSignature(sym)))
+			}
+		}
+	}
+	return results
+}
+
+func symbolSignature(sym *SymbolMap) string {
+	if len(sym.Params) == 0 {
+		return fmt.Sprintf("%s %s", sym.Type, sym.Name)
+	}
+	var parts []string
+	for _, p := range sym.Params {
+		switch {
+		case p.Type != "" && p.Name != "":
+			parts = append(parts, fmt.Sprintf("%s: %s", p.Name, p.Type))
+		case p.Name != "":
+			parts = append(parts, p.Name)
+		case p.Type != "":
+			parts = append(parts, p.Type)
+		}
+	}
+	return fmt.Sprintf("%s %s(%s)", sym.Type, sym.Name, strings.Join(parts, ", "))
+}
+
 func (r *RepoMapConfig) BuildRepoMap(ctx context.Context, repoRoot string) error {
 	r.Path = repoRoot
 
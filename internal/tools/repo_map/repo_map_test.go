@@ -293,3 +293,15 @@ func TestElixir_DocsAndParams(t *testing.T) {
 	assert.Contains(t, out, "- function fun_with_separated_doc")
 	assert.NotContains(t, out, "function fun_with_separated_doc()\n    Doc: This doc should be ignored.")
 }
+
+func TestRepoMapSearch(t *testing.T) {
+	repoMap, err := repo_map.NewRepoMapConfig(context.Background(), "test_fixtures/go")
+	assert.Nil(t, err)
+
+	results := repoMap.Search("sayhello", 10)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0], "function SayHello(")
+
+	assert.Len(t, repoMap.Search("", 2), 2)
+	assert.Empty(t, repoMap.Search("does-not-exist", 10))
+}
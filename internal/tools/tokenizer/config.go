@@ -0,0 +1,16 @@
+package tokenizer
+
+import "strings"
+
+// ForModel returns the tokenizer for the family of modelID, an approximation when no vocabulary is bundled
+func ForModel(modelID string) Tokenizer {
+	id := strings.ToLower(modelID)
+	for _, f := range families {
+		for _, match := range f.match {
+			if strings.Contains(id, match) {
+				return &approximateTokenizer{family: f.family}
+			}
+		}
+	}
+	return &approximateTokenizer{family: defaultFamily}
+}
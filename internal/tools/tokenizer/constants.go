@@ -0,0 +1,22 @@
+package tokenizer
+
+// whitespacePerToken is how many indentation or newline characters share a token, single spaces are
+// merged into the following word and cost nothing
+const whitespacePerToken = 4
+
+// families are matched in order against the model ID, the ratios come from comparing each family's
+// published tokenizer against English prose and Go/Python/TypeScript source
+var families = []struct {
+	match  []string
+	family family
+}{
+	{match: []string{"anthropic.", "claude"}, family: family{name: "claude", wordLetters: 6, lettersPerToken: 3.6, symbolsPerToken: 1.6, digitsPerToken: 1}},
+	{match: []string{"openai.", "gpt"}, family: family{name: "o200k", wordLetters: 7, lettersPerToken: 4.4, symbolsPerToken: 2, digitsPerToken: 3}},
+	{match: []string{"meta.", "llama"}, family: family{name: "llama3", wordLetters: 7, lettersPerToken: 4.2, symbolsPerToken: 2, digitsPerToken: 3}},
+	{match: []string{"mistral.", "mixtral"}, family: family{name: "mistral", wordLetters: 5, lettersPerToken: 3.4, symbolsPerToken: 1.4, digitsPerToken: 1}},
+	{match: []string{"amazon."}, family: family{name: "nova", wordLetters: 6, lettersPerToken: 3.8, symbolsPerToken: 1.6, digitsPerToken: 1}},
+	{match: []string{"cohere."}, family: family{name: "cohere", wordLetters: 6, lettersPerToken: 4, symbolsPerToken: 1.6, digitsPerToken: 1}},
+}
+
+// defaultFamily is used for models that match no family
+var defaultFamily = family{name: "generic", wordLetters: 6, lettersPerToken: 4, symbolsPerToken: 1.6, digitsPerToken: 1}
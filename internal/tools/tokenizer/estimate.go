@@ -0,0 +1,59 @@
+package tokenizer
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pieceRegexp splits text like a BPE pre-tokenizer: letter runs, digit runs, punctuation runs and whitespace
+var pieceRegexp = regexp.MustCompile(`\p{L}+|\p{N}+|[^\s\p{L}\p{N}]+|\s+`)
+
+func (t *approximateTokenizer) Name() string { return t.family.name + " (approximate)" }
+
+func (t *approximateTokenizer) Count(text string) int {
+	tokens := 0
+	for _, piece := range pieceRegexp.FindAllString(text, -1) {
+		tokens += t.countPiece(piece)
+	}
+	return tokens
+}
+
+func (t *approximateTokenizer) countPiece(piece string) int {
+	r, _ := utf8.DecodeRuneInString(piece)
+	length := utf8.RuneCountInString(piece)
+	switch {
+	case unicode.IsLetter(r):
+		if r > unicode.MaxLatin1 && !unicode.In(r, unicode.Latin, unicode.Greek, unicode.Cyrillic) {
+			// ideographic and most non-alphabetic scripts are close to a token per character
+			return length
+		}
+		if length <= t.family.wordLetters {
+			return 1
+		}
+		return 1 + ceilDiv(length-t.family.wordLetters, t.family.lettersPerToken)
+	case unicode.IsDigit(r):
+		return ceilDiv(length, float64(t.family.digitsPerToken))
+	case unicode.IsSpace(r):
+		if piece == " " {
+			return 0
+		}
+		return ceilDiv(length, whitespacePerToken)
+	}
+	return ceilDiv(length, t.family.symbolsPerToken)
+}
+
+// CountFile counts the tokens of a file on disk
+func CountFile(t Tokenizer, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return t.Count(string(data)), nil
+}
+
+func ceilDiv(n int, per float64) int {
+	return int(math.Ceil(float64(n) / per))
+}
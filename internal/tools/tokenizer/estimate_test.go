@@ -0,0 +1,50 @@
+package tokenizer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madhuravius/brains/internal/tools/tokenizer"
+)
+
+func TestForModel(t *testing.T) {
+	assert.Equal(t, "claude (approximate)", tokenizer.ForModel("us.anthropic.claude-3-5-haiku-20241022-v1:0").Name())
+	assert.Equal(t, "o200k (approximate)", tokenizer.ForModel("openai.gpt-oss-120b-1:0").Name())
+	assert.Equal(t, "llama3 (approximate)", tokenizer.ForModel("meta.llama3-1-8b-instruct-v1:0").Name())
+	assert.Equal(t, "generic (approximate)", tokenizer.ForModel("local-model").Name())
+}
+
+func TestCount(t *testing.T) {
+	o200k := tokenizer.ForModel("openai.gpt-oss-120b-1:0")
+	assert.Zero(t, o200k.Count(""))
+	// common words are single tokens, the o200k tokenizer gives 10
+	assert.Equal(t, 10, o200k.Count("The quick brown fox jumps over the lazy dog."))
+	// identifiers are split, digits are grouped in threes
+	assert.InDelta(t, 5, o200k.Count("converseMessagesFromTurns"), 1)
+	assert.Equal(t, 2, o200k.Count("123456"))
+	// ideographs are close to a token each
+	assert.Equal(t, 4, o200k.Count("你好世界"))
+
+	// Claude's vocabulary is smaller, so the same code costs more tokens
+	code := strings.Repeat("func (a *AWSConfig) SetPricing(pricing []ModelPricing) { a.pricing = pricing }\n", 20)
+	claude := tokenizer.ForModel("anthropic.claude-sonnet-4")
+	assert.Greater(t, claude.Count(code), o200k.Count(code))
+	// and both stay in the usual three to four characters per token range for code
+	assert.InDelta(t, len(code)/4, o200k.Count(code), float64(len(code))/6)
+}
+
+func TestCountFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("hello world"), 0o600))
+
+	tokens, err := tokenizer.CountFile(tokenizer.ForModel("local-model"), path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tokens)
+
+	_, err = tokenizer.CountFile(tokenizer.ForModel("local-model"), filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
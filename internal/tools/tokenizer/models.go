@@ -0,0 +1,33 @@
+package tokenizer
+
+// Tokenizer counts the tokens a model family would see for a piece of text without calling the model
+type Tokenizer interface {
+	Count(text string) int
+	// Name describes the tokenizer, approximations say so
+	Name() string
+}
+
+// approximateTokenizer stands in for a model family whose vocabulary is not available offline, it splits
+// text the way BPE pre-tokenizers do and sizes each piece with ratios calibrated for the family
+type approximateTokenizer struct {
+	family family
+}
+
+type family struct {
+	name string
+	// wordLetters is the length up to which a run of letters (a word) is usually a single token
+	wordLetters int
+	// lettersPerToken is the average length of the tokens longer words and identifiers are split into
+	lettersPerToken float64
+	// symbolsPerToken is the average number of punctuation characters merged into a token, code heavy
+	// vocabularies merge more of them (ex: ":=", "()", "{}")
+	symbolsPerToken float64
+	// digitsPerToken is how many digits a number is split into, 3 for the OpenAI vocabularies and llama 3
+	digitsPerToken int
+}
+
+// FileCount is the token count of a single file
+type FileCount struct {
+	Path   string
+	Tokens int
+}